@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"wms-be/config"
 	"wms-be/domain/repository"
+	"wms-be/domain/services"
 	"wms-be/infrastructure/database"
 	"wms-be/infrastructure/jwt"
 	"wms-be/interfaces/http/router"
@@ -27,6 +29,19 @@ func main() {
 	inboundRepo := repository.NewInboundRepository()
 	outboundRepo := repository.NewOutboundRepository()
 	orderRepo := repository.NewOrderRepository(database.GetDB())
+	passwordResetTokenRepo := repository.NewPasswordResetTokenRepository()
+	revokedAccessTokenRepo := repository.NewRevokedAccessTokenRepository()
+	loginAttemptRepo := repository.NewLoginAttemptRepository()
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository()
+	apiKeyRepo := repository.NewAPIKeyRepository()
+	transferOrderRepo := repository.NewTransferOrderRepository()
+	customerRepo := repository.NewCustomerRepository()
+	supplierRepo := repository.NewSupplierRepository()
+	purchaseOrderRepo := repository.NewPurchaseOrderRepository()
+
+	// Background job: expire order pending_payment yang lewat expires_at
+	orderExpiry := services.NewOrderExpiryScheduler(orderRepo)
+	orderExpiry.Start(context.Background())
 
 	// Setup router with all repositories (including Inbound Repository)
 	r := router.SetupRouter(
@@ -38,6 +53,16 @@ func main() {
 		inboundRepo,
 		outboundRepo,
 		orderRepo,
+		passwordResetTokenRepo,
+		revokedAccessTokenRepo,
+		loginAttemptRepo,
+		recoveryCodeRepo,
+		apiKeyRepo,
+		transferOrderRepo,
+		customerRepo,
+		supplierRepo,
+		purchaseOrderRepo,
+		orderExpiry,
 	)
 
 	// Run the server on port 8000
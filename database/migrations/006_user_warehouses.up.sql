@@ -0,0 +1,13 @@
+-- Gudang tambahan yang boleh diakses user (selain users.warehouse_id)
+
+CREATE TABLE public.user_warehouses (
+	user_id uuid NOT NULL,
+	warehouse_id uuid NOT NULL,
+	created_at timestamptz DEFAULT now() NULL,
+	CONSTRAINT user_warehouses_pkey PRIMARY KEY (user_id, warehouse_id)
+);
+CREATE INDEX idx_user_warehouses_warehouse_id ON public.user_warehouses USING btree (warehouse_id);
+
+-- public.user_warehouses foreign keys
+ALTER TABLE public.user_warehouses ADD CONSTRAINT user_warehouses_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+ALTER TABLE public.user_warehouses ADD CONSTRAINT user_warehouses_warehouse_id_fkey FOREIGN KEY (warehouse_id) REFERENCES public.warehouses(id) ON DELETE CASCADE;
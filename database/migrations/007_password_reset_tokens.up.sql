@@ -0,0 +1,18 @@
+-- Token reset password sekali pakai (disimpan dalam bentuk hash seperti refresh_tokens)
+
+CREATE TABLE public.password_reset_tokens (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	user_id uuid NOT NULL,
+	token_hash text NOT NULL,
+	expires_at timestamptz NOT NULL,
+	used_at timestamptz NULL,
+	created_by uuid NULL,
+	created_at timestamptz DEFAULT now() NULL,
+	CONSTRAINT password_reset_tokens_pkey PRIMARY KEY (id)
+);
+CREATE INDEX idx_password_reset_tokens_token_hash ON public.password_reset_tokens USING btree (token_hash);
+CREATE INDEX idx_password_reset_tokens_user_id ON public.password_reset_tokens USING btree (user_id);
+
+-- public.password_reset_tokens foreign keys
+ALTER TABLE public.password_reset_tokens ADD CONSTRAINT password_reset_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+ALTER TABLE public.password_reset_tokens ADD CONSTRAINT password_reset_tokens_created_by_fkey FOREIGN KEY (created_by) REFERENCES public.users(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS public.idx_refresh_tokens_family_id;
+ALTER TABLE public.refresh_tokens DROP COLUMN IF EXISTS family_id;
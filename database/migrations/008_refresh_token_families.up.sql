@@ -0,0 +1,11 @@
+-- Refresh token dikelompokkan per family (satu family per login)
+
+ALTER TABLE public.refresh_tokens ADD COLUMN family_id uuid NULL;
+
+-- token lama dianggap family sendiri-sendiri
+UPDATE public.refresh_tokens SET family_id = id WHERE family_id IS NULL;
+
+ALTER TABLE public.refresh_tokens ALTER COLUMN family_id SET DEFAULT gen_random_uuid();
+ALTER TABLE public.refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX idx_refresh_tokens_family_id ON public.refresh_tokens USING btree (family_id);
@@ -0,0 +1,7 @@
+-- Info sesi per refresh token (untuk daftar sesi aktif)
+
+ALTER TABLE public.refresh_tokens ADD COLUMN user_agent text NULL;
+ALTER TABLE public.refresh_tokens ADD COLUMN ip_address varchar(45) NULL;
+ALTER TABLE public.refresh_tokens ADD COLUMN last_used_at timestamptz NULL;
+
+UPDATE public.refresh_tokens SET last_used_at = created_at WHERE last_used_at IS NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.user_token_revocations;
+DROP TABLE IF EXISTS public.revoked_access_tokens;
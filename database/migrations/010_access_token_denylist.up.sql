@@ -0,0 +1,22 @@
+-- Denylist access token (per jti) dan batas waktu pencabutan semua token per user
+
+CREATE TABLE public.revoked_access_tokens (
+	jti uuid NOT NULL,
+	user_id uuid NOT NULL,
+	expires_at timestamptz NOT NULL,
+	revoked_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT revoked_access_tokens_pkey PRIMARY KEY (jti)
+);
+CREATE INDEX idx_revoked_access_tokens_expires_at ON public.revoked_access_tokens USING btree (expires_at);
+
+-- token milik user yang diterbitkan pada/sebelum revoked_before dianggap dicabut
+CREATE TABLE public.user_token_revocations (
+	user_id uuid NOT NULL,
+	revoked_before timestamptz NOT NULL,
+	updated_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT user_token_revocations_pkey PRIMARY KEY (user_id)
+);
+
+-- foreign keys
+ALTER TABLE public.revoked_access_tokens ADD CONSTRAINT revoked_access_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+ALTER TABLE public.user_token_revocations ADD CONSTRAINT user_token_revocations_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS public.login_attempts;
+
+ALTER TABLE public.users DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE public.users DROP COLUMN IF EXISTS last_failed_login_at;
+ALTER TABLE public.users DROP COLUMN IF EXISTS failed_login_attempts;
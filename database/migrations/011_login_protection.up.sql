@@ -0,0 +1,22 @@
+-- Proteksi brute-force login: counter gagal per akun, lockout sementara dan audit percobaan login
+
+ALTER TABLE public.users ADD COLUMN failed_login_attempts int4 DEFAULT 0 NOT NULL;
+ALTER TABLE public.users ADD COLUMN last_failed_login_at timestamptz NULL;
+ALTER TABLE public.users ADD COLUMN locked_until timestamptz NULL;
+
+CREATE TABLE public.login_attempts (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	email varchar(100) NOT NULL,
+	user_id uuid NULL,
+	ip_address varchar(45) NULL,
+	user_agent text NULL,
+	success bool NOT NULL,
+	failure_reason varchar(30) NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT login_attempts_pkey PRIMARY KEY (id)
+);
+CREATE INDEX idx_login_attempts_ip_created_at ON public.login_attempts USING btree (ip_address, created_at);
+CREATE INDEX idx_login_attempts_user_created_at ON public.login_attempts USING btree (user_id, created_at);
+
+-- public.login_attempts foreign keys
+ALTER TABLE public.login_attempts ADD CONSTRAINT login_attempts_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE SET NULL;
@@ -0,0 +1,19 @@
+-- TOTP two-factor authentication dan recovery code
+
+ALTER TABLE public.users ADD COLUMN totp_secret text NULL;
+ALTER TABLE public.users ADD COLUMN totp_enabled bool DEFAULT false NOT NULL;
+ALTER TABLE public.users ADD COLUMN totp_last_step int8 NULL;
+
+-- recovery code sekali pakai, disimpan dalam bentuk hash
+CREATE TABLE public.user_recovery_codes (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	user_id uuid NOT NULL,
+	code_hash text NOT NULL,
+	used_at timestamptz NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT user_recovery_codes_pkey PRIMARY KEY (id)
+);
+CREATE INDEX idx_user_recovery_codes_user_id ON public.user_recovery_codes USING btree (user_id);
+
+-- public.user_recovery_codes foreign keys
+ALTER TABLE public.user_recovery_codes ADD CONSTRAINT user_recovery_codes_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+-- Postgres tidak mendukung DROP VALUE pada enum; nilai 'service' dibiarkan
+-- (service account dihapus oleh down migration 014_api_keys).
+SELECT 1;
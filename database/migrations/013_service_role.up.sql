@@ -0,0 +1,3 @@
+-- Role untuk service account milik API key (dipisah dari migration api_keys karena
+-- nilai enum baru tidak boleh dipakai dalam transaksi yang sama dengan ADD VALUE)
+ALTER TYPE public."user_role" ADD VALUE IF NOT EXISTS 'service';
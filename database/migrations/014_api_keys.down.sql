@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS public.api_key_warehouses;
+DROP TABLE IF EXISTS public.api_keys;
+
+-- service account hanya dipakai API key; yang sudah tercatat di inbound/outbound tetap dibiarkan
+DELETE FROM public.users u
+WHERE u."role" = 'service'
+	AND NOT EXISTS (SELECT 1 FROM public.inbounds i WHERE i.created_by = u.id)
+	AND NOT EXISTS (SELECT 1 FROM public.outbounds o WHERE o.created_by = u.id)
+	AND NOT EXISTS (SELECT 1 FROM public.transactions t WHERE t.created_by = u.id);
@@ -0,0 +1,34 @@
+-- API key untuk integrasi machine-to-machine (ERP, e-commerce).
+-- Key disimpan dalam bentuk hash seperti refresh_tokens, setiap key punya service account sendiri.
+
+CREATE TABLE public.api_keys (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	"name" varchar(100) NOT NULL,
+	key_prefix varchar(20) NOT NULL,
+	key_hash text NOT NULL,
+	service_account_id uuid NOT NULL,
+	permissions text DEFAULT ''::text NOT NULL,
+	all_warehouses bool DEFAULT false NOT NULL,
+	expires_at timestamptz NULL,
+	last_used_at timestamptz NULL,
+	last_used_ip varchar(45) NULL,
+	revoked_at timestamptz NULL,
+	created_by uuid NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	updated_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT api_keys_pkey PRIMARY KEY (id),
+	CONSTRAINT api_keys_key_hash_key UNIQUE (key_hash)
+);
+CREATE INDEX idx_api_keys_service_account_id ON public.api_keys USING btree (service_account_id);
+
+CREATE TABLE public.api_key_warehouses (
+	api_key_id uuid NOT NULL,
+	warehouse_id uuid NOT NULL,
+	CONSTRAINT api_key_warehouses_pkey PRIMARY KEY (api_key_id, warehouse_id)
+);
+
+-- foreign keys
+ALTER TABLE public.api_keys ADD CONSTRAINT api_keys_service_account_id_fkey FOREIGN KEY (service_account_id) REFERENCES public.users(id) ON DELETE CASCADE;
+ALTER TABLE public.api_keys ADD CONSTRAINT api_keys_created_by_fkey FOREIGN KEY (created_by) REFERENCES public.users(id) ON DELETE SET NULL;
+ALTER TABLE public.api_key_warehouses ADD CONSTRAINT api_key_warehouses_api_key_id_fkey FOREIGN KEY (api_key_id) REFERENCES public.api_keys(id) ON DELETE CASCADE;
+ALTER TABLE public.api_key_warehouses ADD CONSTRAINT api_key_warehouses_warehouse_id_fkey FOREIGN KEY (warehouse_id) REFERENCES public.warehouses(id) ON DELETE CASCADE;
@@ -0,0 +1,5 @@
+ALTER TABLE public.outbounds DROP CONSTRAINT IF EXISTS outbounds_override_by_fkey;
+
+ALTER TABLE public.outbounds DROP COLUMN IF EXISTS override_by;
+ALTER TABLE public.outbounds DROP COLUMN IF EXISTS override_reason;
+ALTER TABLE public.outbounds DROP COLUMN IF EXISTS stock_override;
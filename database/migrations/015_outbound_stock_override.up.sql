@@ -0,0 +1,8 @@
+-- Outbound divalidasi terhadap available_stock; admin bisa override dengan alasan yang dicatat
+
+ALTER TABLE public.outbounds ADD COLUMN stock_override bool DEFAULT false NOT NULL;
+ALTER TABLE public.outbounds ADD COLUMN override_reason text NULL;
+ALTER TABLE public.outbounds ADD COLUMN override_by uuid NULL;
+
+-- public.outbounds foreign keys
+ALTER TABLE public.outbounds ADD CONSTRAINT outbounds_override_by_fkey FOREIGN KEY (override_by) REFERENCES public.users(id);
@@ -0,0 +1,112 @@
+-- transfer_stock memakai SQLSTATE khusus agar aplikasi bisa memetakan error ke 4xx
+-- tanpa parsing pesan:
+--   WT001 not enough available stock in source product
+--   WT002 destination warehouse capacity exceeded
+--   WT003 source / destination warehouse not found
+--   WT004 source product not found in source warehouse
+--   22023 (invalid_parameter_value) quantity <= 0 atau gudang asal = tujuan
+-- Baris ledger (transactions) ditulis aplikasi dalam transaksi DB yang sama.
+
+CREATE OR REPLACE FUNCTION public.transfer_stock(p_product_id uuid, p_from_warehouse_id uuid, p_to_warehouse_id uuid, p_quantity integer, p_reference_number character varying, p_notes text, p_created_by uuid)
+ RETURNS boolean
+ LANGUAGE plpgsql
+AS $function$
+DECLARE
+  src_id            uuid;
+  src_sku           text;
+  src_stock         int;
+  src_reserved      int;
+  target_id         uuid;
+  from_util         int;
+  from_capacity     int;
+  to_util           int;
+  to_capacity       int;
+BEGIN
+  IF p_quantity <= 0 THEN
+    RAISE EXCEPTION 'quantity must be > 0' USING ERRCODE = '22023';
+  END IF;
+
+  IF p_from_warehouse_id = p_to_warehouse_id THEN
+    RAISE EXCEPTION 'source and destination warehouse must be different' USING ERRCODE = '22023';
+  END IF;
+
+  -- Lock source product row (mengunci agar tidak terjadi race)
+  SELECT id, sku, stock, reserved_stock
+  INTO src_id, src_sku, src_stock, src_reserved
+  FROM products
+  WHERE id = p_product_id
+    AND warehouse_id = p_from_warehouse_id
+  FOR UPDATE;
+
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'source product not found in source warehouse' USING ERRCODE = 'WT004';
+  END IF;
+
+  -- Cek available stock di source (stock - reserved)
+  IF (src_stock - COALESCE(src_reserved,0)) < p_quantity THEN
+    RAISE EXCEPTION 'not enough available stock in source product' USING ERRCODE = 'WT001';
+  END IF;
+
+  -- Lock warehouse rows (FOR UPDATE) untuk mencegah race
+  SELECT current_utilization, capacity
+  INTO from_util, from_capacity
+  FROM warehouses
+  WHERE id = p_from_warehouse_id
+  FOR UPDATE;
+
+  SELECT current_utilization, capacity
+  INTO to_util, to_capacity
+  FROM warehouses
+  WHERE id = p_to_warehouse_id
+  FOR UPDATE;
+
+  IF from_util IS NULL THEN
+    RAISE EXCEPTION 'source warehouse not found' USING ERRCODE = 'WT003';
+  END IF;
+  IF to_util IS NULL THEN
+    RAISE EXCEPTION 'destination warehouse not found' USING ERRCODE = 'WT003';
+  END IF;
+
+  -- cek apakah pengurangan dari sumber tidak membuat negative utilization
+  IF (from_util - p_quantity) < 0 THEN
+    RAISE EXCEPTION 'transfer would make source warehouse utilization negative' USING ERRCODE = 'WT001';
+  END IF;
+
+  -- cek apakah tujuan punya kapasitas untuk menampung tambahan
+  IF (to_util + p_quantity) > to_capacity THEN
+    RAISE EXCEPTION 'destination warehouse does not have enough capacity' USING ERRCODE = 'WT002';
+  END IF;
+
+  -- Cari apakah product dengan sku yang sama sudah ada di tujuan (lock jika ada)
+  SELECT id INTO target_id
+  FROM products
+  WHERE sku = src_sku
+    AND warehouse_id = p_to_warehouse_id
+  FOR UPDATE;
+
+  IF target_id IS NULL THEN
+    -- jika belum ada, copy product (masukkan initial stock = p_quantity)
+    INSERT INTO products (
+      name, sku, description, price, stock, warehouse_id, category, min_stock, created_at, updated_at, is_active
+    )
+    SELECT name, sku, description, price, p_quantity, p_to_warehouse_id, category, min_stock, now(), now(), is_active
+    FROM products
+    WHERE id = p_product_id
+    RETURNING id INTO target_id;
+  ELSE
+    -- jika ada, tambahkan stock
+    UPDATE products
+    SET stock = stock + p_quantity,
+        updated_at = now()
+    WHERE id = target_id;
+  END IF;
+
+  -- Kurangi stock di source
+  UPDATE products
+  SET stock = stock - p_quantity,
+      updated_at = now()
+  WHERE id = src_id;
+
+  RETURN TRUE;
+END;
+$function$;
@@ -0,0 +1,7 @@
+ALTER TABLE public.transactions DROP CONSTRAINT IF EXISTS transactions_transfer_order_id_fkey;
+DROP INDEX IF EXISTS idx_transactions_transfer_order_id;
+ALTER TABLE public.transactions DROP COLUMN IF EXISTS transfer_order_id;
+
+DROP TABLE IF EXISTS public.transfer_order_receipts;
+DROP TABLE IF EXISTS public.transfer_orders;
+DROP TYPE IF EXISTS public."transfer_order_status";
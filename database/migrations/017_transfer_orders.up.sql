@@ -0,0 +1,80 @@
+-- Transfer antar gudang dua fase: stok keluar dari gudang asal saat dispatch,
+-- berada "in transit" (tidak dihitung di available_stock gudang mana pun),
+-- dan masuk ke gudang tujuan per penerimaan (boleh parsial).
+
+CREATE TYPE public."transfer_order_status" AS ENUM ('requested','approved','dispatched','in_transit','received','cancelled');
+
+CREATE TABLE public.transfer_orders (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	transfer_number varchar(50) NOT NULL,
+	product_id uuid NOT NULL,
+	destination_product_id uuid NULL,
+	from_warehouse_id uuid NOT NULL,
+	to_warehouse_id uuid NOT NULL,
+	quantity int4 NOT NULL,
+	quantity_received int4 DEFAULT 0 NOT NULL,
+	discrepancy_quantity int4 DEFAULT 0 NOT NULL,
+	discrepancy_notes text NULL,
+	status public."transfer_order_status" DEFAULT 'requested'::transfer_order_status NOT NULL,
+	reference_number varchar(100) NULL,
+	notes text NULL,
+	requested_by uuid NOT NULL,
+	requested_at timestamptz DEFAULT now() NOT NULL,
+	approved_by uuid NULL,
+	approved_at timestamptz NULL,
+	dispatched_by uuid NULL,
+	dispatched_at timestamptz NULL,
+	in_transit_by uuid NULL,
+	in_transit_at timestamptz NULL,
+	received_by uuid NULL,
+	received_at timestamptz NULL,
+	cancelled_by uuid NULL,
+	cancelled_at timestamptz NULL,
+	cancel_reason text NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	updated_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT transfer_orders_pkey PRIMARY KEY (id),
+	CONSTRAINT transfer_orders_transfer_number_key UNIQUE (transfer_number),
+	CONSTRAINT transfer_orders_quantity_check CHECK ((quantity > 0)),
+	CONSTRAINT transfer_orders_received_check CHECK ((quantity_received >= 0 AND discrepancy_quantity >= 0 AND quantity_received + discrepancy_quantity <= quantity)),
+	CONSTRAINT transfer_orders_warehouses_check CHECK ((from_warehouse_id <> to_warehouse_id))
+);
+CREATE INDEX idx_transfer_orders_status ON public.transfer_orders USING btree (status);
+CREATE INDEX idx_transfer_orders_from_warehouse_id ON public.transfer_orders USING btree (from_warehouse_id);
+CREATE INDEX idx_transfer_orders_to_warehouse_id ON public.transfer_orders USING btree (to_warehouse_id);
+CREATE INDEX idx_transfer_orders_product_id ON public.transfer_orders USING btree (product_id);
+CREATE INDEX idx_transfer_orders_created_at ON public.transfer_orders USING btree (created_at DESC);
+
+-- public.transfer_orders foreign keys
+ALTER TABLE public.transfer_orders ADD CONSTRAINT transfer_orders_product_id_fkey FOREIGN KEY (product_id) REFERENCES public.products(id);
+ALTER TABLE public.transfer_orders ADD CONSTRAINT transfer_orders_destination_product_id_fkey FOREIGN KEY (destination_product_id) REFERENCES public.products(id);
+ALTER TABLE public.transfer_orders ADD CONSTRAINT transfer_orders_from_warehouse_id_fkey FOREIGN KEY (from_warehouse_id) REFERENCES public.warehouses(id);
+ALTER TABLE public.transfer_orders ADD CONSTRAINT transfer_orders_to_warehouse_id_fkey FOREIGN KEY (to_warehouse_id) REFERENCES public.warehouses(id);
+ALTER TABLE public.transfer_orders ADD CONSTRAINT transfer_orders_requested_by_fkey FOREIGN KEY (requested_by) REFERENCES public.users(id);
+ALTER TABLE public.transfer_orders ADD CONSTRAINT transfer_orders_approved_by_fkey FOREIGN KEY (approved_by) REFERENCES public.users(id);
+ALTER TABLE public.transfer_orders ADD CONSTRAINT transfer_orders_dispatched_by_fkey FOREIGN KEY (dispatched_by) REFERENCES public.users(id);
+ALTER TABLE public.transfer_orders ADD CONSTRAINT transfer_orders_in_transit_by_fkey FOREIGN KEY (in_transit_by) REFERENCES public.users(id);
+ALTER TABLE public.transfer_orders ADD CONSTRAINT transfer_orders_received_by_fkey FOREIGN KEY (received_by) REFERENCES public.users(id);
+ALTER TABLE public.transfer_orders ADD CONSTRAINT transfer_orders_cancelled_by_fkey FOREIGN KEY (cancelled_by) REFERENCES public.users(id);
+
+-- Penerimaan (parsial) di gudang tujuan
+CREATE TABLE public.transfer_order_receipts (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	transfer_order_id uuid NOT NULL,
+	quantity int4 NOT NULL,
+	notes text NULL,
+	received_by uuid NOT NULL,
+	received_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT transfer_order_receipts_pkey PRIMARY KEY (id),
+	CONSTRAINT transfer_order_receipts_quantity_check CHECK ((quantity >= 0))
+);
+CREATE INDEX idx_transfer_order_receipts_transfer_order_id ON public.transfer_order_receipts USING btree (transfer_order_id);
+
+-- public.transfer_order_receipts foreign keys
+ALTER TABLE public.transfer_order_receipts ADD CONSTRAINT transfer_order_receipts_transfer_order_id_fkey FOREIGN KEY (transfer_order_id) REFERENCES public.transfer_orders(id) ON DELETE CASCADE;
+ALTER TABLE public.transfer_order_receipts ADD CONSTRAINT transfer_order_receipts_received_by_fkey FOREIGN KEY (received_by) REFERENCES public.users(id);
+
+-- Ledger: baris transactions dari dispatch / penerimaan merujuk ke transfer order
+ALTER TABLE public.transactions ADD COLUMN transfer_order_id uuid NULL;
+CREATE INDEX idx_transactions_transfer_order_id ON public.transactions USING btree (transfer_order_id);
+ALTER TABLE public.transactions ADD CONSTRAINT transactions_transfer_order_id_fkey FOREIGN KEY (transfer_order_id) REFERENCES public.transfer_orders(id);
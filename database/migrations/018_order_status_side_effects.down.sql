@@ -0,0 +1,35 @@
+CREATE OR REPLACE FUNCTION public.update_products_on_order_status_change()
+ RETURNS trigger
+ LANGUAGE plpgsql
+AS $function$
+BEGIN
+    -- Pending_payment -> Expired
+     IF NEW.status = 'expired' AND OLD.status <> 'expired' THEN
+        UPDATE products p
+        SET reserved_stock = reserved_stock - oi.quantity
+        FROM order_items oi
+        WHERE oi.order_id = NEW.id AND p.id = oi.product_id;
+
+    -- Pending_payment -> Cancelled
+    ELSIF NEW.status = 'cancelled' AND OLD.status <> 'cancelled' THEN
+        UPDATE products p
+        SET reserved_stock = reserved_stock - oi.quantity
+        FROM order_items oi
+        WHERE oi.order_id = NEW.id AND p.id = oi.product_id;
+
+    -- processing -> Shipped
+    ELSIF NEW.status = 'shipped' AND OLD.status <> 'shipped' THEN
+        UPDATE products p
+        SET reserved_stock = reserved_stock - oi.quantity,
+            stock = stock - oi.quantity
+        FROM order_items oi
+        WHERE oi.order_id = NEW.id AND p.id = oi.product_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$function$;
+
+-- Table Triggers
+create trigger trg_update_products_on_order_status after
+update on public.orders for each row execute function update_products_on_order_status_change();
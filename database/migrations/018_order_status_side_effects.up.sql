@@ -0,0 +1,6 @@
+-- Efek stok perubahan status order (release reserved_stock / kurangi stock) sekarang
+-- dijalankan aplikasi bersama validasi transisi status dalam satu transaksi,
+-- jadi trigger lama dilepas agar efeknya tidak teraplikasi dua kali.
+
+DROP TRIGGER IF EXISTS trg_update_products_on_order_status ON public.orders;
+DROP FUNCTION IF EXISTS public.update_products_on_order_status_change();
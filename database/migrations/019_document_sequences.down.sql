@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_inbounds_receipt_number;
+ALTER TABLE public.inbounds DROP COLUMN IF EXISTS receipt_number;
+
+DROP TABLE IF EXISTS public.document_sequences;
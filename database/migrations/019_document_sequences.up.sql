@@ -0,0 +1,19 @@
+-- Penomoran dokumen dari server (order, inbound, outbound, transfer).
+-- Counter per jenis dokumen, gudang dan periode di-increment dalam transaksi yang sama
+-- dengan insert dokumen, jadi rollback juga membatalkan nomor (tanpa celah).
+
+CREATE TABLE public.document_sequences (
+	document_type varchar(30) NOT NULL,
+	warehouse_id uuid NOT NULL,
+	"period" varchar(8) DEFAULT '' NOT NULL,
+	last_value int8 DEFAULT 0 NOT NULL,
+	updated_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT document_sequences_pkey PRIMARY KEY (document_type, warehouse_id, period)
+);
+
+-- public.document_sequences foreign keys
+ALTER TABLE public.document_sequences ADD CONSTRAINT document_sequences_warehouse_id_fkey FOREIGN KEY (warehouse_id) REFERENCES public.warehouses(id) ON DELETE CASCADE;
+
+-- Nomor penerimaan barang (reference_number inbound tetap nomor dokumen dari supplier)
+ALTER TABLE public.inbounds ADD COLUMN receipt_number varchar(50) NULL;
+CREATE UNIQUE INDEX idx_inbounds_receipt_number ON public.inbounds USING btree (receipt_number);
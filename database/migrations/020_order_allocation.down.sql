@@ -0,0 +1,17 @@
+CREATE OR REPLACE FUNCTION public.update_reserved_stock_on_insert()
+ RETURNS trigger
+ LANGUAGE plpgsql
+AS $function$
+BEGIN
+    IF EXISTS (SELECT 1 FROM orders WHERE id = NEW.order_id AND status = 'pending_payment') THEN
+        UPDATE products
+        SET reserved_stock = reserved_stock + NEW.quantity
+        WHERE id = NEW.product_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$function$;
+
+create trigger trg_update_reserved_stock_on_insert after
+insert on public.order_items for each row execute function update_reserved_stock_on_insert();
@@ -0,0 +1,6 @@
+-- Reservasi stok saat order dibuat sekarang dilakukan aplikasi setelah cek available_stock
+-- per item terhadap baris product yang dikunci (FOR UPDATE), di transaksi yang sama dengan
+-- insert order. Trigger lama mereservasi tanpa cek stok maupun gudang, jadi dilepas.
+
+DROP TRIGGER IF EXISTS trg_update_reserved_stock_on_insert ON public.order_items;
+DROP FUNCTION IF EXISTS public.update_reserved_stock_on_insert();
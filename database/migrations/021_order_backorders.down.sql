@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS public.order_shipment_items;
+DROP TABLE IF EXISTS public.order_shipments;
+
+DROP INDEX IF EXISTS idx_order_items_backordered;
+ALTER TABLE public.order_items
+	DROP CONSTRAINT IF EXISTS order_items_fulfilment_check,
+	DROP COLUMN IF EXISTS allocated_quantity,
+	DROP COLUMN IF EXISTS picked_quantity,
+	DROP COLUMN IF EXISTS shipped_quantity,
+	DROP COLUMN IF EXISTS backordered_quantity;
+
+-- Nilai enum tidak bisa dihapus dari order_status, order yang terkirim sebagian
+-- dikembalikan ke processing
+UPDATE public.orders SET status = 'processing' WHERE status = 'partially_shipped';
@@ -0,0 +1,70 @@
+-- Backorder dan pengiriman parsial order.
+-- Per item: quantity = shipped_quantity + allocated_quantity + backordered_quantity.
+-- allocated_quantity adalah bagian yang direservasi (reserved_stock), picked_quantity bagian
+-- yang sudah diambil dari rak dan siap dikirim (selalu <= allocated_quantity).
+
+ALTER TYPE public."order_status" ADD VALUE IF NOT EXISTS 'partially_shipped' AFTER 'processing';
+
+ALTER TABLE public.order_items
+	ADD COLUMN allocated_quantity int4 DEFAULT 0 NOT NULL,
+	ADD COLUMN picked_quantity int4 DEFAULT 0 NOT NULL,
+	ADD COLUMN shipped_quantity int4 DEFAULT 0 NOT NULL,
+	ADD COLUMN backordered_quantity int4 DEFAULT 0 NOT NULL;
+
+-- Order lama: item order aktif sudah direservasi penuh, order terkirim sudah dikirim penuh
+UPDATE public.order_items oi
+SET allocated_quantity = oi.quantity
+FROM public.orders o
+WHERE o.id = oi.order_id AND o.status IN ('pending_payment', 'confirmed', 'processing');
+
+UPDATE public.order_items oi
+SET shipped_quantity = oi.quantity
+FROM public.orders o
+WHERE o.id = oi.order_id AND o.status IN ('shipped', 'delivered');
+
+ALTER TABLE public.order_items
+	ADD CONSTRAINT order_items_fulfilment_check CHECK (
+		allocated_quantity >= 0 AND picked_quantity >= 0 AND shipped_quantity >= 0 AND backordered_quantity >= 0
+		AND picked_quantity <= allocated_quantity
+		AND shipped_quantity + allocated_quantity + backordered_quantity <= quantity
+	);
+
+-- Item yang masih menunggu stok, untuk alokasi otomatis saat inbound
+CREATE INDEX idx_order_items_backordered ON public.order_items USING btree (product_id) WHERE (backordered_quantity > 0);
+
+-- public.order_shipments definition
+
+CREATE TABLE public.order_shipments (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	order_id uuid NOT NULL,
+	shipment_number varchar(50) NOT NULL,
+	notes text NULL,
+	shipped_by uuid NOT NULL,
+	shipped_at timestamptz DEFAULT now() NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT order_shipments_pkey PRIMARY KEY (id),
+	CONSTRAINT order_shipments_shipment_number_key UNIQUE (shipment_number)
+);
+CREATE INDEX idx_order_shipments_order_id ON public.order_shipments USING btree (order_id);
+
+-- public.order_shipments foreign keys
+ALTER TABLE public.order_shipments ADD CONSTRAINT order_shipments_order_id_fkey FOREIGN KEY (order_id) REFERENCES public.orders(id) ON DELETE CASCADE;
+ALTER TABLE public.order_shipments ADD CONSTRAINT order_shipments_shipped_by_fkey FOREIGN KEY (shipped_by) REFERENCES public.users(id);
+
+-- public.order_shipment_items definition
+
+CREATE TABLE public.order_shipment_items (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	shipment_id uuid NOT NULL,
+	order_item_id uuid NOT NULL,
+	product_id uuid NOT NULL,
+	quantity int4 NOT NULL,
+	CONSTRAINT order_shipment_items_pkey PRIMARY KEY (id),
+	CONSTRAINT order_shipment_items_quantity_check CHECK ((quantity > 0))
+);
+CREATE INDEX idx_order_shipment_items_shipment_id ON public.order_shipment_items USING btree (shipment_id);
+
+-- public.order_shipment_items foreign keys
+ALTER TABLE public.order_shipment_items ADD CONSTRAINT order_shipment_items_shipment_id_fkey FOREIGN KEY (shipment_id) REFERENCES public.order_shipments(id) ON DELETE CASCADE;
+ALTER TABLE public.order_shipment_items ADD CONSTRAINT order_shipment_items_order_item_id_fkey FOREIGN KEY (order_item_id) REFERENCES public.order_items(id) ON DELETE CASCADE;
+ALTER TABLE public.order_shipment_items ADD CONSTRAINT order_shipment_items_product_id_fkey FOREIGN KEY (product_id) REFERENCES public.products(id);
@@ -0,0 +1,26 @@
+-- Riwayat perubahan item order (tambah, ubah quantity, hapus) selama order masih
+-- pending_payment / confirmed. order_item_id sengaja tanpa foreign key agar riwayat
+-- item yang dihapus tetap ada.
+
+-- public.order_item_changes definition
+
+CREATE TABLE public.order_item_changes (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	order_id uuid NOT NULL,
+	order_item_id uuid NOT NULL,
+	product_id uuid NOT NULL,
+	"action" varchar(20) NOT NULL,
+	old_quantity int4 DEFAULT 0 NOT NULL,
+	new_quantity int4 DEFAULT 0 NOT NULL,
+	reserved_delta int4 DEFAULT 0 NOT NULL,
+	changed_by uuid NOT NULL,
+	changed_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT order_item_changes_pkey PRIMARY KEY (id),
+	CONSTRAINT order_item_changes_action_check CHECK ((action IN ('added', 'updated', 'removed')))
+);
+CREATE INDEX idx_order_item_changes_order_id ON public.order_item_changes USING btree (order_id, changed_at);
+
+-- public.order_item_changes foreign keys
+ALTER TABLE public.order_item_changes ADD CONSTRAINT order_item_changes_order_id_fkey FOREIGN KEY (order_id) REFERENCES public.orders(id) ON DELETE CASCADE;
+ALTER TABLE public.order_item_changes ADD CONSTRAINT order_item_changes_product_id_fkey FOREIGN KEY (product_id) REFERENCES public.products(id);
+ALTER TABLE public.order_item_changes ADD CONSTRAINT order_item_changes_changed_by_fkey FOREIGN KEY (changed_by) REFERENCES public.users(id);
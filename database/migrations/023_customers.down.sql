@@ -0,0 +1,17 @@
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_billing_address_id_fkey;
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_shipping_address_id_fkey;
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_customer_id_fkey;
+DROP INDEX IF EXISTS idx_orders_customer_id;
+
+ALTER TABLE public.orders
+	DROP COLUMN IF EXISTS customer_id,
+	DROP COLUMN IF EXISTS shipping_address_id,
+	DROP COLUMN IF EXISTS shipping_address,
+	DROP COLUMN IF EXISTS billing_address_id,
+	DROP COLUMN IF EXISTS billing_address;
+
+ALTER INDEX idx_orders_customer_code RENAME TO idx_orders_customer_id;
+ALTER TABLE public.orders RENAME COLUMN customer_code TO customer_id;
+
+DROP TABLE IF EXISTS public.customer_addresses;
+DROP TABLE IF EXISTS public.customers;
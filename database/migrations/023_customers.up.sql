@@ -0,0 +1,82 @@
+-- Master data customer. Order sebelumnya menyimpan customer_id / customer_name sebagai teks
+-- bebas; customer dibuat dari data order lama (customer_id lama menjadi code) dan order
+-- sekarang mereferensikan customer beserta alamat kirim / tagih yang dipilih.
+
+-- public.customers definition
+
+CREATE TABLE public.customers (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	code varchar(100) NOT NULL,
+	"name" varchar(100) NOT NULL,
+	contact_person varchar(100) NULL,
+	email varchar(100) NULL,
+	phone varchar(50) NULL,
+	tax_id varchar(50) NULL,
+	credit_limit numeric(15, 2) DEFAULT 0.00 NOT NULL,
+	is_active bool DEFAULT true NOT NULL,
+	notes text NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	updated_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT customers_pkey PRIMARY KEY (id),
+	CONSTRAINT customers_code_key UNIQUE (code),
+	CONSTRAINT customers_credit_limit_check CHECK ((credit_limit >= 0))
+);
+CREATE INDEX idx_customers_name ON public.customers USING btree (name);
+
+-- public.customer_addresses definition
+
+CREATE TABLE public.customer_addresses (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	customer_id uuid NOT NULL,
+	address_type varchar(20) NOT NULL,
+	"label" varchar(100) NULL,
+	recipient_name varchar(100) NULL,
+	phone varchar(50) NULL,
+	address text NOT NULL,
+	city varchar(100) NULL,
+	province varchar(100) NULL,
+	postal_code varchar(20) NULL,
+	country varchar(100) NULL,
+	is_default bool DEFAULT false NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	updated_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT customer_addresses_pkey PRIMARY KEY (id),
+	CONSTRAINT customer_addresses_type_check CHECK ((address_type IN ('shipping', 'billing')))
+);
+CREATE INDEX idx_customer_addresses_customer_id ON public.customer_addresses USING btree (customer_id);
+-- satu alamat default per jenis per customer
+CREATE UNIQUE INDEX idx_customer_addresses_default ON public.customer_addresses USING btree (customer_id, address_type) WHERE is_default;
+
+-- public.customer_addresses foreign keys
+ALTER TABLE public.customer_addresses ADD CONSTRAINT customer_addresses_customer_id_fkey FOREIGN KEY (customer_id) REFERENCES public.customers(id) ON DELETE CASCADE;
+
+-- Customer dari order lama, nama diambil dari order terbaru
+INSERT INTO public.customers (code, name)
+SELECT DISTINCT ON (customer_id) customer_id, customer_name
+FROM public.orders
+ORDER BY customer_id, created_at DESC;
+
+-- orders.customer_id lama (teks) menjadi customer_code, customer_id sekarang referensi ke customers.
+-- customer_code, customer_name dan alamat disimpan sebagai snapshot saat order dibuat.
+ALTER TABLE public.orders RENAME COLUMN customer_id TO customer_code;
+ALTER INDEX idx_orders_customer_id RENAME TO idx_orders_customer_code;
+
+ALTER TABLE public.orders
+	ADD COLUMN customer_id uuid NULL,
+	ADD COLUMN shipping_address_id uuid NULL,
+	ADD COLUMN shipping_address text NULL,
+	ADD COLUMN billing_address_id uuid NULL,
+	ADD COLUMN billing_address text NULL;
+
+UPDATE public.orders o
+SET customer_id = c.id
+FROM public.customers c
+WHERE c.code = o.customer_code;
+
+ALTER TABLE public.orders ALTER COLUMN customer_id SET NOT NULL;
+CREATE INDEX idx_orders_customer_id ON public.orders USING btree (customer_id, created_at DESC);
+
+-- public.orders foreign keys
+ALTER TABLE public.orders ADD CONSTRAINT orders_customer_id_fkey FOREIGN KEY (customer_id) REFERENCES public.customers(id);
+ALTER TABLE public.orders ADD CONSTRAINT orders_shipping_address_id_fkey FOREIGN KEY (shipping_address_id) REFERENCES public.customer_addresses(id) ON DELETE SET NULL;
+ALTER TABLE public.orders ADD CONSTRAINT orders_billing_address_id_fkey FOREIGN KEY (billing_address_id) REFERENCES public.customer_addresses(id) ON DELETE SET NULL;
@@ -0,0 +1,9 @@
+ALTER TABLE public.inbounds DROP CONSTRAINT IF EXISTS inbounds_supplier_id_fkey;
+DROP INDEX IF EXISTS idx_inbounds_supplier_id;
+
+ALTER TABLE public.inbounds
+	DROP COLUMN IF EXISTS supplier_id,
+	DROP COLUMN IF EXISTS ordered_date,
+	DROP COLUMN IF EXISTS expected_date;
+
+DROP TABLE IF EXISTS public.suppliers;
@@ -0,0 +1,73 @@
+-- Master data supplier. Inbound sebelumnya menyimpan supplier_name / supplier_contact sebagai
+-- teks bebas; supplier dibuat dari data inbound lama (nama yang hanya beda huruf besar/kecil
+-- dan spasi digabung) dan inbound sekarang mereferensikan supplier.
+
+-- public.suppliers definition
+
+CREATE TABLE public.suppliers (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	code varchar(100) NOT NULL,
+	"name" varchar(100) NOT NULL,
+	contact_person varchar(100) NULL,
+	email varchar(100) NULL,
+	phone varchar(50) NULL,
+	address text NULL,
+	tax_id varchar(50) NULL,
+	lead_time_days int4 DEFAULT 0 NOT NULL,
+	is_active bool DEFAULT true NOT NULL,
+	notes text NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	updated_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT suppliers_pkey PRIMARY KEY (id),
+	CONSTRAINT suppliers_code_key UNIQUE (code),
+	CONSTRAINT suppliers_lead_time_days_check CHECK ((lead_time_days >= 0))
+);
+CREATE INDEX idx_suppliers_name ON public.suppliers USING btree (name);
+
+-- Supplier dari inbound lama: satu per nama ternormalisasi, ejaan yang paling sering dipakai
+-- menjadi nama, kontak diambil dari inbound terbaru yang mengisinya
+WITH names AS (
+	SELECT
+		lower(regexp_replace(btrim(supplier_name), '\s+', ' ', 'g')) AS normalized_name,
+		regexp_replace(btrim(supplier_name), '\s+', ' ', 'g') AS supplier_name,
+		NULLIF(btrim(supplier_contact), '') AS supplier_contact,
+		received_date
+	FROM public.inbounds
+),
+spellings AS (
+	SELECT DISTINCT ON (normalized_name) normalized_name, supplier_name
+	FROM names
+	GROUP BY normalized_name, supplier_name
+	ORDER BY normalized_name, COUNT(*) DESC, supplier_name
+),
+contacts AS (
+	SELECT DISTINCT ON (normalized_name) normalized_name, supplier_contact
+	FROM names
+	WHERE supplier_contact IS NOT NULL
+	ORDER BY normalized_name, received_date DESC
+)
+INSERT INTO public.suppliers (code, name, contact_person)
+SELECT
+	'SUP-' || lpad((row_number() OVER (ORDER BY s.normalized_name))::text, 4, '0'),
+	s.supplier_name,
+	c.supplier_contact
+FROM spellings s
+LEFT JOIN contacts c ON c.normalized_name = s.normalized_name;
+
+-- inbounds.supplier_id referensi ke suppliers; supplier_name tetap disimpan sebagai snapshot.
+-- ordered_date / expected_date untuk laporan lead time & ketepatan waktu supplier.
+ALTER TABLE public.inbounds
+	ADD COLUMN supplier_id uuid NULL,
+	ADD COLUMN ordered_date timestamptz NULL,
+	ADD COLUMN expected_date timestamptz NULL;
+
+UPDATE public.inbounds i
+SET supplier_id = s.id
+FROM public.suppliers s
+WHERE lower(s.name) = lower(regexp_replace(btrim(i.supplier_name), '\s+', ' ', 'g'));
+
+ALTER TABLE public.inbounds ALTER COLUMN supplier_id SET NOT NULL;
+CREATE INDEX idx_inbounds_supplier_id ON public.inbounds USING btree (supplier_id, received_date DESC);
+
+-- public.inbounds foreign keys
+ALTER TABLE public.inbounds ADD CONSTRAINT inbounds_supplier_id_fkey FOREIGN KEY (supplier_id) REFERENCES public.suppliers(id);
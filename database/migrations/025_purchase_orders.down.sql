@@ -0,0 +1,12 @@
+ALTER TABLE public.inbounds DROP CONSTRAINT IF EXISTS inbounds_purchase_order_line_id_fkey;
+ALTER TABLE public.inbounds DROP CONSTRAINT IF EXISTS inbounds_purchase_order_id_fkey;
+DROP INDEX IF EXISTS idx_inbounds_purchase_order_line_id;
+DROP INDEX IF EXISTS idx_inbounds_purchase_order_id;
+
+ALTER TABLE public.inbounds
+	DROP COLUMN IF EXISTS purchase_order_line_id,
+	DROP COLUMN IF EXISTS purchase_order_id;
+
+DROP TABLE IF EXISTS public.purchase_order_lines;
+DROP TABLE IF EXISTS public.purchase_orders;
+DROP TYPE IF EXISTS public."purchase_order_status";
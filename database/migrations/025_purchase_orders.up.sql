@@ -0,0 +1,79 @@
+-- Purchase order ke supplier. Inbound bisa mereferensikan line PO: quantity diterima
+-- diakumulasi per line, penerimaan lebih dibatasi toleransi over-receipt dan PO tertutup
+-- otomatis jika semua line sudah diterima (dikurangi toleransi under-receipt).
+
+CREATE TYPE public."purchase_order_status" AS ENUM ('open','partially_received','closed','cancelled');
+
+CREATE TABLE public.purchase_orders (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	po_number varchar(50) NOT NULL,
+	supplier_id uuid NOT NULL,
+	warehouse_id uuid NOT NULL,
+	status public."purchase_order_status" DEFAULT 'open'::purchase_order_status NOT NULL,
+	order_date timestamptz DEFAULT now() NOT NULL,
+	expected_date timestamptz NULL,
+	over_receipt_tolerance numeric(5, 2) DEFAULT 0 NOT NULL,
+	under_receipt_tolerance numeric(5, 2) DEFAULT 0 NOT NULL,
+	total_amount numeric(15, 2) DEFAULT 0.00 NOT NULL,
+	reference_number varchar(100) NULL,
+	notes text NULL,
+	created_by uuid NOT NULL,
+	closed_by uuid NULL,
+	closed_at timestamptz NULL,
+	close_reason text NULL,
+	cancelled_by uuid NULL,
+	cancelled_at timestamptz NULL,
+	cancel_reason text NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	updated_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT purchase_orders_pkey PRIMARY KEY (id),
+	CONSTRAINT purchase_orders_po_number_key UNIQUE (po_number),
+	CONSTRAINT purchase_orders_tolerance_check CHECK ((over_receipt_tolerance BETWEEN 0 AND 100 AND under_receipt_tolerance BETWEEN 0 AND 100))
+);
+CREATE INDEX idx_purchase_orders_supplier_id ON public.purchase_orders USING btree (supplier_id);
+CREATE INDEX idx_purchase_orders_warehouse_id ON public.purchase_orders USING btree (warehouse_id);
+CREATE INDEX idx_purchase_orders_status ON public.purchase_orders USING btree (status);
+CREATE INDEX idx_purchase_orders_created_at ON public.purchase_orders USING btree (created_at DESC);
+
+-- public.purchase_orders foreign keys
+ALTER TABLE public.purchase_orders ADD CONSTRAINT purchase_orders_supplier_id_fkey FOREIGN KEY (supplier_id) REFERENCES public.suppliers(id);
+ALTER TABLE public.purchase_orders ADD CONSTRAINT purchase_orders_warehouse_id_fkey FOREIGN KEY (warehouse_id) REFERENCES public.warehouses(id);
+ALTER TABLE public.purchase_orders ADD CONSTRAINT purchase_orders_created_by_fkey FOREIGN KEY (created_by) REFERENCES public.users(id);
+ALTER TABLE public.purchase_orders ADD CONSTRAINT purchase_orders_closed_by_fkey FOREIGN KEY (closed_by) REFERENCES public.users(id);
+ALTER TABLE public.purchase_orders ADD CONSTRAINT purchase_orders_cancelled_by_fkey FOREIGN KEY (cancelled_by) REFERENCES public.users(id);
+
+-- public.purchase_order_lines definition
+
+CREATE TABLE public.purchase_order_lines (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	purchase_order_id uuid NOT NULL,
+	product_id uuid NOT NULL,
+	ordered_quantity int4 NOT NULL,
+	received_quantity int4 DEFAULT 0 NOT NULL,
+	unit_cost numeric(15, 2) DEFAULT 0.00 NOT NULL,
+	total_cost numeric(15, 2) DEFAULT 0.00 NOT NULL,
+	notes text NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	updated_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT purchase_order_lines_pkey PRIMARY KEY (id),
+	CONSTRAINT purchase_order_lines_ordered_quantity_check CHECK ((ordered_quantity > 0)),
+	CONSTRAINT purchase_order_lines_received_quantity_check CHECK ((received_quantity >= 0)),
+	CONSTRAINT purchase_order_lines_unit_cost_check CHECK ((unit_cost >= 0))
+);
+CREATE INDEX idx_purchase_order_lines_purchase_order_id ON public.purchase_order_lines USING btree (purchase_order_id);
+CREATE INDEX idx_purchase_order_lines_product_id ON public.purchase_order_lines USING btree (product_id);
+
+-- public.purchase_order_lines foreign keys
+ALTER TABLE public.purchase_order_lines ADD CONSTRAINT purchase_order_lines_purchase_order_id_fkey FOREIGN KEY (purchase_order_id) REFERENCES public.purchase_orders(id) ON DELETE CASCADE;
+ALTER TABLE public.purchase_order_lines ADD CONSTRAINT purchase_order_lines_product_id_fkey FOREIGN KEY (product_id) REFERENCES public.products(id);
+
+-- Inbound yang diterima terhadap line PO
+ALTER TABLE public.inbounds
+	ADD COLUMN purchase_order_id uuid NULL,
+	ADD COLUMN purchase_order_line_id uuid NULL;
+CREATE INDEX idx_inbounds_purchase_order_id ON public.inbounds USING btree (purchase_order_id);
+CREATE INDEX idx_inbounds_purchase_order_line_id ON public.inbounds USING btree (purchase_order_line_id);
+
+-- public.inbounds foreign keys
+ALTER TABLE public.inbounds ADD CONSTRAINT inbounds_purchase_order_id_fkey FOREIGN KEY (purchase_order_id) REFERENCES public.purchase_orders(id);
+ALTER TABLE public.inbounds ADD CONSTRAINT inbounds_purchase_order_line_id_fkey FOREIGN KEY (purchase_order_line_id) REFERENCES public.purchase_order_lines(id);
@@ -0,0 +1,5 @@
+ALTER TABLE public.inbounds DROP CONSTRAINT IF EXISTS inbounds_inbound_receipt_id_fkey;
+DROP INDEX IF EXISTS idx_inbounds_inbound_receipt_id;
+ALTER TABLE public.inbounds DROP COLUMN IF EXISTS inbound_receipt_id;
+
+DROP TABLE IF EXISTS public.inbound_receipts;
@@ -0,0 +1,35 @@
+-- Penerimaan barang multi-line: satu header per pengiriman supplier (satu nomor referensi
+-- dan tanggal terima) dengan banyak line. Setiap line tetap satu baris inbounds sehingga
+-- trigger trg_update_stock_inbound menambah stok per product seperti sebelumnya.
+
+CREATE TABLE public.inbound_receipts (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	receipt_number varchar(50) NOT NULL,
+	warehouse_id uuid NOT NULL,
+	supplier_id uuid NOT NULL,
+	supplier_name varchar(100) NOT NULL,
+	supplier_contact varchar(100) NULL,
+	reference_number varchar(100) NULL,
+	received_date timestamptz NOT NULL,
+	total_quantity int4 DEFAULT 0 NOT NULL,
+	total_cost numeric(15, 2) DEFAULT 0.00 NOT NULL,
+	notes text NULL,
+	created_by uuid NOT NULL,
+	created_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT inbound_receipts_pkey PRIMARY KEY (id),
+	CONSTRAINT inbound_receipts_receipt_number_key UNIQUE (receipt_number)
+);
+CREATE INDEX idx_inbound_receipts_warehouse_id ON public.inbound_receipts USING btree (warehouse_id, received_date DESC);
+CREATE INDEX idx_inbound_receipts_supplier_id ON public.inbound_receipts USING btree (supplier_id);
+
+-- public.inbound_receipts foreign keys
+ALTER TABLE public.inbound_receipts ADD CONSTRAINT inbound_receipts_warehouse_id_fkey FOREIGN KEY (warehouse_id) REFERENCES public.warehouses(id);
+ALTER TABLE public.inbound_receipts ADD CONSTRAINT inbound_receipts_supplier_id_fkey FOREIGN KEY (supplier_id) REFERENCES public.suppliers(id);
+ALTER TABLE public.inbound_receipts ADD CONSTRAINT inbound_receipts_created_by_fkey FOREIGN KEY (created_by) REFERENCES public.users(id);
+
+-- Line penerimaan; inbound lama (satu product per penerimaan) tidak punya header
+ALTER TABLE public.inbounds ADD COLUMN inbound_receipt_id uuid NULL;
+CREATE INDEX idx_inbounds_inbound_receipt_id ON public.inbounds USING btree (inbound_receipt_id);
+
+-- public.inbounds foreign keys
+ALTER TABLE public.inbounds ADD CONSTRAINT inbounds_inbound_receipt_id_fkey FOREIGN KEY (inbound_receipt_id) REFERENCES public.inbound_receipts(id);
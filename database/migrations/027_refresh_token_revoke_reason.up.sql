@@ -0,0 +1,5 @@
+-- Alasan refresh token direvoke. Hanya token yang direvoke karena rotasi yang dianggap
+-- reuse jika dipakai lagi; token yang dicabut lewat logout / sign out / perubahan akun
+-- cukup ditolak. Token lama tanpa alasan diperlakukan sebagai bukan rotasi.
+
+ALTER TABLE public.refresh_tokens ADD COLUMN revoked_reason varchar(20) NULL;
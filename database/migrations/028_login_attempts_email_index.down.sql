@@ -0,0 +1 @@
+DROP INDEX IF EXISTS public.idx_login_attempts_email_created_at;
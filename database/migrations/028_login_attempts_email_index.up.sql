@@ -0,0 +1,2 @@
+-- Login gagal ke email yang tidak terdaftar dihitung per email untuk jeda / lockout
+CREATE INDEX idx_login_attempts_email_created_at ON public.login_attempts USING btree (lower((email)::text), created_at);
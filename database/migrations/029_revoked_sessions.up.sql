@@ -0,0 +1,14 @@
+-- Sesi (family refresh token) yang di-sign out. Access token membawa claim sid = family_id,
+-- access token sesi yang dicabut ditolak sampai expires_at (exp access token terakhir sesi).
+
+CREATE TABLE public.revoked_sessions (
+	family_id uuid NOT NULL,
+	user_id uuid NOT NULL,
+	expires_at timestamptz NOT NULL,
+	revoked_at timestamptz DEFAULT now() NOT NULL,
+	CONSTRAINT revoked_sessions_pkey PRIMARY KEY (family_id)
+);
+CREATE INDEX idx_revoked_sessions_expires_at ON public.revoked_sessions USING btree (expires_at);
+
+-- foreign keys
+ALTER TABLE public.revoked_sessions ADD CONSTRAINT revoked_sessions_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey kredensial integrasi machine-to-machine. Request yang memakai key ini
+// tercatat atas nama ServiceAccount (user dengan role service).
+type APIKey struct {
+	ID               uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Name             string     `gorm:"size:100;not null" json:"name"`
+	KeyPrefix        string     `gorm:"size:20;not null" json:"key_prefix"`
+	KeyHash          string     `gorm:"type:text;not null;unique" json:"-"`
+	ServiceAccountID uuid.UUID  `gorm:"type:uuid;not null;index" json:"service_account_id"`
+	Permissions      string     `gorm:"type:text;not null;default:''" json:"-"` // dipisah koma
+	AllWarehouses    bool       `gorm:"default:false;not null" json:"all_warehouses"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	LastUsedAt       *time.Time `json:"last_used_at"`
+	LastUsedIP       string     `gorm:"column:last_used_ip;size:45" json:"last_used_ip"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+	CreatedBy        *uuid.UUID `gorm:"type:uuid" json:"created_by"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Warehouses []APIKeyWarehouse `gorm:"foreignKey:APIKeyID" json:"-"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// PermissionList permission key dalam bentuk slice
+func (k *APIKey) PermissionList() []Permission {
+	permissions := []Permission{}
+	for _, p := range strings.Split(k.Permissions, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			permissions = append(permissions, Permission(p))
+		}
+	}
+	return permissions
+}
+
+// SetPermissions simpan permission sebagai teks dipisah koma
+func (k *APIKey) SetPermissions(permissions []Permission) {
+	parts := make([]string, len(permissions))
+	for i, p := range permissions {
+		parts[i] = string(p)
+	}
+	k.Permissions = strings.Join(parts, ",")
+}
+
+// WarehouseScope gudang yang boleh diakses key
+func (k *APIKey) WarehouseScope() WarehouseScope {
+	if k.AllWarehouses {
+		return AllWarehouses()
+	}
+
+	scope := WarehouseScope{}
+	for _, w := range k.Warehouses {
+		scope.WarehouseIDs = append(scope.WarehouseIDs, w.WarehouseID)
+	}
+	return scope
+}
+
+// IsUsable key belum dicabut dan belum expired
+func (k *APIKey) IsUsable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || k.ExpiresAt.After(now))
+}
+
+// APIKeyWarehouse gudang yang boleh diakses API key (jika tidak AllWarehouses)
+type APIKeyWarehouse struct {
+	APIKeyID    uuid.UUID `gorm:"column:api_key_id;type:uuid;primaryKey" json:"api_key_id"`
+	WarehouseID uuid.UUID `gorm:"type:uuid;primaryKey" json:"warehouse_id"`
+}
+
+func (APIKeyWarehouse) TableName() string {
+	return "api_key_warehouses"
+}
+
+// APIKeyPrincipal identitas hasil autentikasi API key, dipakai middleware
+type APIKeyPrincipal struct {
+	KeyID            uuid.UUID
+	ServiceAccountID uuid.UUID
+	Permissions      []Permission
+	Scope            WarehouseScope
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Jenis alamat customer
+const (
+	AddressShipping = "shipping"
+	AddressBilling  = "billing"
+)
+
+type Customer struct {
+	ID            uuid.UUID         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Code          string            `gorm:"type:varchar(100);unique;not null" json:"code"`
+	Name          string            `gorm:"type:varchar(100);not null" json:"name"`
+	ContactPerson string            `gorm:"type:varchar(100)" json:"contact_person"`
+	Email         string            `gorm:"type:varchar(100)" json:"email"`
+	Phone         string            `gorm:"type:varchar(50)" json:"phone"`
+	TaxID         string            `gorm:"type:varchar(50)" json:"tax_id"`
+	CreditLimit   float64           `gorm:"type:numeric(15,2);default:0.00" json:"credit_limit"` // 0 = tanpa batas
+	IsActive      bool              `gorm:"default:true" json:"is_active"`
+	Notes         string            `gorm:"type:text" json:"notes"`
+	CreatedAt     time.Time         `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	UpdatedAt     time.Time         `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+	Addresses     []CustomerAddress `gorm:"foreignKey:CustomerID" json:"addresses"`
+}
+
+type CustomerAddress struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CustomerID    uuid.UUID `gorm:"type:uuid;not null" json:"customer_id"`
+	AddressType   string    `gorm:"type:varchar(20);not null" json:"address_type"`
+	Label         string    `gorm:"type:varchar(100)" json:"label"`
+	RecipientName string    `gorm:"type:varchar(100)" json:"recipient_name"`
+	Phone         string    `gorm:"type:varchar(50)" json:"phone"`
+	Address       string    `gorm:"type:text;not null" json:"address"`
+	City          string    `gorm:"type:varchar(100)" json:"city"`
+	Province      string    `gorm:"type:varchar(100)" json:"province"`
+	PostalCode    string    `gorm:"type:varchar(20)" json:"postal_code"`
+	Country       string    `gorm:"type:varchar(100)" json:"country"`
+	IsDefault     bool      `gorm:"default:false" json:"is_default"`
+	CreatedAt     time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+}
+
+func (Customer) TableName() string {
+	return "customers"
+}
+
+func (CustomerAddress) TableName() string {
+	return "customer_addresses"
+}
+
+// FullAddress alamat lengkap satu baris, disimpan sebagai snapshot di order
+func (a *CustomerAddress) FullAddress() string {
+	parts := []string{a.RecipientName, a.Phone, a.Address, a.City, a.Province, a.PostalCode, a.Country}
+	filled := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			filled = append(filled, p)
+		}
+	}
+	return strings.Join(filled, ", ")
+}
@@ -7,22 +7,29 @@ import (
 )
 
 type Inbound struct {
-	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	ProductID       uuid.UUID `gorm:"type:uuid;not null" json:"product_id"`
-	Product         Product   `gorm:"foreignKey:ProductID"`
-	WarehouseID     uuid.UUID `gorm:"type:uuid;not null" json:"warehouse_id"`
-	Warehouse       Warehouse `gorm:"foreignKey:WarehouseID"`
-	Quantity        int       `json:"quantity"`
-	SupplierName    string    `gorm:"type:varchar(100);not null" json:"supplier_name"`
-	SupplierContact string    `gorm:"type:varchar(100);" json:"supplier_contact,omitempty"`
-	ReferenceNumber string    `gorm:"type:varchar(100);" json:"reference_number,omitempty"`
-	UnitCost        float64   `json:"unit_cost,omitempty"`
-	TotalCost       float64   `json:"total_cost,omitempty"`
-	Notes           string    `json:"notes,omitempty"`
-	ReceivedDate    time.Time `json:"received_date"`
-	CreatedAt       time.Time `json:"created_at"`
-	CreatedBy       uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
-	User            User      `gorm:"foreignKey:CreatedBy"`
+	ID                  uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InboundReceiptID    *uuid.UUID `gorm:"type:uuid" json:"inbound_receipt_id,omitempty"` // header penerimaan multi-line
+	ProductID           uuid.UUID  `gorm:"type:uuid;not null" json:"product_id"`
+	Product             Product    `gorm:"foreignKey:ProductID"`
+	WarehouseID         uuid.UUID  `gorm:"type:uuid;not null" json:"warehouse_id"`
+	Warehouse           Warehouse  `gorm:"foreignKey:WarehouseID"`
+	Quantity            int        `json:"quantity"`
+	SupplierID          uuid.UUID  `gorm:"type:uuid;not null" json:"supplier_id"`
+	SupplierName        string     `gorm:"type:varchar(100);not null" json:"supplier_name"` // snapshot nama supplier
+	SupplierContact     string     `gorm:"type:varchar(100);" json:"supplier_contact,omitempty"`
+	ReceiptNumber       string     `gorm:"type:varchar(50);unique" json:"receipt_number,omitempty"`
+	PurchaseOrderID     *uuid.UUID `gorm:"type:uuid" json:"purchase_order_id,omitempty"`
+	PurchaseOrderLineID *uuid.UUID `gorm:"type:uuid" json:"purchase_order_line_id,omitempty"`
+	ReferenceNumber     string     `gorm:"type:varchar(100);" json:"reference_number,omitempty"`
+	UnitCost            float64    `json:"unit_cost,omitempty"`
+	TotalCost           float64    `json:"total_cost,omitempty"`
+	Notes               string     `json:"notes,omitempty"`
+	OrderedDate         *time.Time `json:"ordered_date,omitempty"`  // tanggal pesan ke supplier, dasar lead time
+	ExpectedDate        *time.Time `json:"expected_date,omitempty"` // janji kirim supplier, dasar on-time rate
+	ReceivedDate        time.Time  `json:"received_date"`
+	CreatedAt           time.Time  `json:"created_at"`
+	CreatedBy           uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	User                User       `gorm:"foreignKey:CreatedBy"`
 }
 
 func (Inbound) TableName() string {
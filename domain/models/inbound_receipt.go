@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InboundReceipt header penerimaan barang satu pengiriman supplier; setiap line adalah
+// satu Inbound (stok per product tetap ditambah trigger inbound)
+type InboundReceipt struct {
+	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ReceiptNumber   string    `gorm:"type:varchar(50);unique" json:"receipt_number"`
+	WarehouseID     uuid.UUID `gorm:"type:uuid;not null" json:"warehouse_id"`
+	Warehouse       Warehouse `gorm:"foreignKey:WarehouseID"`
+	SupplierID      uuid.UUID `gorm:"type:uuid;not null" json:"supplier_id"`
+	SupplierName    string    `gorm:"type:varchar(100);not null" json:"supplier_name"` // snapshot nama supplier
+	SupplierContact string    `gorm:"type:varchar(100);" json:"supplier_contact,omitempty"`
+	ReferenceNumber string    `gorm:"type:varchar(100);" json:"reference_number,omitempty"` // nomor surat jalan supplier
+	ReceivedDate    time.Time `json:"received_date"`
+	TotalQuantity   int       `json:"total_quantity"`
+	TotalCost       float64   `json:"total_cost"`
+	Notes           string    `json:"notes,omitempty"`
+	CreatedBy       uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	User            User      `gorm:"foreignKey:CreatedBy"`
+	CreatedAt       time.Time `json:"created_at"`
+	Lines           []Inbound `gorm:"foreignKey:InboundReceiptID" json:"lines"`
+}
+
+func (InboundReceipt) TableName() string {
+	return "inbound_receipts"
+}
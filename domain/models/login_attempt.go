@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Alasan gagal login yang dicatat di login_attempts
+const (
+	LoginFailureUnknownEmail     = "unknown_email"
+	LoginFailureWrongPassword    = "wrong_password"
+	LoginFailureInactive         = "inactive"
+	LoginFailureLocked           = "locked"
+	LoginFailureThrottled        = "throttled"
+	LoginFailureInvalidTwoFactor = "invalid_2fa_code"
+)
+
+// LoginAttempt catatan audit setiap percobaan login
+type LoginAttempt struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Email         string     `gorm:"size:100;not null" json:"email"`
+	UserID        *uuid.UUID `gorm:"type:uuid" json:"user_id"`
+	IPAddress     string     `gorm:"size:45" json:"ip_address"`
+	UserAgent     string     `gorm:"type:text" json:"user_agent"`
+	Success       bool       `gorm:"not null" json:"success"`
+	FailureReason string     `gorm:"size:30" json:"failure_reason,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
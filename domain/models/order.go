@@ -6,20 +6,38 @@ import (
 	"github.com/google/uuid"
 )
 
+// Status order sesuai enum public.order_status
+const (
+	OrderStatusPendingPayment   = "pending_payment"
+	OrderStatusConfirmed        = "confirmed"
+	OrderStatusProcessing       = "processing"
+	OrderStatusPartiallyShipped = "partially_shipped"
+	OrderStatusShipped          = "shipped"
+	OrderStatusDelivered        = "delivered"
+	OrderStatusCancelled        = "cancelled"
+	OrderStatusExpired          = "expired"
+)
+
 type Order struct {
-	ID           uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	OrderNumber  string      `gorm:"type:varchar(50);unique;not null" json:"order_number"`
-	CustomerID   string      `gorm:"type:varchar(100);not null" json:"customer_id"`
-	CustomerName string      `gorm:"type:varchar(100);not null" json:"customer_name"`
-	Status       string      `gorm:"type:order_status;default:pending_payment" json:"status"`
-	TotalAmount  float64     `gorm:"type:numeric(15,2);default:0.00" json:"total_amount"`
-	WarehouseID  uuid.UUID   `gorm:"type:uuid;not null" json:"warehouse_id"`
-	Warehouse    Warehouse   `gorm:"foreignKey:WarehouseID"`
-	Notes        string      `gorm:"type:text" json:"notes"`
-	ExpiresAt    time.Time   `gorm:"type:timestamptz" json:"expires_at"`
-	CreatedAt    time.Time   `gorm:"type:timestamptz;default:now()" json:"created_at"`
-	UpdatedAt    time.Time   `gorm:"type:timestamptz;default:now()" json:"updated_at"`
-	OrderItems   []OrderItem `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"items"`
+	ID                uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderNumber       string          `gorm:"type:varchar(50);unique;not null" json:"order_number"`
+	CustomerID        uuid.UUID       `gorm:"type:uuid;not null" json:"customer_id"`
+	CustomerCode      string          `gorm:"type:varchar(100);not null" json:"customer_code"` // snapshot customer & alamat saat order dibuat
+	CustomerName      string          `gorm:"type:varchar(100);not null" json:"customer_name"`
+	ShippingAddressID *uuid.UUID      `gorm:"type:uuid" json:"shipping_address_id"`
+	ShippingAddress   string          `gorm:"type:text" json:"shipping_address"`
+	BillingAddressID  *uuid.UUID      `gorm:"type:uuid" json:"billing_address_id"`
+	BillingAddress    string          `gorm:"type:text" json:"billing_address"`
+	Status            string          `gorm:"type:order_status;default:pending_payment" json:"status"`
+	TotalAmount       float64         `gorm:"type:numeric(15,2);default:0.00" json:"total_amount"`
+	WarehouseID       uuid.UUID       `gorm:"type:uuid;not null" json:"warehouse_id"`
+	Warehouse         Warehouse       `gorm:"foreignKey:WarehouseID"`
+	Notes             string          `gorm:"type:text" json:"notes"`
+	ExpiresAt         time.Time       `gorm:"type:timestamptz" json:"expires_at"`
+	CreatedAt         time.Time       `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	UpdatedAt         time.Time       `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+	OrderItems        []OrderItem     `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"items"`
+	Shipments         []OrderShipment `gorm:"foreignKey:OrderID" json:"shipments,omitempty"`
 }
 
 type OrderItem struct {
@@ -30,7 +48,58 @@ type OrderItem struct {
 	Quantity   int       `gorm:"not null" json:"quantity"`
 	UnitPrice  float64   `gorm:"type:numeric(15,2);not null" json:"unit_price"`
 	TotalPrice float64   `gorm:"type:numeric(15,2);not null" json:"total_price"`
-	CreatedAt  time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	// Quantity = ShippedQuantity + AllocatedQuantity + BackorderedQuantity selama order aktif
+	AllocatedQuantity   int       `gorm:"not null;default:0" json:"allocated_quantity"` // direservasi, belum dikirim
+	PickedQuantity      int       `gorm:"not null;default:0" json:"picked_quantity"`    // bagian allocated yang sudah diambil
+	ShippedQuantity     int       `gorm:"not null;default:0" json:"shipped_quantity"`
+	BackorderedQuantity int       `gorm:"not null;default:0" json:"backordered_quantity"` // menunggu stok masuk
+	CreatedAt           time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+}
+
+// OutstandingQuantity jumlah yang belum dikirim (teralokasi maupun backorder)
+func (i *OrderItem) OutstandingQuantity() int {
+	return i.AllocatedQuantity + i.BackorderedQuantity
+}
+
+// OrderShipment satu pengiriman order; order bisa dikirim dalam beberapa pengiriman
+type OrderShipment struct {
+	ID             uuid.UUID           `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID        uuid.UUID           `gorm:"type:uuid;not null" json:"order_id"`
+	ShipmentNumber string              `gorm:"type:varchar(50);unique;not null" json:"shipment_number"`
+	Notes          string              `gorm:"type:text" json:"notes,omitempty"`
+	ShippedBy      uuid.UUID           `gorm:"type:uuid;not null" json:"shipped_by"`
+	ShippedAt      time.Time           `gorm:"type:timestamptz;default:now()" json:"shipped_at"`
+	CreatedAt      time.Time           `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	Items          []OrderShipmentItem `gorm:"foreignKey:ShipmentID" json:"items"`
+}
+
+type OrderShipmentItem struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ShipmentID  uuid.UUID `gorm:"type:uuid;not null" json:"shipment_id"`
+	OrderItemID uuid.UUID `gorm:"type:uuid;not null" json:"order_item_id"`
+	ProductID   uuid.UUID `gorm:"type:uuid;not null" json:"product_id"`
+	Quantity    int       `gorm:"not null" json:"quantity"`
+}
+
+// Aksi riwayat perubahan item order
+const (
+	OrderItemAdded   = "added"
+	OrderItemUpdated = "updated"
+	OrderItemRemoved = "removed"
+)
+
+// OrderItemChange riwayat satu perubahan item order sebelum order diproses
+type OrderItemChange struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID       uuid.UUID `gorm:"type:uuid;not null" json:"order_id"`
+	OrderItemID   uuid.UUID `gorm:"type:uuid;not null" json:"order_item_id"`
+	ProductID     uuid.UUID `gorm:"type:uuid;not null" json:"product_id"`
+	Action        string    `gorm:"type:varchar(20);not null" json:"action"`
+	OldQuantity   int       `gorm:"not null;default:0" json:"old_quantity"`
+	NewQuantity   int       `gorm:"not null;default:0" json:"new_quantity"`
+	ReservedDelta int       `gorm:"not null;default:0" json:"reserved_delta"` // perubahan reserved_stock product
+	ChangedBy     uuid.UUID `gorm:"type:uuid;not null" json:"changed_by"`
+	ChangedAt     time.Time `gorm:"type:timestamptz;default:now()" json:"changed_at"`
 }
 
 func (Order) TableName() string {
@@ -40,3 +109,15 @@ func (Order) TableName() string {
 func (OrderItem) TableName() string {
 	return "order_items"
 }
+
+func (OrderShipment) TableName() string {
+	return "order_shipments"
+}
+
+func (OrderShipmentItem) TableName() string {
+	return "order_shipment_items"
+}
+
+func (OrderItemChange) TableName() string {
+	return "order_item_changes"
+}
@@ -24,6 +24,11 @@ type Outbound struct {
 	CreatedAt          time.Time `json:"created_at"`
 	CreatedBy          uuid.UUID `gorm:"type:uuid;not null;index" json:"created_by"`
 	User               User      `gorm:"foreignKey:CreatedBy" json:"user"`
+
+	// StockOverride outbound melebihi available_stock, hanya boleh oleh admin dengan alasan
+	StockOverride  bool       `gorm:"not null;default:false" json:"stock_override"`
+	OverrideReason string     `gorm:"type:text" json:"override_reason,omitempty"`
+	OverrideBy     *uuid.UUID `gorm:"type:uuid" json:"override_by,omitempty"`
 }
 
 func (Outbound) TableName() string {
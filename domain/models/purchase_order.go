@@ -0,0 +1,93 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status purchase order sesuai enum public.purchase_order_status
+const (
+	PurchaseOrderStatusOpen              = "open"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusClosed            = "closed"
+	PurchaseOrderStatusCancelled         = "cancelled"
+)
+
+// PurchaseOrder pesanan pembelian ke supplier untuk satu gudang
+type PurchaseOrder struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	PONumber        string     `gorm:"column:po_number;type:varchar(50);unique;not null" json:"po_number"`
+	SupplierID      uuid.UUID  `gorm:"type:uuid;not null" json:"supplier_id"`
+	Supplier        Supplier   `gorm:"foreignKey:SupplierID" json:"supplier"`
+	WarehouseID     uuid.UUID  `gorm:"type:uuid;not null" json:"warehouse_id"`
+	Warehouse       Warehouse  `gorm:"foreignKey:WarehouseID" json:"warehouse"`
+	Status          string     `gorm:"type:purchase_order_status;default:open" json:"status"`
+	OrderDate       time.Time  `gorm:"type:timestamptz;default:now()" json:"order_date"`
+	ExpectedDate    *time.Time `gorm:"type:timestamptz" json:"expected_date,omitempty"`
+	TotalAmount     float64    `gorm:"type:numeric(15,2);default:0.00" json:"total_amount"`
+	ReferenceNumber string     `gorm:"type:varchar(100)" json:"reference_number,omitempty"`
+	Notes           string     `gorm:"type:text" json:"notes,omitempty"`
+
+	// Toleransi penerimaan dalam persen dari ordered_quantity per line:
+	// over = boleh diterima lebih, under = line dianggap lengkap walau kurang
+	OverReceiptTolerance  float64 `gorm:"type:numeric(5,2);not null;default:0" json:"over_receipt_tolerance"`
+	UnderReceiptTolerance float64 `gorm:"type:numeric(5,2);not null;default:0" json:"under_receipt_tolerance"`
+
+	CreatedBy    uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	ClosedBy     *uuid.UUID `gorm:"type:uuid" json:"closed_by,omitempty"`
+	ClosedAt     *time.Time `gorm:"type:timestamptz" json:"closed_at,omitempty"`
+	CloseReason  string     `gorm:"type:text" json:"close_reason,omitempty"`
+	CancelledBy  *uuid.UUID `gorm:"type:uuid" json:"cancelled_by,omitempty"`
+	CancelledAt  *time.Time `gorm:"type:timestamptz" json:"cancelled_at,omitempty"`
+	CancelReason string     `gorm:"type:text" json:"cancel_reason,omitempty"`
+
+	CreatedAt time.Time           `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	UpdatedAt time.Time           `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+	Lines     []PurchaseOrderLine `gorm:"foreignKey:PurchaseOrderID;constraint:OnDelete:CASCADE" json:"lines"`
+	Receipts  []Inbound           `gorm:"foreignKey:PurchaseOrderID" json:"receipts,omitempty"` // inbound terhadap PO ini
+}
+
+type PurchaseOrderLine struct {
+	ID               uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	PurchaseOrderID  uuid.UUID `gorm:"type:uuid;not null" json:"purchase_order_id"`
+	ProductID        uuid.UUID `gorm:"type:uuid;not null" json:"product_id"`
+	Product          Product   `gorm:"foreignKey:ProductID" json:"product"`
+	OrderedQuantity  int       `gorm:"not null" json:"ordered_quantity"`
+	ReceivedQuantity int       `gorm:"not null;default:0" json:"received_quantity"`
+	UnitCost         float64   `gorm:"type:numeric(15,2);not null;default:0.00" json:"unit_cost"`
+	TotalCost        float64   `gorm:"type:numeric(15,2);not null;default:0.00" json:"total_cost"`
+	Notes            string    `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt        time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+}
+
+func (PurchaseOrder) TableName() string {
+	return "purchase_orders"
+}
+
+func (PurchaseOrderLine) TableName() string {
+	return "purchase_order_lines"
+}
+
+// IsReceivable PO masih menunggu barang dari supplier
+func (p *PurchaseOrder) IsReceivable() bool {
+	return p.Status == PurchaseOrderStatusOpen || p.Status == PurchaseOrderStatusPartiallyReceived
+}
+
+// OutstandingQuantity jumlah yang belum diterima (0 jika sudah diterima penuh atau lebih)
+func (l *PurchaseOrderLine) OutstandingQuantity() int {
+	return max(l.OrderedQuantity-l.ReceivedQuantity, 0)
+}
+
+// MaxReceivableQuantity total penerimaan maksimum line dengan toleransi over-receipt (persen)
+func (l *PurchaseOrderLine) MaxReceivableQuantity(overTolerance float64) int {
+	return l.OrderedQuantity + int(math.Floor(float64(l.OrderedQuantity)*overTolerance/100+1e-9))
+}
+
+// IsFulfilled line dianggap lengkap jika kekurangannya masih dalam toleransi under-receipt (persen)
+func (l *PurchaseOrderLine) IsFulfilled(underTolerance float64) bool {
+	shortfall := l.OrderedQuantity - l.ReceivedQuantity
+	return float64(shortfall) <= float64(l.OrderedQuantity)*underTolerance/100+1e-9
+}
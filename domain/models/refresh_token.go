@@ -6,14 +6,29 @@ import (
 	"github.com/google/uuid"
 )
 
+// Alasan refresh token direvoke; hanya token hasil rotasi yang dianggap reuse jika dipakai lagi
+const (
+	RefreshTokenRevokedRotated     = "rotated"
+	RefreshTokenRevokedLogout      = "logout"
+	RefreshTokenRevokedSignOut     = "sign_out"     // satu sesi di-sign out
+	RefreshTokenRevokedAllSessions = "all_sessions" // sign out semua perangkat / perubahan akun
+	RefreshTokenRevokedReuse       = "reuse"        // family dicabut karena reuse terdeteksi
+)
+
 type RefreshToken struct {
-	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
-	TokenHash string     `gorm:"type:text;not null;index" json:"token_hash"`
-	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
-	RevokedAt *time.Time `gorm:"default:null" json:"revoked_at"`
-	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	FamilyID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"family_id"` // satu family per login
+	TokenHash     string     `gorm:"type:text;not null;index" json:"token_hash"`
+	ExpiresAt     time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt     *time.Time `gorm:"default:null" json:"revoked_at"`
+	RevokedReason string     `gorm:"type:varchar(20);default:null" json:"revoked_reason,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	UserAgent  string     `gorm:"type:text" json:"user_agent"`
+	IPAddress  string     `gorm:"type:varchar(45)" json:"ip_address"`
+	LastUsedAt *time.Time `gorm:"default:null" json:"last_used_at"`
 }
 
 func (RefreshToken) TableName() string {
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevokedAccessToken access token yang dicabut sebelum exp (misal karena logout)
+type RevokedAccessToken struct {
+	JTI       uuid.UUID `gorm:"column:jti;type:uuid;primaryKey" json:"jti"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	RevokedAt time.Time `gorm:"not null" json:"revoked_at"`
+}
+
+func (RevokedAccessToken) TableName() string {
+	return "revoked_access_tokens"
+}
+
+// RevokedSession sesi (family refresh token) yang di-sign out, access token dengan sid ini ditolak
+type RevokedSession struct {
+	FamilyID  uuid.UUID `gorm:"type:uuid;primaryKey" json:"family_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	RevokedAt time.Time `gorm:"not null" json:"revoked_at"`
+}
+
+func (RevokedSession) TableName() string {
+	return "revoked_sessions"
+}
+
+// UserTokenRevocation semua token user yang diterbitkan pada/sebelum RevokedBefore (presisi milidetik) tidak berlaku
+type UserTokenRevocation struct {
+	UserID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	RevokedBefore time.Time `gorm:"not null" json:"revoked_before"`
+	UpdatedAt     time.Time `gorm:"not null" json:"updated_at"`
+}
+
+func (UserTokenRevocation) TableName() string {
+	return "user_token_revocations"
+}
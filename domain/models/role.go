@@ -0,0 +1,118 @@
+package models
+
+// Role sesuai enum public.user_role
+const (
+	RoleAdmin = "admin"
+	RoleStaff = "staff"
+	// RoleService service account milik API key, tidak bisa login dan
+	// permission-nya berasal dari API key (bukan dari role)
+	RoleService = "service"
+)
+
+// Permission adalah hak akses bernama yang dicek per route
+type Permission string
+
+const (
+	PermissionWarehouseRead      Permission = "warehouse:read"
+	PermissionWarehouseWrite     Permission = "warehouse:write"
+	PermissionProductRead        Permission = "product:read"
+	PermissionProductWrite       Permission = "product:write"
+	PermissionStockAdjust        Permission = "stock:adjust"
+	PermissionInboundRead        Permission = "inbound:read"
+	PermissionInboundWrite       Permission = "inbound:write"
+	PermissionOutboundRead       Permission = "outbound:read"
+	PermissionOutboundWrite      Permission = "outbound:write"
+	PermissionTransactionRead    Permission = "transaction:read"
+	PermissionTransferRead       Permission = "transfer:read"
+	PermissionTransferCreate     Permission = "transfer:create"
+	PermissionTransferApprove    Permission = "transfer:approve"
+	PermissionSupplierRead       Permission = "supplier:read"
+	PermissionSupplierWrite      Permission = "supplier:write"
+	PermissionPurchaseOrderRead  Permission = "purchase_order:read"
+	PermissionPurchaseOrderWrite Permission = "purchase_order:write"
+	PermissionCustomerRead       Permission = "customer:read"
+	PermissionCustomerWrite      Permission = "customer:write"
+	PermissionOrderRead          Permission = "order:read"
+	PermissionOrderWrite         Permission = "order:write"
+	PermissionOrderCancel        Permission = "order:cancel"
+	PermissionDashboardRead      Permission = "dashboard:read"
+	PermissionUserManage         Permission = "user:manage"
+	PermissionAPIKeyManage       Permission = "api_key:manage"
+	PermissionJobManage          Permission = "job:manage"
+)
+
+// rolePermissions memetakan role ke daftar permission yang dimiliki
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermissionWarehouseRead,
+		PermissionWarehouseWrite,
+		PermissionProductRead,
+		PermissionProductWrite,
+		PermissionStockAdjust,
+		PermissionInboundRead,
+		PermissionInboundWrite,
+		PermissionOutboundRead,
+		PermissionOutboundWrite,
+		PermissionTransactionRead,
+		PermissionTransferRead,
+		PermissionTransferCreate,
+		PermissionTransferApprove,
+		PermissionSupplierRead,
+		PermissionSupplierWrite,
+		PermissionPurchaseOrderRead,
+		PermissionPurchaseOrderWrite,
+		PermissionCustomerRead,
+		PermissionCustomerWrite,
+		PermissionOrderRead,
+		PermissionOrderWrite,
+		PermissionOrderCancel,
+		PermissionDashboardRead,
+		PermissionUserManage,
+		PermissionAPIKeyManage,
+		PermissionJobManage,
+	},
+	RoleStaff: {
+		PermissionWarehouseRead,
+		PermissionProductRead,
+		PermissionInboundRead,
+		PermissionInboundWrite,
+		PermissionOutboundRead,
+		PermissionOutboundWrite,
+		PermissionTransactionRead,
+		PermissionTransferRead,
+		PermissionTransferCreate,
+		PermissionSupplierRead,
+		PermissionPurchaseOrderRead,
+		PermissionCustomerRead,
+		PermissionOrderRead,
+		PermissionOrderWrite,
+		PermissionDashboardRead,
+	},
+}
+
+// apiKeyForbiddenPermissions tidak boleh diberikan ke API key agar integrasi
+// tidak bisa membuat user / key baru (eskalasi hak akses)
+var apiKeyForbiddenPermissions = map[Permission]bool{
+	PermissionUserManage:   true,
+	PermissionAPIKeyManage: true,
+}
+
+// IsGrantableToAPIKey cek permission dikenal dan boleh diberikan ke API key
+func IsGrantableToAPIKey(permission Permission) bool {
+	return RoleHasPermission(RoleAdmin, permission) && !apiKeyForbiddenPermissions[permission]
+}
+
+// PermissionsForRole mengembalikan permission milik role (kosong jika role tidak dikenal)
+func PermissionsForRole(role string) []Permission {
+	return rolePermissions[role]
+}
+
+// RoleHasPermission cek apakah role memiliki permission tertentu
+func RoleHasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClientInfo data client yang melakukan login / refresh token
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// Session adalah satu family refresh token yang masih aktif (satu per login)
+type Session struct {
+	ID         uuid.UUID  `json:"id"` // family_id
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	StartedAt  time.Time  `json:"started_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+}
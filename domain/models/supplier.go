@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Supplier struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Code          string    `gorm:"type:varchar(100);unique;not null" json:"code"`
+	Name          string    `gorm:"type:varchar(100);not null" json:"name"`
+	ContactPerson string    `gorm:"type:varchar(100)" json:"contact_person"`
+	Email         string    `gorm:"type:varchar(100)" json:"email"`
+	Phone         string    `gorm:"type:varchar(50)" json:"phone"`
+	Address       string    `gorm:"type:text" json:"address"`
+	TaxID         string    `gorm:"type:varchar(50)" json:"tax_id"`
+	LeadTimeDays  int       `gorm:"not null;default:0" json:"lead_time_days"` // lead time standar, dasar expected_date inbound
+	IsActive      bool      `gorm:"default:true" json:"is_active"`
+	Notes         string    `gorm:"type:text" json:"notes"`
+	CreatedAt     time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+}
+
+func (Supplier) TableName() string {
+	return "suppliers"
+}
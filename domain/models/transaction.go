@@ -10,14 +10,15 @@ import (
 type TransactionType string
 
 const (
-	Transfer       TransactionType = "transfer"
-	PendingPayment TransactionType = "pending_payment"
-	Confirmed      TransactionType = "confirmed"
-	Processing     TransactionType = "processing"
-	Shipped        TransactionType = "shipped"
-	Delivered      TransactionType = "delivered"
-	Cancelled      TransactionType = "cancelled"
-	Expired        TransactionType = "expired"
+	Transfer           TransactionType = "transfer"
+	InboundTransaction TransactionType = "inbound"
+	PendingPayment     TransactionType = "pending_payment"
+	Confirmed          TransactionType = "confirmed"
+	Processing         TransactionType = "processing"
+	Shipped            TransactionType = "shipped"
+	Delivered          TransactionType = "delivered"
+	Cancelled          TransactionType = "cancelled"
+	Expired            TransactionType = "expired"
 )
 
 type Transaction struct {
@@ -31,6 +32,7 @@ type Transaction struct {
 	Notes           string          `gorm:"type:text" json:"notes,omitempty"`
 	CreatedBy       uuid.UUID       `gorm:"type:uuid;not null;index" json:"created_by"`
 	CreatedAt       time.Time       `gorm:"type:timestamptz;default:now();index" json:"created_at"`
+	TransferOrderID *uuid.UUID      `gorm:"type:uuid;index" json:"transfer_order_id,omitempty"`
 }
 
 func (Transaction) TableName() string {
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status transfer order sesuai enum public.transfer_order_status
+const (
+	TransferStatusRequested  = "requested"
+	TransferStatusApproved   = "approved"
+	TransferStatusDispatched = "dispatched"
+	TransferStatusInTransit  = "in_transit"
+	TransferStatusReceived   = "received"
+	TransferStatusCancelled  = "cancelled"
+)
+
+// transferOrderTransitions perpindahan status yang diizinkan.
+// in_transit -> in_transit terjadi saat penerimaan parsial.
+var transferOrderTransitions = map[string][]string{
+	TransferStatusRequested:  {TransferStatusApproved, TransferStatusCancelled},
+	TransferStatusApproved:   {TransferStatusDispatched, TransferStatusCancelled},
+	TransferStatusDispatched: {TransferStatusInTransit, TransferStatusReceived},
+	TransferStatusInTransit:  {TransferStatusInTransit, TransferStatusReceived},
+}
+
+// CanTransferOrderTransition cek apakah status from boleh pindah ke to
+func CanTransferOrderTransition(from, to string) bool {
+	for _, s := range transferOrderTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransferOrder transfer stok antar gudang dua fase (dispatch lalu receive)
+type TransferOrder struct {
+	ID                   uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TransferNumber       string     `gorm:"type:varchar(50);unique;not null" json:"transfer_number"`
+	ProductID            uuid.UUID  `gorm:"type:uuid;not null" json:"product_id"`
+	Product              Product    `gorm:"foreignKey:ProductID" json:"product"`
+	DestinationProductID *uuid.UUID `gorm:"type:uuid" json:"destination_product_id,omitempty"`
+	FromWarehouseID      uuid.UUID  `gorm:"type:uuid;not null" json:"from_warehouse_id"`
+	FromWarehouse        Warehouse  `gorm:"foreignKey:FromWarehouseID" json:"from_warehouse"`
+	ToWarehouseID        uuid.UUID  `gorm:"type:uuid;not null" json:"to_warehouse_id"`
+	ToWarehouse          Warehouse  `gorm:"foreignKey:ToWarehouseID" json:"to_warehouse"`
+	Quantity             int        `gorm:"not null" json:"quantity"`
+	QuantityReceived     int        `gorm:"not null;default:0" json:"quantity_received"`
+	DiscrepancyQuantity  int        `gorm:"not null;default:0" json:"discrepancy_quantity"`
+	DiscrepancyNotes     string     `gorm:"type:text" json:"discrepancy_notes,omitempty"`
+	Status               string     `gorm:"type:transfer_order_status;default:requested" json:"status"`
+	ReferenceNumber      string     `gorm:"type:varchar(100)" json:"reference_number,omitempty"`
+	Notes                string     `gorm:"type:text" json:"notes,omitempty"`
+
+	RequestedBy  uuid.UUID  `gorm:"type:uuid;not null" json:"requested_by"`
+	RequestedAt  time.Time  `gorm:"type:timestamptz;default:now()" json:"requested_at"`
+	ApprovedBy   *uuid.UUID `gorm:"type:uuid" json:"approved_by,omitempty"`
+	ApprovedAt   *time.Time `gorm:"type:timestamptz" json:"approved_at,omitempty"`
+	DispatchedBy *uuid.UUID `gorm:"type:uuid" json:"dispatched_by,omitempty"`
+	DispatchedAt *time.Time `gorm:"type:timestamptz" json:"dispatched_at,omitempty"`
+	InTransitBy  *uuid.UUID `gorm:"type:uuid" json:"in_transit_by,omitempty"`
+	InTransitAt  *time.Time `gorm:"type:timestamptz" json:"in_transit_at,omitempty"`
+	ReceivedBy   *uuid.UUID `gorm:"type:uuid" json:"received_by,omitempty"`
+	ReceivedAt   *time.Time `gorm:"type:timestamptz" json:"received_at,omitempty"`
+	CancelledBy  *uuid.UUID `gorm:"type:uuid" json:"cancelled_by,omitempty"`
+	CancelledAt  *time.Time `gorm:"type:timestamptz" json:"cancelled_at,omitempty"`
+	CancelReason string     `gorm:"type:text" json:"cancel_reason,omitempty"`
+
+	CreatedAt time.Time              `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	UpdatedAt time.Time              `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+	Receipts  []TransferOrderReceipt `gorm:"foreignKey:TransferOrderID" json:"receipts"`
+}
+
+func (TransferOrder) TableName() string {
+	return "transfer_orders"
+}
+
+// OutstandingQuantity jumlah yang belum diterima maupun dicatat sebagai selisih
+func (t *TransferOrder) OutstandingQuantity() int {
+	return t.Quantity - t.QuantityReceived - t.DiscrepancyQuantity
+}
+
+// InTransitQuantity stok yang sudah keluar dari gudang asal tapi belum masuk gudang tujuan
+func (t *TransferOrder) InTransitQuantity() int {
+	if t.Status != TransferStatusDispatched && t.Status != TransferStatusInTransit {
+		return 0
+	}
+	return t.OutstandingQuantity()
+}
+
+// TransferOrderReceipt satu kali penerimaan barang transfer di gudang tujuan
+type TransferOrderReceipt struct {
+	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TransferOrderID uuid.UUID `gorm:"type:uuid;not null" json:"transfer_order_id"`
+	Quantity        int       `gorm:"not null" json:"quantity"`
+	Notes           string    `gorm:"type:text" json:"notes,omitempty"`
+	ReceivedBy      uuid.UUID `gorm:"type:uuid;not null" json:"received_by"`
+	ReceivedAt      time.Time `gorm:"type:timestamptz;default:now()" json:"received_at"`
+}
+
+func (TransferOrderReceipt) TableName() string {
+	return "transfer_order_receipts"
+}
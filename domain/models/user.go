@@ -17,6 +17,18 @@ type User struct {
 	IsActive     bool      `gorm:"default:true" json:"is_active"`
 	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// proteksi brute-force, hanya diubah lewat UserRepository.RegisterLoginFailure / ResetLoginFailures
+	FailedLoginAttempts int        `gorm:"default:0;not null" json:"failed_login_attempts"`
+	LastFailedLoginAt   *time.Time `json:"last_failed_login_at"`
+	LockedUntil         *time.Time `json:"locked_until"`
+
+	// TOTP 2FA, secret tersimpan saat enrolment dan baru aktif setelah kode pertama diverifikasi
+	TOTPSecret   *string `gorm:"column:totp_secret;type:text" json:"-"`
+	TOTPEnabled  bool    `gorm:"column:totp_enabled;default:false;not null" json:"totp_enabled"`
+	TOTPLastStep *int64  `gorm:"column:totp_last_step" json:"-"`
+
+	AssignedWarehouses []UserWarehouse `gorm:"foreignKey:UserID" json:"assigned_warehouses,omitempty"`
 }
 
 func (User) TableName() string {
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserWarehouse: gudang tambahan yang di-assign ke user
+type UserWarehouse struct {
+	UserID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	WarehouseID uuid.UUID `gorm:"type:uuid;primaryKey" json:"warehouse_id"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (UserWarehouse) TableName() string {
+	return "user_warehouses"
+}
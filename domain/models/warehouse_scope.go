@@ -0,0 +1,37 @@
+package models
+
+import "github.com/google/uuid"
+
+// WarehouseScope membatasi gudang yang boleh diakses oleh user yang sedang login.
+// Admin mendapat All = true, staff hanya gudang miliknya.
+type WarehouseScope struct {
+	All          bool
+	WarehouseIDs []uuid.UUID
+}
+
+// AllWarehouses scope tanpa batasan (admin / proses internal)
+func AllWarehouses() WarehouseScope {
+	return WarehouseScope{All: true}
+}
+
+// Allows cek apakah gudang termasuk dalam scope
+func (s WarehouseScope) Allows(warehouseID uuid.UUID) bool {
+	if s.All {
+		return true
+	}
+	for _, id := range s.WarehouseIDs {
+		if id == warehouseID {
+			return true
+		}
+	}
+	return false
+}
+
+// IDStrings mengembalikan daftar ID gudang dalam bentuk string (untuk query IN)
+func (s WarehouseScope) IDStrings() []string {
+	ids := make([]string, 0, len(s.WarehouseIDs))
+	for _, id := range s.WarehouseIDs {
+		ids = append(ids, id.String())
+	}
+	return ids
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"wms-be/domain/models"
+	"wms-be/infrastructure/database"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type APIKeyRepository interface {
+	Create(apiKey *models.APIKey, serviceAccount *models.User, rawKey string, warehouseIDs []uuid.UUID) error
+	FindByKey(rawKey string) (*models.APIKey, error)
+	GetByID(id uuid.UUID) (*models.APIKey, error)
+	GetAll(includeRevoked bool, page, limit int) ([]models.APIKey, int, error)
+	Update(apiKey *models.APIKey, warehouseIDs []uuid.UUID) error
+	Revoke(id uuid.UUID) (bool, error)
+	TouchLastUsed(id uuid.UUID, ipAddress string) error
+}
+
+type apiKeyRepo struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository() APIKeyRepository {
+	return &apiKeyRepo{db: database.GetDB()}
+}
+
+// Create simpan service account, API key (hash) dan gudangnya dalam satu transaksi
+func (r *apiKeyRepo) Create(apiKey *models.APIKey, serviceAccount *models.User, rawKey string, warehouseIDs []uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// service account tidak terikat gudang utama (warehouse_id NULL), scope diambil dari key
+		if err := tx.Omit(clause.Associations, "warehouse_id").Create(serviceAccount).Error; err != nil {
+			return err
+		}
+
+		apiKey.ServiceAccountID = serviceAccount.ID
+		apiKey.KeyHash = hashToken(rawKey)
+		if err := tx.Omit(clause.Associations).Create(apiKey).Error; err != nil {
+			return err
+		}
+
+		return replaceAPIKeyWarehouses(tx, apiKey.ID, warehouseIDs)
+	})
+}
+
+func replaceAPIKeyWarehouses(tx *gorm.DB, apiKeyID uuid.UUID, warehouseIDs []uuid.UUID) error {
+	if err := tx.Where("api_key_id = ?", apiKeyID).Delete(&models.APIKeyWarehouse{}).Error; err != nil {
+		return err
+	}
+	if len(warehouseIDs) == 0 {
+		return nil
+	}
+
+	rows := make([]models.APIKeyWarehouse, 0, len(warehouseIDs))
+	for _, id := range warehouseIDs {
+		rows = append(rows, models.APIKeyWarehouse{APIKeyID: apiKeyID, WarehouseID: id})
+	}
+	return tx.Create(&rows).Error
+}
+
+// FindByKey cari key berdasarkan hash (termasuk yang revoked/expired, dicek di service)
+func (r *apiKeyRepo) FindByKey(rawKey string) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	err := r.db.Preload("Warehouses").Where("key_hash = ?", hashToken(rawKey)).First(&apiKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("api key not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+func (r *apiKeyRepo) GetByID(id uuid.UUID) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	if err := r.db.Preload("Warehouses").Where("id = ?", id).First(&apiKey).Error; err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+func (r *apiKeyRepo) GetAll(includeRevoked bool, page, limit int) ([]models.APIKey, int, error) {
+	var apiKeys []models.APIKey
+	var total int64
+
+	query := r.db.Model(&models.APIKey{})
+	if !includeRevoked {
+		query = query.Where("revoked_at IS NULL")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Warehouses").
+		Order("created_at DESC").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&apiKeys).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return apiKeys, int(total), nil
+}
+
+// Update simpan nama/permission/expiry dan ganti seluruh gudang key
+func (r *apiKeyRepo) Update(apiKey *models.APIKey, warehouseIDs []uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.APIKey{}).Where("id = ?", apiKey.ID).Updates(map[string]interface{}{
+			"name":           apiKey.Name,
+			"permissions":    apiKey.Permissions,
+			"all_warehouses": apiKey.AllWarehouses,
+			"expires_at":     apiKey.ExpiresAt,
+			"updated_at":     time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return replaceAPIKeyWarehouses(tx, apiKey.ID, warehouseIDs)
+	})
+}
+
+// Revoke cabut key, false jika tidak ada atau sudah dicabut
+func (r *apiKeyRepo) Revoke(id uuid.UUID) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": now, "updated_at": now})
+	return result.RowsAffected > 0, result.Error
+}
+
+// TouchLastUsed catat pemakaian terakhir, dibatasi maksimal sekali per menit agar tidak menulis di setiap request
+func (r *apiKeyRepo) TouchLastUsed(id uuid.UUID, ipAddress string) error {
+	now := time.Now()
+	return r.db.Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-time.Minute)).
+		Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": ipAddress}).Error
+}
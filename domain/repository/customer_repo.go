@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"time"
+	"wms-be/domain/models"
+	"wms-be/infrastructure/database"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CustomerRepository interface {
+	CreateCustomer(customer *models.Customer) error
+	GetCustomers(filters map[string]interface{}, page, limit int) ([]models.Customer, int64, error)
+	GetCustomerByID(id uuid.UUID) (*models.Customer, error)
+	UpdateCustomer(customer *models.Customer) error
+	DeleteCustomer(id uuid.UUID) error
+	CreateAddress(address *models.CustomerAddress) error
+	UpdateAddress(address *models.CustomerAddress) error
+	DeleteAddress(customerID, addressID uuid.UUID) error
+	GetOutstanding(customerID uuid.UUID) (CustomerOutstanding, error)
+}
+
+// CustomerOutstanding tagihan customer yang belum dibayar (order pending_payment)
+type CustomerOutstanding struct {
+	Amount float64
+	Orders int64
+}
+
+type customerRepo struct {
+	db *gorm.DB
+}
+
+func NewCustomerRepository() CustomerRepository {
+	return &customerRepo{db: database.GetDB()}
+}
+
+// CreateCustomer simpan customer beserta alamat awalnya
+func (r *customerRepo) CreateCustomer(customer *models.Customer) error {
+	return r.db.Create(customer).Error
+}
+
+func (r *customerRepo) GetCustomers(filters map[string]interface{}, page, limit int) ([]models.Customer, int64, error) {
+	var customers []models.Customer
+	var total int64
+
+	query := r.db.Model(&models.Customer{})
+	if search, ok := filters["search"].(string); ok && search != "" {
+		pattern := "%" + search + "%"
+		query = query.Where("code ILIKE ? OR name ILIKE ? OR email ILIKE ? OR tax_id ILIKE ?", pattern, pattern, pattern, pattern)
+	}
+	if active, ok := filters["is_active"].(bool); ok {
+		query = query.Where("is_active = ?", active)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Addresses").
+		Order("name").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&customers).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return customers, total, nil
+}
+
+func (r *customerRepo) GetCustomerByID(id uuid.UUID) (*models.Customer, error) {
+	var customer models.Customer
+	err := r.db.Preload("Addresses", func(db *gorm.DB) *gorm.DB {
+		return db.Order("address_type, is_default DESC, created_at")
+	}).First(&customer, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+// UpdateCustomer update data customer tanpa menyentuh alamat (dikelola lewat endpoint alamat)
+func (r *customerRepo) UpdateCustomer(customer *models.Customer) error {
+	customer.UpdatedAt = time.Now()
+	result := r.db.Model(&models.Customer{}).Where("id = ?", customer.ID).
+		Select("code", "name", "contact_person", "email", "phone", "tax_id", "credit_limit", "is_active", "notes", "updated_at").
+		Updates(customer)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteCustomer hapus customer; gagal (foreign key) jika customer sudah punya order
+func (r *customerRepo) DeleteCustomer(id uuid.UUID) error {
+	result := r.db.Where("id = ?", id).Delete(&models.Customer{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateAddress simpan alamat; alamat default menggantikan default lama dengan jenis yang sama
+func (r *customerRepo) CreateAddress(address *models.CustomerAddress) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockCustomer(tx, address.CustomerID); err != nil {
+			return err
+		}
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, address); err != nil {
+				return err
+			}
+		}
+		return tx.Create(address).Error
+	})
+}
+
+func (r *customerRepo) UpdateAddress(address *models.CustomerAddress) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockCustomer(tx, address.CustomerID); err != nil {
+			return err
+		}
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, address); err != nil {
+				return err
+			}
+		}
+
+		address.UpdatedAt = time.Now()
+		result := tx.Model(&models.CustomerAddress{}).
+			Where("id = ? AND customer_id = ?", address.ID, address.CustomerID).
+			Select("address_type", "label", "recipient_name", "phone", "address", "city", "province", "postal_code", "country", "is_default", "updated_at").
+			Updates(address)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// DeleteAddress hapus alamat; order yang memakainya tetap menyimpan snapshot alamat
+func (r *customerRepo) DeleteAddress(customerID, addressID uuid.UUID) error {
+	result := r.db.Where("id = ? AND customer_id = ?", addressID, customerID).Delete(&models.CustomerAddress{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *customerRepo) GetOutstanding(customerID uuid.UUID) (CustomerOutstanding, error) {
+	return customerOutstanding(r.db, customerID)
+}
+
+func customerOutstanding(tx *gorm.DB, customerID uuid.UUID) (CustomerOutstanding, error) {
+	var outstanding CustomerOutstanding
+	err := tx.Model(&models.Order{}).
+		Select("COALESCE(SUM(total_amount), 0) AS amount, COUNT(*) AS orders").
+		Where("customer_id = ? AND status = ?", customerID, models.OrderStatusPendingPayment).
+		Scan(&outstanding).Error
+	return outstanding, err
+}
+
+// lockCustomer kunci baris customer agar perubahan alamat default dan pengecekan credit
+// limit (order baru / item order bertambah) tidak balapan
+func lockCustomer(tx *gorm.DB, id uuid.UUID) (*models.Customer, error) {
+	var customer models.Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func clearDefaultAddress(tx *gorm.DB, address *models.CustomerAddress) error {
+	return tx.Model(&models.CustomerAddress{}).
+		Where("customer_id = ? AND address_type = ? AND is_default AND id <> ?", address.CustomerID, address.AddressType, address.ID).
+		Update("is_default", false).Error
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"time"
+	"wms-be/domain/models"
+	"wms-be/infrastructure/docnumber"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Jenis dokumen bernomor (kolom document_sequences.document_type)
+const (
+	DocumentOrder         = "order"
+	DocumentInbound       = "inbound"
+	DocumentOutbound      = "outbound"
+	DocumentTransfer      = "transfer"
+	DocumentPurchaseOrder = "purchase_order"
+)
+
+// DocumentNumbering scheme penomoran satu jenis dokumen, dikonfigurasi di service
+type DocumentNumbering struct {
+	Type     string
+	Scheme   docnumber.Scheme
+	Location *time.Location // zona waktu untuk token tanggal & periode
+}
+
+// assignDocumentNumber alokasikan nomor urut berikutnya untuk gudang dan periode saat ini.
+// Harus dipanggil di dalam transaksi yang juga meng-insert dokumennya: baris counter terkunci
+// sampai commit (pembuat paralel antre) dan ikut di-rollback jika insert gagal.
+func assignDocumentNumber(tx *gorm.DB, numbering DocumentNumbering, warehouseID uuid.UUID) (string, error) {
+	now := time.Now()
+	if numbering.Location != nil {
+		now = now.In(numbering.Location)
+	}
+
+	var warehouse models.Warehouse
+	if err := tx.Select("id", "code").First(&warehouse, "id = ?", warehouseID).Error; err != nil {
+		return "", err
+	}
+
+	var seq struct{ LastValue int64 }
+	err := tx.Raw(`
+		INSERT INTO document_sequences (document_type, warehouse_id, period, last_value, updated_at)
+		VALUES (?, ?, ?, 1, now())
+		ON CONFLICT (document_type, warehouse_id, period)
+		DO UPDATE SET last_value = document_sequences.last_value + 1, updated_at = now()
+		RETURNING last_value
+	`, numbering.Type, warehouseID, numbering.Scheme.Period(now)).Scan(&seq).Error
+	if err != nil {
+		return "", err
+	}
+
+	return numbering.Scheme.Format(warehouse.Code, now, seq.LastValue), nil
+}
@@ -1,15 +1,22 @@
 package repository
 
 import (
+	"fmt"
+	"sort"
 	"wms-be/domain/models"
 	"wms-be/infrastructure/database"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type InboundRepository interface {
-	GetInbounds(search, warehouseId string, page, limit int) ([]models.Inbound, int, error)
-	CreateInbound(inbound models.Inbound) (models.Inbound, error)
+	GetInbounds(search, warehouseId, supplierId string, page, limit int, scope models.WarehouseScope) ([]models.Inbound, int, error)
+	CreateInbound(inbound models.Inbound, numbering DocumentNumbering, validate func(product *models.Product) error, receive PurchaseOrderReceiver) (models.Inbound, []BackorderAllocation, error)
+	GetReceipts(filters map[string]interface{}, page, limit int, scope models.WarehouseScope) ([]models.InboundReceipt, int64, error)
+	GetReceiptByID(id uuid.UUID) (*models.InboundReceipt, error)
+	CreateReceipt(receipt models.InboundReceipt, numbering DocumentNumbering, validate func(products map[uuid.UUID]*models.Product) error, receivers []PurchaseOrderReceiver) (models.InboundReceipt, []BackorderAllocation, error)
 }
 
 type inboundRepo struct {
@@ -20,7 +27,7 @@ func NewInboundRepository() InboundRepository {
 	return &inboundRepo{db: database.GetDB()}
 }
 
-func (r *inboundRepo) GetInbounds(search, warehouseId string, page, limit int) ([]models.Inbound, int, error) {
+func (r *inboundRepo) GetInbounds(search, warehouseId, supplierId string, page, limit int, scope models.WarehouseScope) ([]models.Inbound, int, error) {
 	var inbounds []models.Inbound
 	var total int64
 
@@ -30,6 +37,7 @@ func (r *inboundRepo) GetInbounds(search, warehouseId string, page, limit int) (
 		Preload("Warehouse").
 		Preload("Product").
 		Preload("User")
+	query = applyWarehouseScope(query, "inbounds.warehouse_id", scope)
 
 	if search != "" {
 		searchPattern := "%" + search + "%"
@@ -42,6 +50,9 @@ func (r *inboundRepo) GetInbounds(search, warehouseId string, page, limit int) (
 	if warehouseId != "" {
 		query = query.Where("inbounds.warehouse_id = ?", warehouseId)
 	}
+	if supplierId != "" {
+		query = query.Where("inbounds.supplier_id = ?", supplierId)
+	}
 
 	err := query.Count(&total).Error
 	if err != nil {
@@ -56,7 +67,177 @@ func (r *inboundRepo) GetInbounds(search, warehouseId string, page, limit int) (
 	return inbounds, int(total), nil
 }
 
-func (r *inboundRepo) CreateInbound(inbound models.Inbound) (models.Inbound, error) {
-	err := r.db.Create(&inbound).Error
-	return inbound, err
+// CreateInbound simpan inbound dengan receipt_number yang dialokasikan di transaksi yang sama.
+// Product dikunci (FOR UPDATE) dan divalidasi lewat validate sebelum disimpan. Stok bertambah
+// lewat trigger fn_update_stock_inbound, lalu stok baru langsung dialokasikan ke item order
+// yang backorder. Inbound terhadap line PO menambah received_quantity line (divalidasi
+// receive) di transaksi yang sama.
+func (r *inboundRepo) CreateInbound(inbound models.Inbound, numbering DocumentNumbering, validate func(product *models.Product) error, receive PurchaseOrderReceiver) (models.Inbound, []BackorderAllocation, error) {
+	var allocations []BackorderAllocation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if inbound.PurchaseOrderID != nil {
+			if err := receivePurchaseOrderLine(tx, *inbound.PurchaseOrderID, receive); err != nil {
+				return err
+			}
+		}
+
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", inbound.ProductID).
+			First(&product).Error; err != nil {
+			return err
+		}
+		if err := validate(&product); err != nil {
+			return err
+		}
+
+		number, err := assignDocumentNumber(tx, numbering, inbound.WarehouseID)
+		if err != nil {
+			return err
+		}
+		inbound.ReceiptNumber = number
+		if err := tx.Create(&inbound).Error; err != nil {
+			return err
+		}
+
+		allocations, err = allocateBackorders(tx, inbound.ProductID)
+		return err
+	})
+	return inbound, allocations, err
+}
+
+func (r *inboundRepo) GetReceipts(filters map[string]interface{}, page, limit int, scope models.WarehouseScope) ([]models.InboundReceipt, int64, error) {
+	var receipts []models.InboundReceipt
+	var total int64
+
+	query := r.db.Model(&models.InboundReceipt{})
+	query = applyWarehouseScope(query, "warehouse_id", scope)
+
+	if warehouseID, ok := filters["warehouse_id"].(string); ok && warehouseID != "" {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+	if supplierID, ok := filters["supplier_id"].(string); ok && supplierID != "" {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+	if search, ok := filters["search"].(string); ok && search != "" {
+		pattern := "%" + search + "%"
+		query = query.Where("receipt_number ILIKE ? OR reference_number ILIKE ? OR supplier_name ILIKE ?", pattern, pattern, pattern)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := r.preloadReceipt(query).
+		Order("received_date DESC, created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&receipts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return receipts, total, nil
+}
+
+func (r *inboundRepo) GetReceiptByID(id uuid.UUID) (*models.InboundReceipt, error) {
+	var receipt models.InboundReceipt
+	if err := r.preloadReceipt(r.db).First(&receipt, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+func (r *inboundRepo) preloadReceipt(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("Warehouse").
+		Preload("User").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("receipt_number") }).
+		Preload("Lines.Product")
+}
+
+// CreateReceipt simpan header penerimaan dan semua line-nya dalam satu transaksi: gagal satu
+// line, tidak ada yang tersimpan. Line mendapat receipt_number header ditambah nomor urut.
+// receivers[i] (nil jika line tanpa PO) memvalidasi penerimaan line PO untuk receipt.Lines[i],
+// validate memeriksa product semua line yang sudah dikunci (product tidak ada = tidak ada di map).
+// Kunci diambil berurutan (PO, product urut id, sequence nomor dokumen) agar penerimaan paralel
+// tidak deadlock.
+func (r *inboundRepo) CreateReceipt(receipt models.InboundReceipt, numbering DocumentNumbering, validate func(products map[uuid.UUID]*models.Product) error, receivers []PurchaseOrderReceiver) (models.InboundReceipt, []BackorderAllocation, error) {
+	var allocations []BackorderAllocation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var orderIDs []uuid.UUID
+		seen := map[uuid.UUID]bool{}
+		for _, line := range receipt.Lines {
+			if line.PurchaseOrderID != nil && !seen[*line.PurchaseOrderID] {
+				seen[*line.PurchaseOrderID] = true
+				orderIDs = append(orderIDs, *line.PurchaseOrderID)
+			}
+		}
+		sort.Slice(orderIDs, func(i, j int) bool { return orderIDs[i].String() < orderIDs[j].String() })
+		for _, id := range orderIDs {
+			if _, err := lockPurchaseOrder(tx, id); err != nil {
+				return err
+			}
+		}
+		for i, line := range receipt.Lines {
+			if line.PurchaseOrderID != nil {
+				if err := receivePurchaseOrderLine(tx, *line.PurchaseOrderID, receivers[i]); err != nil {
+					return err
+				}
+			}
+		}
+
+		// semua product dikunci sekaligus urut id, trigger stok saat insert line tidak menunggu lagi
+		var productIDs []uuid.UUID
+		seen = map[uuid.UUID]bool{}
+		for _, line := range receipt.Lines {
+			if !seen[line.ProductID] {
+				seen[line.ProductID] = true
+				productIDs = append(productIDs, line.ProductID)
+			}
+		}
+		var locked []models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", productIDs).
+			Order("id").
+			Find(&locked).Error; err != nil {
+			return err
+		}
+		products := make(map[uuid.UUID]*models.Product, len(locked))
+		for i := range locked {
+			products[locked[i].ID] = &locked[i]
+		}
+		if err := validate(products); err != nil {
+			return err
+		}
+
+		number, err := assignDocumentNumber(tx, numbering, receipt.WarehouseID)
+		if err != nil {
+			return err
+		}
+		receipt.ReceiptNumber = number
+
+		lines := receipt.Lines
+		if err := tx.Omit(clause.Associations).Create(&receipt).Error; err != nil {
+			return err
+		}
+		for i := range lines {
+			line := &lines[i]
+			line.InboundReceiptID = &receipt.ID
+			line.ReceiptNumber = fmt.Sprintf("%s-%03d", number, i+1)
+			if err := tx.Omit(clause.Associations).Create(line).Error; err != nil {
+				return err
+			}
+		}
+		receipt.Lines = lines
+
+		for _, product := range locked {
+			allocated, err := allocateBackorders(tx, product.ID)
+			if err != nil {
+				return err
+			}
+			allocations = append(allocations, allocated...)
+		}
+		return nil
+	})
+	return receipt, allocations, err
 }
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"time"
+	"wms-be/domain/models"
+	"wms-be/infrastructure/database"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IPFailureStats ringkasan login gagal dari satu IP dalam satu window
+type IPFailureStats struct {
+	Count         int
+	FirstFailedAt *time.Time
+}
+
+// EmailFailureStats ringkasan login gagal ke email yang tidak terdaftar dalam satu window
+type EmailFailureStats struct {
+	Count        int
+	LastFailedAt *time.Time
+}
+
+type LoginAttemptRepository interface {
+	Record(attempt *models.LoginAttempt) error
+	GetIPFailureStats(ipAddress string, since time.Time) (*IPFailureStats, error)
+	GetUnknownEmailFailureStats(email string, since time.Time) (*EmailFailureStats, error)
+	GetByUser(userID uuid.UUID, page, limit int) ([]models.LoginAttempt, int, error)
+}
+
+type loginAttemptRepo struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepository() LoginAttemptRepository {
+	return &loginAttemptRepo{db: database.GetDB()}
+}
+
+func (r *loginAttemptRepo) Record(attempt *models.LoginAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+// GetIPFailureStats hanya menghitung kegagalan kredensial, percobaan yang ditolak karena throttle tidak dihitung
+func (r *loginAttemptRepo) GetIPFailureStats(ipAddress string, since time.Time) (*IPFailureStats, error) {
+	var stats IPFailureStats
+
+	err := r.db.Model(&models.LoginAttempt{}).
+		Select("COUNT(*) AS count, MIN(created_at) AS first_failed_at").
+		Where("ip_address = ? AND created_at > ? AND success = false", ipAddress, since).
+		Where("failure_reason IN ?", []string{
+			models.LoginFailureUnknownEmail,
+			models.LoginFailureWrongPassword,
+			models.LoginFailureInvalidTwoFactor,
+		}).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetUnknownEmailFailureStats hitung login gagal ke email yang tidak terdaftar (tanpa membedakan
+// huruf besar/kecil), dipakai untuk meniru counter gagal akun
+func (r *loginAttemptRepo) GetUnknownEmailFailureStats(email string, since time.Time) (*EmailFailureStats, error) {
+	var stats EmailFailureStats
+
+	err := r.db.Model(&models.LoginAttempt{}).
+		Select("COUNT(*) AS count, MAX(created_at) AS last_failed_at").
+		Where("lower(email) = lower(?) AND created_at > ? AND failure_reason = ?", email, since, models.LoginFailureUnknownEmail).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (r *loginAttemptRepo) GetByUser(userID uuid.UUID, page, limit int) ([]models.LoginAttempt, int, error) {
+	var attempts []models.LoginAttempt
+	var total int64
+
+	query := r.db.Model(&models.LoginAttempt{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&attempts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return attempts, int(total), nil
+}
@@ -1,18 +1,66 @@
 package repository
 
 import (
+	"time"
 	"wms-be/domain/models"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRepository interface {
-	CreateOrder(order *models.Order) (*models.Order, error)
-	UpdateOrderStatus(id string, status string) (*models.Order, error)
-	GetOrders(page, limit int, filters map[string]interface{}) ([]models.Order, int64, error)
+	CreateOrder(order *models.Order, numbering DocumentNumbering, allocate OrderAllocator, checkCredit CreditCheck) (*models.Order, error)
+	TransitionOrderStatus(id string, transition func(order *models.Order) (OrderStatusChange, error)) (*models.Order, error)
+	GetOrders(page, limit int, filters map[string]interface{}, scope models.WarehouseScope) ([]models.Order, int64, error)
 	GetOrderByID(id string) (*models.Order, error)
+	GetExpiredPendingOrderIDs(now time.Time, limit int) ([]string, error)
+	UpdateOrderItems(id string, update func(order *models.Order) error) (*models.Order, error)
+	ShipOrder(id string, numbering DocumentNumbering, ship OrderShipper) (*models.Order, error)
+	EditOrderItem(id string, productID uuid.UUID, edit OrderItemEditor, checkCredit CreditCheck) (*models.Order, error)
+	GetOrderItemChanges(orderID string) ([]models.OrderItemChange, error)
 }
 
+// OrderStatusChange status baru order beserta efek stok untuk semua item-nya
+type OrderStatusChange struct {
+	Status          string
+	ReleaseReserved bool // reserved_stock -= allocated_quantity, sisa item (allocated & backorder) dilepas
+}
+
+// OrderShipper susun pengiriman dari order yang sudah dikunci: quantity item order diubah di
+// tempat, dikembalikan shipment (tanpa nomor) dan status order berikutnya
+type OrderShipper func(order *models.Order) (*models.OrderShipment, OrderStatusChange, error)
+
+// OrderItemEdit satu perubahan item order hasil OrderItemEditor. Item berisi state item
+// setelah perubahan; Change dicatat sebagai riwayat.
+type OrderItemEdit struct {
+	Item          models.OrderItem
+	ReservedDelta int // reserved_stock += ReservedDelta
+	Change        models.OrderItemChange
+}
+
+// OrderItemEditor tentukan perubahan item terhadap order dan product yang sudah dikunci
+// (product nil jika tidak ditemukan)
+type OrderItemEditor func(order *models.Order, product *models.Product) (*OrderItemEdit, error)
+
+// BackorderAllocation stok masuk yang dialokasikan ke item order backorder
+type BackorderAllocation struct {
+	OrderID     uuid.UUID `json:"order_id"`
+	OrderNumber string    `json:"order_number"`
+	OrderItemID uuid.UUID `json:"order_item_id"`
+	ProductID   uuid.UUID `json:"product_id"`
+	Quantity    int       `json:"quantity"`
+}
+
+// OrderAllocator tentukan quantity tiap item order terhadap product yang sudah dikunci (FOR UPDATE).
+// products berisi product yang ditemukan, dengan key product ID. Item yang tersisa di order
+// setelah alokasi akan di-insert dan allocated_quantity-nya direservasi.
+type OrderAllocator func(order *models.Order, products map[uuid.UUID]*models.Product) error
+
+// CreditCheck validasi credit limit terhadap customer yang sudah dikunci dan tagihan
+// outstanding-nya, dijalankan setelah allocate / edit di transaksi yang sama
+type CreditCheck func(customer *models.Customer, outstanding CustomerOutstanding) error
+
 type orderRepo struct {
 	DB *gorm.DB
 }
@@ -21,9 +69,46 @@ func NewOrderRepository(DB *gorm.DB) OrderRepository {
 	return &orderRepo{DB: DB}
 }
 
-// CreateOrder membuat order beserta items
-func (r *orderRepo) CreateOrder(order *models.Order) (*models.Order, error) {
-	err := r.DB.Create(order).Error
+// CreateOrder membuat order beserta items. Product item dikunci, dialokasikan lewat allocate,
+// lalu order_number dan reserved_stock diterapkan di transaksi yang sama.
+func (r *orderRepo) CreateOrder(order *models.Order, numbering DocumentNumbering, allocate OrderAllocator, checkCredit CreditCheck) (*models.Order, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		// customer dikunci sebelum product, urutan yang sama dengan EditOrderItem
+		customer, err := lockCustomer(tx, order.CustomerID)
+		if err != nil {
+			return err
+		}
+		products, err := lockOrderProducts(tx, order.OrderItems)
+		if err != nil {
+			return err
+		}
+		if err := allocate(order, products); err != nil {
+			return err
+		}
+		outstanding, err := customerOutstanding(tx, customer.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkCredit(customer, outstanding); err != nil {
+			return err
+		}
+
+		number, err := assignDocumentNumber(tx, numbering, order.WarehouseID)
+		if err != nil {
+			return err
+		}
+		order.OrderNumber = number
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+
+		for _, item := range order.OrderItems {
+			if err := adjustReservedStock(tx, item.ProductID, item.AllocatedQuantity); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -35,28 +120,354 @@ func (r *orderRepo) CreateOrder(order *models.Order) (*models.Order, error) {
 	return order, nil
 }
 
-// UpdateOrderStatus update status dan kembalikan order lengkap
-func (r *orderRepo) UpdateOrderStatus(id string, status string) (*models.Order, error) {
+// TransitionOrderStatus kunci baris order (FOR UPDATE), tentukan perubahan lewat transition
+// lalu terapkan status dan efek stoknya dalam satu transaksi. Karena baris order dikunci,
+// dua perubahan status paralel tidak bisa sama-sama lolos validasi (efek stok tidak dobel).
+func (r *orderRepo) TransitionOrderStatus(id string, transition func(order *models.Order) (OrderStatusChange, error)) (*models.Order, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, id)
+		if err != nil {
+			return err
+		}
+
+		change, err := transition(order)
+		if err != nil {
+			return err
+		}
+
+		if err := applyOrderStockEffect(tx, order.OrderItems, change); err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+			"status":     change.Status,
+			"updated_at": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetOrderByID(id)
+}
+
+// lockOrderProducts kunci product semua item, urut berdasarkan ID agar order paralel
+// dengan product yang sama tidak deadlock
+func lockOrderProducts(tx *gorm.DB, items []models.OrderItem) (map[uuid.UUID]*models.Product, error) {
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+
+	var locked []models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&locked).Error; err != nil {
+		return nil, err
+	}
+
+	products := make(map[uuid.UUID]*models.Product, len(locked))
+	for i := range locked {
+		products[locked[i].ID] = &locked[i]
+	}
+	return products, nil
+}
+
+// applyOrderStockEffect lepas reservasi item order. Item dikosongkan dari allocated dan
+// backorder supaya tidak dialokasikan lagi saat stok masuk. Product dikunci lebih dulu
+// (urut ID) agar pembatalan paralel dengan product yang sama tidak deadlock.
+func applyOrderStockEffect(tx *gorm.DB, items []models.OrderItem, change OrderStatusChange) error {
+	if !change.ReleaseReserved {
+		return nil
+	}
+	if _, err := lockOrderProducts(tx, items); err != nil {
+		return err
+	}
+
+	for i := range items {
+		item := &items[i]
+		if err := adjustReservedStock(tx, item.ProductID, -item.AllocatedQuantity); err != nil {
+			return err
+		}
+		item.AllocatedQuantity = 0
+		item.PickedQuantity = 0
+		item.BackorderedQuantity = 0
+	}
+	return saveOrderItemQuantities(tx, items)
+}
+
+// adjustReservedStock reserved_stock += delta
+func adjustReservedStock(tx *gorm.DB, productID uuid.UUID, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	return tx.Model(&models.Product{}).Where("id = ?", productID).
+		Update("reserved_stock", gorm.Expr("reserved_stock + ?", delta)).Error
+}
+
+// saveOrderItemQuantities simpan kolom fulfilment item order
+func saveOrderItemQuantities(tx *gorm.DB, items []models.OrderItem) error {
+	for _, item := range items {
+		if err := tx.Model(&models.OrderItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+			"allocated_quantity":   item.AllocatedQuantity,
+			"picked_quantity":      item.PickedQuantity,
+			"shipped_quantity":     item.ShippedQuantity,
+			"backordered_quantity": item.BackorderedQuantity,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockOrder kunci baris order (FOR UPDATE) beserta item-nya
+func lockOrder(tx *gorm.DB, id string) (*models.Order, error) {
 	var order models.Order
-	// Update status
-	err := r.DB.Model(&order).Where("id = ?", id).Update("status", status).Error
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("OrderItems").
+		First(&order, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// UpdateOrderItems kunci order, ubah quantity fulfilment item lewat update lalu simpan
+func (r *orderRepo) UpdateOrderItems(id string, update func(order *models.Order) error) (*models.Order, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := update(order); err != nil {
+			return err
+		}
+		if err := saveOrderItemQuantities(tx, order.OrderItems); err != nil {
+			return err
+		}
+		return tx.Model(&models.Order{}).Where("id = ?", order.ID).Update("updated_at", time.Now()).Error
+	})
 	if err != nil {
 		return nil, err
 	}
-	// Ambil kembali order beserta items & product
-	err = r.DB.Preload("Warehouse").Preload("OrderItems.Product").First(&order, "id = ?", id).Error
+	return r.GetOrderByID(id)
+}
+
+// ShipOrder catat satu pengiriman: stock dan reserved_stock berkurang sebanyak quantity
+// yang dikirim, item order, shipment dan status order disimpan di transaksi yang sama
+func (r *orderRepo) ShipOrder(id string, numbering DocumentNumbering, ship OrderShipper) (*models.Order, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, id)
+		if err != nil {
+			return err
+		}
+
+		shipment, change, err := ship(order)
+		if err != nil {
+			return err
+		}
+
+		// kunci product urut ID sebelum update stok, seperti CreateOrder
+		if _, err := lockOrderProducts(tx, order.OrderItems); err != nil {
+			return err
+		}
+		for _, item := range shipment.Items {
+			if err := tx.Model(&models.Product{}).Where("id = ?", item.ProductID).Updates(map[string]interface{}{
+				"stock":          gorm.Expr("stock - ?", item.Quantity),
+				"reserved_stock": gorm.Expr("reserved_stock - ?", item.Quantity),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if err := saveOrderItemQuantities(tx, order.OrderItems); err != nil {
+			return err
+		}
+
+		number, err := assignDocumentNumber(tx, numbering, order.WarehouseID)
+		if err != nil {
+			return err
+		}
+		shipment.OrderID = order.ID
+		shipment.ShipmentNumber = number
+		if err := tx.Create(shipment).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+			"status":     change.Status,
+			"updated_at": time.Now(),
+		}).Error
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &order, nil
+	return r.GetOrderByID(id)
+}
+
+// EditOrderItem kunci order lalu product item yang diubah, terapkan perubahan item
+// (tambah / ubah quantity / hapus sesuai Change.Action), selisih reserved_stock dan riwayatnya
+// dalam satu transaksi. total_amount order dihitung ulang oleh trigger calculate_order_total.
+// checkCredit (boleh nil) dijalankan setelah edit dengan customer yang dikunci sebelum product.
+func (r *orderRepo) EditOrderItem(id string, productID uuid.UUID, edit OrderItemEditor, checkCredit CreditCheck) (*models.Order, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, id)
+		if err != nil {
+			return err
+		}
+
+		var customer *models.Customer
+		if checkCredit != nil {
+			if customer, err = lockCustomer(tx, order.CustomerID); err != nil {
+				return err
+			}
+		}
+
+		var locked []models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", productID).Find(&locked).Error; err != nil {
+			return err
+		}
+		var product *models.Product
+		if len(locked) > 0 {
+			product = &locked[0]
+		}
+
+		result, err := edit(order, product)
+		if err != nil {
+			return err
+		}
+		if customer != nil {
+			outstanding, err := customerOutstanding(tx, customer.ID)
+			if err != nil {
+				return err
+			}
+			if err := checkCredit(customer, outstanding); err != nil {
+				return err
+			}
+		}
+
+		item := result.Item
+		switch result.Change.Action {
+		case models.OrderItemAdded:
+			// unit_price & total_price diisi trigger set_order_item_prices
+			if err := tx.Omit(clause.Associations).Create(&item).Error; err != nil {
+				return err
+			}
+		case models.OrderItemRemoved:
+			if err := tx.Delete(&models.OrderItem{}, "id = ?", item.ID).Error; err != nil {
+				return err
+			}
+		default:
+			if err := tx.Model(&models.OrderItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+				"quantity":             item.Quantity,
+				"total_price":          gorm.Expr("unit_price * ?", item.Quantity),
+				"allocated_quantity":   item.AllocatedQuantity,
+				"picked_quantity":      item.PickedQuantity,
+				"backordered_quantity": item.BackorderedQuantity,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := adjustReservedStock(tx, product.ID, result.ReservedDelta); err != nil {
+			return err
+		}
+
+		change := result.Change
+		change.OrderID = order.ID
+		change.OrderItemID = item.ID
+		change.ProductID = product.ID
+		change.ReservedDelta = result.ReservedDelta
+		if err := tx.Create(&change).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Order{}).Where("id = ?", order.ID).Update("updated_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetOrderByID(id)
+}
+
+// GetOrderItemChanges riwayat perubahan item order, terlama dulu
+func (r *orderRepo) GetOrderItemChanges(orderID string) ([]models.OrderItemChange, error) {
+	var changes []models.OrderItemChange
+	err := r.DB.Where("order_id = ?", orderID).Order("changed_at").Find(&changes).Error
+	return changes, err
+}
+
+// allocateBackorders alokasikan available_stock product ke item order yang backorder,
+// order terlama dulu. Dipanggil di transaksi yang menambah stok (inbound). Order yang sedang
+// dikunci transaksi lain (dikirim / dibatalkan) dilewati (SKIP LOCKED) agar tidak deadlock
+// dengan jalur yang mengunci order lebih dulu; sisanya teralokasi di stok masuk berikutnya.
+func allocateBackorders(tx *gorm.DB, productID uuid.UUID) ([]BackorderAllocation, error) {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&product, "id = ?", productID).Error; err != nil {
+		return nil, err
+	}
+	available := product.Stock - product.ReservedStock
+	if available <= 0 {
+		return nil, nil
+	}
+
+	var items []struct {
+		models.OrderItem
+		OrderNumber string
+	}
+	err := tx.Raw(`
+		SELECT oi.*, o.order_number
+		FROM order_items oi
+		JOIN orders o ON o.id = oi.order_id
+		WHERE oi.product_id = ? AND oi.backordered_quantity > 0 AND o.status IN ?
+		ORDER BY o.created_at, oi.created_at
+		FOR UPDATE OF oi, o SKIP LOCKED
+	`, productID, []string{
+		models.OrderStatusPendingPayment,
+		models.OrderStatusConfirmed,
+		models.OrderStatusProcessing,
+		models.OrderStatusPartiallyShipped,
+	}).Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var allocations []BackorderAllocation
+	for _, item := range items {
+		if available == 0 {
+			break
+		}
+		quantity := min(item.BackorderedQuantity, available)
+		available -= quantity
+
+		if err := tx.Model(&models.OrderItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+			"allocated_quantity":   gorm.Expr("allocated_quantity + ?", quantity),
+			"backordered_quantity": gorm.Expr("backordered_quantity - ?", quantity),
+		}).Error; err != nil {
+			return nil, err
+		}
+		if err := adjustReservedStock(tx, productID, quantity); err != nil {
+			return nil, err
+		}
+
+		allocations = append(allocations, BackorderAllocation{
+			OrderID:     item.OrderID,
+			OrderNumber: item.OrderNumber,
+			OrderItemID: item.ID,
+			ProductID:   productID,
+			Quantity:    quantity,
+		})
+	}
+	return allocations, nil
 }
 
 // GetOrders ambil list order dengan filter dan pagination
-func (r *orderRepo) GetOrders(page, limit int, filters map[string]interface{}) ([]models.Order, int64, error) {
+func (r *orderRepo) GetOrders(page, limit int, filters map[string]interface{}, scope models.WarehouseScope) ([]models.Order, int64, error) {
 	var orders []models.Order
 	var total int64
 
 	query := r.DB.Model(&models.Order{})
+	query = applyWarehouseScope(query, "warehouse_id", scope)
 
 	if search, ok := filters["search"].(string); ok && search != "" {
 		query = query.Where("order_number LIKE ?", "%"+search+"%")
@@ -67,6 +478,9 @@ func (r *orderRepo) GetOrders(page, limit int, filters map[string]interface{}) (
 	if warehouseId, ok := filters["warehouse_id"].(string); ok && warehouseId != "" {
 		query = query.Where("warehouse_id = ?", warehouseId)
 	}
+	if customerId, ok := filters["customer_id"].(string); ok && customerId != "" {
+		query = query.Where("customer_id = ?", customerId)
+	}
 
 	// Hitung total
 	err := query.Count(&total).Error
@@ -76,6 +490,7 @@ func (r *orderRepo) GetOrders(page, limit int, filters map[string]interface{}) (
 
 	// Ambil data dengan preload nested relation
 	err = query.Preload("Warehouse").Preload("OrderItems.Product").
+		Order("created_at DESC").
 		Offset((page - 1) * limit).
 		Limit(limit).
 		Find(&orders).Error
@@ -90,9 +505,24 @@ func (r *orderRepo) GetOrders(page, limit int, filters map[string]interface{}) (
 func (r *orderRepo) GetOrderByID(id string) (*models.Order, error) {
 	var order models.Order
 	// Preload nested relation
-	err := r.DB.Preload("Warehouse").Preload("OrderItems.Product").First(&order, "id = ?", id).Error
+	err := r.DB.Preload("Warehouse").Preload("OrderItems.Product").
+		Preload("Shipments", func(db *gorm.DB) *gorm.DB { return db.Order("shipped_at") }).
+		Preload("Shipments.Items").
+		First(&order, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &order, nil
 }
+
+// GetExpiredPendingOrderIDs order pending_payment yang sudah lewat expires_at, terlama dulu
+// (memakai index parsial idx_orders_pending_expiration). expires_at kosong berarti tidak kedaluwarsa.
+func (r *orderRepo) GetExpiredPendingOrderIDs(now time.Time, limit int) ([]string, error) {
+	var ids []string
+	err := r.DB.Model(&models.Order{}).
+		Where("status = ? AND expires_at > ? AND expires_at <= ?", models.OrderStatusPendingPayment, time.Time{}, now).
+		Order("expires_at").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
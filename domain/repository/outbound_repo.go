@@ -5,11 +5,12 @@ import (
 	"wms-be/infrastructure/database"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OutboundRepository interface {
-	GetOutbounds(search, warehouseId string, page, limit int) ([]models.Outbound, int, error)
-	CreateOutbound(outbound models.Outbound) (models.Outbound, error)
+	GetOutbounds(search, warehouseId string, page, limit int, scope models.WarehouseScope) ([]models.Outbound, int, error)
+	CreateOutbound(outbound models.Outbound, numbering DocumentNumbering, validate func(product *models.Product) error) (models.Outbound, error)
 }
 
 type outboundRepo struct {
@@ -21,7 +22,7 @@ func NewOutboundRepository() OutboundRepository {
 }
 
 // GetOutbounds retrieves outbound records with optional filters.
-func (r *outboundRepo) GetOutbounds(search, warehouseId string, page, limit int) ([]models.Outbound, int, error) {
+func (r *outboundRepo) GetOutbounds(search, warehouseId string, page, limit int, scope models.WarehouseScope) ([]models.Outbound, int, error) {
 	var outbounds []models.Outbound
 	var total int64
 
@@ -31,6 +32,7 @@ func (r *outboundRepo) GetOutbounds(search, warehouseId string, page, limit int)
 		Preload("Warehouse").
 		Preload("Product").
 		Preload("User")
+	query = applyWarehouseScope(query, "outbounds.warehouse_id", scope)
 
 	if search != "" {
 		searchPattern := "%" + search + "%"
@@ -41,7 +43,7 @@ func (r *outboundRepo) GetOutbounds(search, warehouseId string, page, limit int)
 	}
 
 	if warehouseId != "" {
-		query = query.Where("warehouse_id = ?", warehouseId)
+		query = query.Where("outbounds.warehouse_id = ?", warehouseId)
 	}
 
 	err := query.Count(&total).Error
@@ -57,7 +59,33 @@ func (r *outboundRepo) GetOutbounds(search, warehouseId string, page, limit int)
 	return outbounds, int(total), nil
 }
 
-func (r *outboundRepo) CreateOutbound(outbound models.Outbound) (models.Outbound, error) {
-	err := r.db.Create(&outbound).Error
+// CreateOutbound mengunci baris product (FOR UPDATE), menjalankan validate terhadap stok
+// terkini lalu menyimpan outbound dalam satu transaksi. Pengurangan stok dilakukan
+// trigger fn_update_stock_outbound di transaksi yang sama, jadi outbound paralel
+// untuk product yang sama tidak bisa lolos validasi bersamaan.
+// reference_number kosong diisi nomor dokumen dari numbering.
+func (r *outboundRepo) CreateOutbound(outbound models.Outbound, numbering DocumentNumbering, validate func(product *models.Product) error) (models.Outbound, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", outbound.ProductID).
+			First(&product).Error; err != nil {
+			return err
+		}
+
+		if err := validate(&product); err != nil {
+			return err
+		}
+
+		if outbound.ReferenceNumber == "" {
+			number, err := assignDocumentNumber(tx, numbering, outbound.WarehouseID)
+			if err != nil {
+				return err
+			}
+			outbound.ReferenceNumber = number
+		}
+
+		return tx.Omit(clause.Associations).Create(&outbound).Error
+	})
 	return outbound, err
 }
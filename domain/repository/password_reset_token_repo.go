@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"time"
+	"wms-be/domain/models"
+	"wms-be/infrastructure/database"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PasswordResetTokenRepository interface {
+	Store(userID uuid.UUID, createdBy *uuid.UUID, token string, expiresAt time.Time) error
+	Redeem(token, passwordHash string) (uuid.UUID, error)
+	InvalidateForUser(userID uuid.UUID) error
+}
+
+type passwordResetTokenRepo struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetTokenRepository() PasswordResetTokenRepository {
+	return &passwordResetTokenRepo{db: database.GetDB()}
+}
+
+// Store simpan hash token reset ke DB
+func (r *passwordResetTokenRepo) Store(userID uuid.UUID, createdBy *uuid.UUID, token string, expiresAt time.Time) error {
+	prt := &models.PasswordResetToken{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: expiresAt,
+		CreatedBy: createdBy,
+	}
+	return r.db.Create(prt).Error
+}
+
+// Redeem pakai token reset untuk set password baru (sudah di-hash) dalam satu transaksi.
+// Token diklaim dengan UPDATE bersyarat used_at IS NULL, jadi dua request paralel dengan
+// token yang sama hanya satu yang berhasil. Mengembalikan user pemilik token, atau
+// gorm.ErrRecordNotFound jika token tidak valid / expired / sudah dipakai.
+func (r *passwordResetTokenRepo) Redeem(token, passwordHash string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var prt models.PasswordResetToken
+		now := time.Now()
+		err := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashToken(token), now).
+			First(&prt).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", prt.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return gorm.ErrRecordNotFound
+		}
+
+		userID = prt.UserID
+		return tx.Model(&models.User{}).
+			Where("id = ?", prt.UserID).
+			Updates(map[string]interface{}{"password_hash": passwordHash, "updated_at": now}).Error
+	})
+	return userID, err
+}
+
+// InvalidateForUser matikan semua token reset user yang belum dipakai
+func (r *passwordResetTokenRepo) InvalidateForUser(userID uuid.UUID) error {
+	return r.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
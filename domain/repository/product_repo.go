@@ -9,7 +9,7 @@ import (
 )
 
 type ProductRepository interface {
-	GetProducts(search, warehouseId, category string, page, limit int) ([]models.Product, int, error)
+	GetProducts(search, warehouseId, category string, page, limit int, scope models.WarehouseScope) ([]models.Product, int, error)
 	GetProductBySKU(sku string) (models.Product, error)
 	GetProductByID(id string) (models.Product, error)
 	CreateProduct(product models.Product) (models.Product, error)
@@ -25,11 +25,12 @@ func NewProductRepository() ProductRepository {
 	return &productRepo{db: database.GetDB()}
 }
 
-func (r *productRepo) GetProducts(search, warehouseId, category string, page, limit int) ([]models.Product, int, error) {
+func (r *productRepo) GetProducts(search, warehouseId, category string, page, limit int, scope models.WarehouseScope) ([]models.Product, int, error) {
 	var products []models.Product
 	var total int64
 
 	query := r.db.Model(&models.Product{}).Where("is_active = true").Preload("Warehouse")
+	query = applyWarehouseScope(query, "warehouse_id", scope)
 
 	if search != "" {
 		query = query.Where("name ILIKE ? OR sku ILIKE ? OR description ILIKE ?", "%"+search+"%", "%"+search+"%", "%"+search+"%")
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"time"
+	"wms-be/domain/models"
+	"wms-be/infrastructure/database"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PurchaseOrderRepository interface {
+	Create(order *models.PurchaseOrder, numbering DocumentNumbering) error
+	GetByID(id uuid.UUID) (*models.PurchaseOrder, error)
+	GetByLineID(lineID uuid.UUID) (*models.PurchaseOrder, error)
+	GetAll(filters map[string]interface{}, page, limit int, scope models.WarehouseScope) ([]models.PurchaseOrder, int64, error)
+	Close(id uuid.UUID, apply func(order *models.PurchaseOrder) error) (*models.PurchaseOrder, error)
+	Cancel(id uuid.UUID, apply func(order *models.PurchaseOrder) error) (*models.PurchaseOrder, error)
+	GetOpenLines(filters map[string]interface{}, scope models.WarehouseScope) ([]OpenPurchaseOrderLine, error)
+}
+
+// PurchaseOrderReceiver validasi penerimaan inbound terhadap PO yang sudah dikunci dan
+// kembalikan line yang received_quantity-nya sudah ditambah
+type PurchaseOrderReceiver func(order *models.PurchaseOrder) (*models.PurchaseOrderLine, error)
+
+// OpenPurchaseOrderLine satu baris laporan PO terbuka: line yang masih menunggu barang
+type OpenPurchaseOrderLine struct {
+	PurchaseOrderID     uuid.UUID  `json:"purchase_order_id"`
+	PONumber            string     `json:"po_number"`
+	Status              string     `json:"status"`
+	SupplierID          uuid.UUID  `json:"supplier_id"`
+	SupplierCode        string     `json:"supplier_code"`
+	SupplierName        string     `json:"supplier_name"`
+	WarehouseID         uuid.UUID  `json:"warehouse_id"`
+	WarehouseName       string     `json:"warehouse_name"`
+	LineID              uuid.UUID  `json:"line_id"`
+	ProductID           uuid.UUID  `json:"product_id"`
+	ProductSKU          string     `json:"product_sku"`
+	ProductName         string     `json:"product_name"`
+	OrderedQuantity     int        `json:"ordered_quantity"`
+	ReceivedQuantity    int        `json:"received_quantity"`
+	OutstandingQuantity int        `json:"outstanding_quantity"`
+	UnitCost            float64    `json:"unit_cost"`
+	OutstandingValue    float64    `json:"outstanding_value"`
+	OrderDate           time.Time  `json:"order_date"`
+	ExpectedDate        *time.Time `json:"expected_date"`
+	DaysOverdue         int        `json:"days_overdue"` // 0 jika belum lewat expected_date
+}
+
+type purchaseOrderRepo struct {
+	db *gorm.DB
+}
+
+func NewPurchaseOrderRepository() PurchaseOrderRepository {
+	return &purchaseOrderRepo{db: database.GetDB()}
+}
+
+func (r *purchaseOrderRepo) preload(query *gorm.DB) *gorm.DB {
+	return query.Preload("Supplier").
+		Preload("Warehouse").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") }).
+		Preload("Lines.Product")
+}
+
+// Create simpan PO beserta line-nya, po_number dialokasikan per gudang
+func (r *purchaseOrderRepo) Create(order *models.PurchaseOrder, numbering DocumentNumbering) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		number, err := assignDocumentNumber(tx, numbering, order.WarehouseID)
+		if err != nil {
+			return err
+		}
+		order.PONumber = number
+		if err := tx.Omit(clause.Associations).Create(order).Error; err != nil {
+			return err
+		}
+
+		for i := range order.Lines {
+			order.Lines[i].PurchaseOrderID = order.ID
+		}
+		return tx.Omit(clause.Associations).Create(&order.Lines).Error
+	})
+}
+
+// GetByID PO lengkap beserta inbound yang sudah diterima terhadapnya
+func (r *purchaseOrderRepo) GetByID(id uuid.UUID) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	query := r.preload(r.db).Preload("Receipts", func(db *gorm.DB) *gorm.DB { return db.Order("received_date, created_at") })
+	if err := query.First(&order, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *purchaseOrderRepo) GetByLineID(lineID uuid.UUID) (*models.PurchaseOrder, error) {
+	var line models.PurchaseOrderLine
+	if err := r.db.Select("id", "purchase_order_id").First(&line, "id = ?", lineID).Error; err != nil {
+		return nil, err
+	}
+	return r.GetByID(line.PurchaseOrderID)
+}
+
+func (r *purchaseOrderRepo) GetAll(filters map[string]interface{}, page, limit int, scope models.WarehouseScope) ([]models.PurchaseOrder, int64, error) {
+	var orders []models.PurchaseOrder
+	var total int64
+
+	query := r.db.Model(&models.PurchaseOrder{})
+	query = applyWarehouseScope(query, "warehouse_id", scope)
+
+	if status, ok := filters["status"].(string); ok && status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if warehouseID, ok := filters["warehouse_id"].(string); ok && warehouseID != "" {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+	if supplierID, ok := filters["supplier_id"].(string); ok && supplierID != "" {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+	if search, ok := filters["search"].(string); ok && search != "" {
+		query = query.Where("po_number ILIKE ? OR reference_number ILIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := r.preload(query).
+		Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&orders).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+// lockPurchaseOrder kunci baris PO (FOR UPDATE) beserta line-nya. Semua perubahan PO
+// (penerimaan, close, cancel) lewat kunci ini agar tidak balapan.
+func lockPurchaseOrder(tx *gorm.DB, id uuid.UUID) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("purchase_order_id = ?", order.ID).Order("created_at, id").Find(&order.Lines).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func savePurchaseOrderStatus(tx *gorm.DB, order *models.PurchaseOrder) error {
+	order.UpdatedAt = time.Now()
+	return tx.Model(&models.PurchaseOrder{}).Where("id = ?", order.ID).
+		Select("status", "closed_by", "closed_at", "close_reason", "cancelled_by", "cancelled_at", "cancel_reason", "updated_at").
+		Updates(order).Error
+}
+
+// receivePurchaseOrderLine terapkan penerimaan inbound ke PO dalam transaksi inbound:
+// kunci PO, validasi lewat receive, lalu simpan received_quantity line dan status PO
+func receivePurchaseOrderLine(tx *gorm.DB, orderID uuid.UUID, receive PurchaseOrderReceiver) error {
+	order, err := lockPurchaseOrder(tx, orderID)
+	if err != nil {
+		return err
+	}
+	line, err := receive(order)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Model(&models.PurchaseOrderLine{}).Where("id = ?", line.ID).Updates(map[string]interface{}{
+		"received_quantity": line.ReceivedQuantity,
+		"updated_at":        time.Now(),
+	}).Error
+	if err != nil {
+		return err
+	}
+	return savePurchaseOrderStatus(tx, order)
+}
+
+func (r *purchaseOrderRepo) withLockedOrder(id uuid.UUID, apply func(order *models.PurchaseOrder) error) (*models.PurchaseOrder, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		order, err := lockPurchaseOrder(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := apply(order); err != nil {
+			return err
+		}
+		return savePurchaseOrderStatus(tx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// Close tutup PO secara manual (short close), sisa yang belum diterima tidak ditunggu lagi
+func (r *purchaseOrderRepo) Close(id uuid.UUID, apply func(order *models.PurchaseOrder) error) (*models.PurchaseOrder, error) {
+	return r.withLockedOrder(id, apply)
+}
+
+func (r *purchaseOrderRepo) Cancel(id uuid.UUID, apply func(order *models.PurchaseOrder) error) (*models.PurchaseOrder, error) {
+	return r.withLockedOrder(id, apply)
+}
+
+// GetOpenLines laporan line PO open / partially_received yang belum diterima penuh,
+// diurutkan dari expected_date paling awal
+func (r *purchaseOrderRepo) GetOpenLines(filters map[string]interface{}, scope models.WarehouseScope) ([]OpenPurchaseOrderLine, error) {
+	var rows []OpenPurchaseOrderLine
+
+	query := r.db.Table("purchase_order_lines l").
+		Select(`po.id AS purchase_order_id, po.po_number, po.status,
+			s.id AS supplier_id, s.code AS supplier_code, s.name AS supplier_name,
+			w.id AS warehouse_id, w.name AS warehouse_name,
+			l.id AS line_id, p.id AS product_id, p.sku AS product_sku, p.name AS product_name,
+			l.ordered_quantity, l.received_quantity,
+			l.ordered_quantity - l.received_quantity AS outstanding_quantity,
+			l.unit_cost,
+			(l.ordered_quantity - l.received_quantity) * l.unit_cost AS outstanding_value,
+			po.order_date, po.expected_date,
+			GREATEST(COALESCE(CURRENT_DATE - po.expected_date::date, 0), 0) AS days_overdue`).
+		Joins("JOIN purchase_orders po ON po.id = l.purchase_order_id").
+		Joins("JOIN suppliers s ON s.id = po.supplier_id").
+		Joins("JOIN warehouses w ON w.id = po.warehouse_id").
+		Joins("JOIN products p ON p.id = l.product_id").
+		Where("po.status IN ?", []string{models.PurchaseOrderStatusOpen, models.PurchaseOrderStatusPartiallyReceived}).
+		Where("l.received_quantity < l.ordered_quantity")
+	query = applyWarehouseScope(query, "po.warehouse_id", scope)
+
+	if supplierID, ok := filters["supplier_id"].(string); ok && supplierID != "" {
+		query = query.Where("po.supplier_id = ?", supplierID)
+	}
+	if warehouseID, ok := filters["warehouse_id"].(string); ok && warehouseID != "" {
+		query = query.Where("po.warehouse_id = ?", warehouseID)
+	}
+	if overdue, ok := filters["overdue"].(bool); ok && overdue {
+		query = query.Where("po.expected_date::date < CURRENT_DATE")
+	}
+
+	err := query.Order("po.expected_date NULLS LAST, po.po_number, l.created_at").Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"time"
+	"wms-be/domain/models"
+	"wms-be/infrastructure/database"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository interface {
+	ReplaceForUser(userID uuid.UUID, codes []string) error
+	Use(userID uuid.UUID, code string) (bool, error)
+	CountUnused(userID uuid.UUID) (int, error)
+	DeleteForUser(userID uuid.UUID) error
+}
+
+type recoveryCodeRepo struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository() RecoveryCodeRepository {
+	return &recoveryCodeRepo{db: database.GetDB()}
+}
+
+// ReplaceForUser hapus recovery code lama lalu simpan hash code baru
+func (r *recoveryCodeRepo) ReplaceForUser(userID uuid.UUID, codes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		rows := make([]models.RecoveryCode, 0, len(codes))
+		for _, code := range codes {
+			rows = append(rows, models.RecoveryCode{UserID: userID, CodeHash: hashToken(code)})
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+// Use tandai code terpakai, false jika tidak ada atau sudah pernah dipakai
+func (r *recoveryCodeRepo) Use(userID uuid.UUID, code string) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashToken(code)).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *recoveryCodeRepo) CountUnused(userID uuid.UUID) (int, error) {
+	var count int64
+	err := r.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return int(count), err
+}
+
+func (r *recoveryCodeRepo) DeleteForUser(userID uuid.UUID) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
@@ -13,9 +13,15 @@ import (
 )
 
 type RefreshTokenRepository interface {
-	Store(userID uuid.UUID, token string, expiresAt time.Time) error
+	Store(userID, familyID uuid.UUID, token string, expiresAt time.Time, client models.ClientInfo) error
 	FindByToken(token string) (*models.RefreshToken, error)
-	Revoke(token string) error
+	FindByTokenIncludingRevoked(token string) (*models.RefreshToken, error)
+	Revoke(userID uuid.UUID, token string) error
+	RevokeIfActive(token string) (bool, error)
+	RevokeFamily(familyID uuid.UUID) error
+	RevokeFamilyForUser(userID, familyID uuid.UUID) (bool, error)
+	RevokeAllForUser(userID uuid.UUID) error
+	ListActiveSessions(userID uuid.UUID) ([]models.Session, error)
 	DeleteExpired() error
 }
 
@@ -33,14 +39,19 @@ func hashToken(token string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// Store simpan refresh token ke DB
-func (r *refreshTokenRepo) Store(userID uuid.UUID, token string, expiresAt time.Time) error {
+// Store simpan refresh token ke DB beserta info client
+func (r *refreshTokenRepo) Store(userID, familyID uuid.UUID, token string, expiresAt time.Time, client models.ClientInfo) error {
+	now := time.Now()
 	rt := &models.RefreshToken{
-		UserID:    userID,
-		TokenHash: hashToken(token),
-		ExpiresAt: expiresAt,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		UserID:     userID,
+		FamilyID:   familyID,
+		TokenHash:  hashToken(token),
+		ExpiresAt:  expiresAt,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		LastUsedAt: &now,
 	}
 	return r.db.Create(rt).Error
 }
@@ -59,17 +70,86 @@ func (r *refreshTokenRepo) FindByToken(token string) (*models.RefreshToken, erro
 	return &rt, err
 }
 
-// Revoke tandai token sebagai revoked
-func (r *refreshTokenRepo) Revoke(token string) error {
-	tokenHash := hashToken(token)
+// FindByTokenIncludingRevoked cari token termasuk yang sudah revoked/expired
+// (dipakai untuk deteksi reuse refresh token)
+func (r *refreshTokenRepo) FindByTokenIncludingRevoked(token string) (*models.RefreshToken, error) {
+	var rt models.RefreshToken
+
+	err := r.db.Where("token_hash = ?", hashToken(token)).First(&rt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("refresh token not found")
+	}
+	return &rt, err
+}
+
+// revokeWhere tandai token yang cocok dengan kondisi sebagai revoked dengan alasan reason,
+// mengembalikan jumlah token yang baru saja direvoke
+func (r *refreshTokenRepo) revokeWhere(reason, query string, args ...interface{}) (int64, error) {
 	now := time.Now()
 
-	return r.db.Model(&models.RefreshToken{}).
-		Where("token_hash = ?", tokenHash).
+	result := r.db.Model(&models.RefreshToken{}).
+		Where(query, args...).
+		Where("revoked_at IS NULL").
 		Updates(map[string]interface{}{
-			"revoked_at": now,
-			"updated_at": now,
-		}).Error
+			"revoked_at":     now,
+			"revoked_reason": reason,
+			"updated_at":     now,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// Revoke tandai token milik user sebagai revoked (logout), token milik user lain diabaikan
+func (r *refreshTokenRepo) Revoke(userID uuid.UUID, token string) error {
+	_, err := r.revokeWhere(models.RefreshTokenRevokedLogout, "user_id = ? AND token_hash = ?", userID, hashToken(token))
+	return err
+}
+
+// RevokeIfActive revoke token karena rotasi, hanya jika belum revoked.
+// false berarti token sudah dipakai/revoked lebih dulu (misal request rotasi paralel).
+func (r *refreshTokenRepo) RevokeIfActive(token string) (bool, error) {
+	affected, err := r.revokeWhere(models.RefreshTokenRevokedRotated, "token_hash = ?", hashToken(token))
+	return affected > 0, err
+}
+
+// RevokeFamily revoke semua token dalam satu family (reuse terdeteksi)
+func (r *refreshTokenRepo) RevokeFamily(familyID uuid.UUID) error {
+	_, err := r.revokeWhere(models.RefreshTokenRevokedReuse, "family_id = ?", familyID)
+	return err
+}
+
+// RevokeFamilyForUser revoke satu sesi (family) milik user tertentu.
+// false berarti sesi tidak ditemukan / bukan milik user / sudah tidak aktif.
+func (r *refreshTokenRepo) RevokeFamilyForUser(userID, familyID uuid.UUID) (bool, error) {
+	affected, err := r.revokeWhere(models.RefreshTokenRevokedSignOut, "user_id = ? AND family_id = ?", userID, familyID)
+	return affected > 0, err
+}
+
+// RevokeAllForUser revoke semua refresh token aktif milik user
+func (r *refreshTokenRepo) RevokeAllForUser(userID uuid.UUID) error {
+	_, err := r.revokeWhere(models.RefreshTokenRevokedAllSessions, "user_id = ?", userID)
+	return err
+}
+
+// ListActiveSessions daftar sesi aktif user, satu baris per family
+func (r *refreshTokenRepo) ListActiveSessions(userID uuid.UUID) ([]models.Session, error) {
+	var sessions []models.Session
+
+	err := r.db.Raw(`
+		SELECT rt.family_id AS id,
+			COALESCE(rt.user_agent, '') AS user_agent,
+			COALESCE(rt.ip_address, '') AS ip_address,
+			(SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = rt.family_id) AS started_at,
+			rt.last_used_at,
+			rt.expires_at
+		FROM refresh_tokens rt
+		WHERE rt.user_id = ? AND rt.revoked_at IS NULL AND rt.expires_at > ?
+		ORDER BY rt.last_used_at DESC NULLS LAST
+	`, userID, time.Now()).Scan(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
 }
 
 // DeleteExpired bersihkan token yang sudah lewat masa berlaku
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"wms-be/domain/models"
+	"wms-be/infrastructure/database"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RevokedAccessTokenRepository interface {
+	Revoke(jti, userID uuid.UUID, expiresAt time.Time) error
+	IsRevoked(jti uuid.UUID) (bool, error)
+	RevokeSession(familyID, userID uuid.UUID, expiresAt time.Time) error
+	IsSessionRevoked(familyID uuid.UUID) (bool, error)
+	RevokeAllForUser(userID uuid.UUID, before time.Time) error
+	GetUserCutoff(userID uuid.UUID) (*time.Time, error)
+	DeleteExpired() error
+}
+
+type revokedAccessTokenRepo struct {
+	db *gorm.DB
+}
+
+func NewRevokedAccessTokenRepository() RevokedAccessTokenRepository {
+	return &revokedAccessTokenRepo{db: database.GetDB()}
+}
+
+// Revoke masukkan jti ke denylist (abaikan jika sudah ada)
+func (r *revokedAccessTokenRepo) Revoke(jti, userID uuid.UUID, expiresAt time.Time) error {
+	entry := &models.RevokedAccessToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+		RevokedAt: time.Now(),
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(entry).Error
+}
+
+// IsRevoked cek jti ada di denylist
+func (r *revokedAccessTokenRepo) IsRevoked(jti uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RevokedAccessToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+// RevokeSession masukkan sesi ke denylist, expiresAt diperpanjang jika sudah ada
+func (r *revokedAccessTokenRepo) RevokeSession(familyID, userID uuid.UUID, expiresAt time.Time) error {
+	entry := &models.RevokedSession{
+		FamilyID:  familyID,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+		RevokedAt: time.Now(),
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "family_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"expires_at": gorm.Expr("GREATEST(revoked_sessions.expires_at, EXCLUDED.expires_at)")}),
+	}).Create(entry).Error
+}
+
+// IsSessionRevoked cek sesi ada di denylist
+func (r *revokedAccessTokenRepo) IsSessionRevoked(familyID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RevokedSession{}).Where("family_id = ?", familyID).Count(&count).Error
+	return count > 0, err
+}
+
+// RevokeAllForUser set batas waktu, token user yang diterbitkan sebelum/pada waktu ini dicabut
+func (r *revokedAccessTokenRepo) RevokeAllForUser(userID uuid.UUID, before time.Time) error {
+	entry := &models.UserTokenRevocation{
+		UserID:        userID,
+		RevokedBefore: before,
+		UpdatedAt:     time.Now(),
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before", "updated_at"}),
+	}).Create(entry).Error
+}
+
+// GetUserCutoff ambil batas waktu pencabutan user, nil jika belum pernah
+func (r *revokedAccessTokenRepo) GetUserCutoff(userID uuid.UUID) (*time.Time, error) {
+	var entry models.UserTokenRevocation
+	err := r.db.Where("user_id = ?", userID).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry.RevokedBefore, nil
+}
+
+// DeleteExpired bersihkan denylist (token dan sesi) yang sudah expired
+func (r *revokedAccessTokenRepo) DeleteExpired() error {
+	now := time.Now()
+	if err := r.db.Where("expires_at < ?", now).Delete(&models.RevokedAccessToken{}).Error; err != nil {
+		return err
+	}
+	return r.db.Where("expires_at < ?", now).Delete(&models.RevokedSession{}).Error
+}
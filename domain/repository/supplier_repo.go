@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"time"
+	"wms-be/domain/models"
+	"wms-be/infrastructure/database"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SupplierRepository interface {
+	CreateSupplier(supplier *models.Supplier) error
+	GetSuppliers(filters map[string]interface{}, page, limit int) ([]models.Supplier, int64, error)
+	GetSupplierByID(id uuid.UUID) (*models.Supplier, error)
+	UpdateSupplier(supplier *models.Supplier) error
+	DeleteSupplier(id uuid.UUID) error
+	MergeSuppliers(targetID uuid.UUID, sourceIDs []uuid.UUID) (int64, error)
+	GetPerformance(filters map[string]interface{}, scope models.WarehouseScope) ([]SupplierPerformance, error)
+}
+
+// SupplierPerformance ringkasan penerimaan barang dari satu supplier dalam periode laporan
+type SupplierPerformance struct {
+	SupplierID        uuid.UUID `json:"supplier_id"`
+	SupplierCode      string    `json:"supplier_code"`
+	SupplierName      string    `json:"supplier_name"`
+	Receipts          int64     `json:"receipts"` // pengiriman: satu inbound receipt multi-line dihitung sekali
+	DeliveredQuantity int64     `json:"delivered_quantity"`
+	TotalSpend        float64   `json:"total_spend"`
+	AvgLeadTimeDays   *float64  `json:"avg_lead_time_days"`    // null jika tidak ada inbound dengan ordered_date
+	ScheduledReceipts int64     `json:"scheduled_receipts"`    // pengiriman dengan expected_date
+	OnTimeReceipts    int64     `json:"on_time_receipts"`      // semua line diterima paling lambat di expected_date
+	OnTimeRate        *float64  `json:"on_time_rate" gorm:"-"` // persen, dihitung di service
+}
+
+type supplierRepo struct {
+	db *gorm.DB
+}
+
+func NewSupplierRepository() SupplierRepository {
+	return &supplierRepo{db: database.GetDB()}
+}
+
+func (r *supplierRepo) CreateSupplier(supplier *models.Supplier) error {
+	return r.db.Create(supplier).Error
+}
+
+func (r *supplierRepo) GetSuppliers(filters map[string]interface{}, page, limit int) ([]models.Supplier, int64, error) {
+	var suppliers []models.Supplier
+	var total int64
+
+	query := r.db.Model(&models.Supplier{})
+	if search, ok := filters["search"].(string); ok && search != "" {
+		pattern := "%" + search + "%"
+		query = query.Where("code ILIKE ? OR name ILIKE ? OR email ILIKE ? OR tax_id ILIKE ?", pattern, pattern, pattern, pattern)
+	}
+	if active, ok := filters["is_active"].(bool); ok {
+		query = query.Where("is_active = ?", active)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("name").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&suppliers).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return suppliers, total, nil
+}
+
+func (r *supplierRepo) GetSupplierByID(id uuid.UUID) (*models.Supplier, error) {
+	var supplier models.Supplier
+	if err := r.db.First(&supplier, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+func (r *supplierRepo) UpdateSupplier(supplier *models.Supplier) error {
+	supplier.UpdatedAt = time.Now()
+	result := r.db.Model(&models.Supplier{}).Where("id = ?", supplier.ID).
+		Select("code", "name", "contact_person", "email", "phone", "address", "tax_id", "lead_time_days", "is_active", "notes", "updated_at").
+		Updates(supplier)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteSupplier hapus supplier; gagal (foreign key) jika supplier sudah punya inbound
+func (r *supplierRepo) DeleteSupplier(id uuid.UUID) error {
+	result := r.db.Where("id = ?", id).Delete(&models.Supplier{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// MergeSuppliers gabungkan supplier duplikat (ejaan berbeda) ke target: inbound, inbound receipt
+// dan purchase order dipindah ke target lalu supplier sumber dihapus. Mengembalikan jumlah inbound yang dipindah.
+func (r *supplierRepo) MergeSuppliers(targetID uuid.UUID, sourceIDs []uuid.UUID) (int64, error) {
+	var moved int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var suppliers []models.Supplier
+		ids := append([]uuid.UUID{targetID}, sourceIDs...)
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id IN ?", ids).
+			Order("id").
+			Find(&suppliers).Error
+		if err != nil {
+			return err
+		}
+		if len(suppliers) != len(ids) {
+			return gorm.ErrRecordNotFound
+		}
+
+		result := tx.Model(&models.Inbound{}).
+			Where("supplier_id IN ?", sourceIDs).
+			Update("supplier_id", targetID)
+		if result.Error != nil {
+			return result.Error
+		}
+		moved = result.RowsAffected
+
+		err = tx.Model(&models.InboundReceipt{}).
+			Where("supplier_id IN ?", sourceIDs).
+			Update("supplier_id", targetID).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.PurchaseOrder{}).
+			Where("supplier_id IN ?", sourceIDs).
+			Update("supplier_id", targetID).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("id IN ?", sourceIDs).Delete(&models.Supplier{}).Error
+	})
+	return moved, err
+}
+
+// GetPerformance laporan per supplier dari inbound: quantity diterima, spend (total_cost),
+// rata-rata lead time (ordered_date → received_date) dan jumlah penerimaan tepat waktu.
+// Penerimaan dihitung per pengiriman (inbound_receipt_id, atau inbound itu sendiri jika
+// tanpa receipt), bukan per line; pengiriman tepat waktu jika tidak ada line yang terlambat.
+func (r *supplierRepo) GetPerformance(filters map[string]interface{}, scope models.WarehouseScope) ([]SupplierPerformance, error) {
+	var rows []SupplierPerformance
+
+	query := r.db.Table("suppliers s").
+		Select(`s.id AS supplier_id, s.code AS supplier_code, s.name AS supplier_name,
+			COUNT(DISTINCT COALESCE(i.inbound_receipt_id, i.id)) AS receipts,
+			COALESCE(SUM(i.quantity), 0) AS delivered_quantity,
+			COALESCE(SUM(i.total_cost), 0) AS total_spend,
+			AVG(EXTRACT(EPOCH FROM (i.received_date - i.ordered_date)) / 86400) FILTER (WHERE i.ordered_date IS NOT NULL) AS avg_lead_time_days,
+			COUNT(DISTINCT COALESCE(i.inbound_receipt_id, i.id)) FILTER (WHERE i.expected_date IS NOT NULL) AS scheduled_receipts,
+			COUNT(DISTINCT COALESCE(i.inbound_receipt_id, i.id)) FILTER (WHERE i.expected_date IS NOT NULL)
+				- COUNT(DISTINCT COALESCE(i.inbound_receipt_id, i.id)) FILTER (WHERE i.received_date::date > i.expected_date::date) AS on_time_receipts`).
+		Joins("JOIN inbounds i ON i.supplier_id = s.id")
+	query = applyWarehouseScope(query, "i.warehouse_id", scope)
+
+	if supplierID, ok := filters["supplier_id"].(uuid.UUID); ok {
+		query = query.Where("s.id = ?", supplierID)
+	}
+	if warehouseID, ok := filters["warehouse_id"].(string); ok && warehouseID != "" {
+		query = query.Where("i.warehouse_id = ?", warehouseID)
+	}
+	if from, ok := filters["from"].(time.Time); ok {
+		query = query.Where("i.received_date >= ?", from)
+	}
+	if to, ok := filters["to"].(time.Time); ok {
+		query = query.Where("i.received_date < ?", to)
+	}
+
+	err := query.Group("s.id, s.code, s.name").
+		Order("total_spend DESC, s.name").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -5,10 +5,12 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TransactionRepository interface {
 	CreateTransaction(transaction *models.Transaction) (*models.Transaction, error)
+	CreateTransfer(transaction *models.Transaction, numbering DocumentNumbering, validate func(product *models.Product) error) (*models.Transaction, error)
 	GetTransactionsByWarehouse(warehouseID uuid.UUID, limit, offset int) ([]models.Transaction, error)
 	GetTransactionByID(id uuid.UUID) (*models.Transaction, error)
 	GetAllTransactions(limit, offset int) ([]models.Transaction, error) // Corrected interface method
@@ -32,6 +34,44 @@ func (r *transactionRepository) CreateTransaction(transaction *models.Transactio
 	return transaction, nil
 }
 
+// CreateTransfer jalankan transfer_stock dan simpan baris ledger dalam satu transaksi DB,
+// jika salah satu gagal keduanya di-rollback. Product asal dikunci (FOR UPDATE) lebih dulu
+// agar validate melihat stok terkini. reference_number kosong diisi nomor transfer.
+func (r *transactionRepository) CreateTransfer(transaction *models.Transaction, numbering DocumentNumbering, validate func(product *models.Product) error) (*models.Transaction, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND warehouse_id = ?", transaction.ProductID, transaction.WarehouseID).
+			First(&product).Error; err != nil {
+			return err
+		}
+
+		if err := validate(&product); err != nil {
+			return err
+		}
+
+		if transaction.ReferenceNumber == "" {
+			number, err := assignDocumentNumber(tx, numbering, transaction.WarehouseID)
+			if err != nil {
+				return err
+			}
+			transaction.ReferenceNumber = number
+		}
+
+		if err := tx.Exec(`SELECT public.transfer_stock(?, ?, ?, ?, ?, ?, ?)`,
+			transaction.ProductID, transaction.WarehouseID, transaction.ToWarehouseID, transaction.Quantity,
+			transaction.ReferenceNumber, transaction.Notes, transaction.CreatedBy).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(transaction).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
 // Corrected method signature for GetAllTransactions
 func (r *transactionRepository) GetAllTransactions(limit, offset int) ([]models.Transaction, error) {
 	var transactions []models.Transaction
@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"errors"
+	"wms-be/domain/models"
+	"wms-be/infrastructure/database"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TransferOrderRepository interface {
+	Create(order *models.TransferOrder, numbering DocumentNumbering) error
+	GetByID(id uuid.UUID) (*models.TransferOrder, error)
+	GetAll(filters map[string]interface{}, page, limit int, scope models.WarehouseScope) ([]models.TransferOrder, int64, error)
+	Approve(id uuid.UUID, apply func(order *models.TransferOrder, product *models.Product) error) (*models.TransferOrder, error)
+	Dispatch(id uuid.UUID, apply func(order *models.TransferOrder, product *models.Product) error) (*models.TransferOrder, error)
+	MarkInTransit(id uuid.UUID, apply func(order *models.TransferOrder) error) (*models.TransferOrder, error)
+	Receive(id uuid.UUID, receipt *models.TransferOrderReceipt, apply func(order *models.TransferOrder, warehouse *models.Warehouse) error) (*models.TransferOrder, error)
+	Cancel(id uuid.UUID, apply func(order *models.TransferOrder) error) (*models.TransferOrder, error)
+}
+
+type transferOrderRepo struct {
+	db *gorm.DB
+}
+
+func NewTransferOrderRepository() TransferOrderRepository {
+	return &transferOrderRepo{db: database.GetDB()}
+}
+
+func (r *transferOrderRepo) preload(query *gorm.DB) *gorm.DB {
+	return query.Preload("Product").
+		Preload("FromWarehouse").
+		Preload("ToWarehouse").
+		Preload("Receipts", func(db *gorm.DB) *gorm.DB { return db.Order("received_at") })
+}
+
+// Create simpan transfer order, transfer_number dialokasikan per gudang asal
+func (r *transferOrderRepo) Create(order *models.TransferOrder, numbering DocumentNumbering) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		number, err := assignDocumentNumber(tx, numbering, order.FromWarehouseID)
+		if err != nil {
+			return err
+		}
+		order.TransferNumber = number
+		return tx.Omit(clause.Associations).Create(order).Error
+	})
+}
+
+func (r *transferOrderRepo) GetByID(id uuid.UUID) (*models.TransferOrder, error) {
+	var order models.TransferOrder
+	if err := r.preload(r.db).First(&order, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetAll daftar transfer order, scope gudang berlaku untuk gudang asal maupun tujuan
+func (r *transferOrderRepo) GetAll(filters map[string]interface{}, page, limit int, scope models.WarehouseScope) ([]models.TransferOrder, int64, error) {
+	var orders []models.TransferOrder
+	var total int64
+
+	query := r.db.Model(&models.TransferOrder{})
+	if !scope.All {
+		ids := scope.IDStrings()
+		if len(ids) == 0 {
+			query = query.Where("1 = 0")
+		} else {
+			query = query.Where("from_warehouse_id IN ? OR to_warehouse_id IN ?", ids, ids)
+		}
+	}
+
+	if status, ok := filters["status"].(string); ok && status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if warehouseID, ok := filters["warehouse_id"].(string); ok && warehouseID != "" {
+		query = query.Where("(from_warehouse_id = ? OR to_warehouse_id = ?)", warehouseID, warehouseID)
+	}
+	if search, ok := filters["search"].(string); ok && search != "" {
+		query = query.Where("transfer_number ILIKE ? OR reference_number ILIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := r.preload(query).
+		Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&orders).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+// withLockedOrder kunci baris transfer order (FOR UPDATE), jalankan fn lalu simpan
+// perubahan order, semua dalam satu transaksi. Order lengkap dikembalikan setelah commit.
+func (r *transferOrderRepo) withLockedOrder(id uuid.UUID, fn func(tx *gorm.DB, order *models.TransferOrder) error) (*models.TransferOrder, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var order models.TransferOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		if err := fn(tx, &order); err != nil {
+			return err
+		}
+
+		return tx.Omit(clause.Associations, "created_at").Save(&order).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+func lockProduct(tx *gorm.DB, id uuid.UUID) (*models.Product, error) {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// Approve reservasi stok di gudang asal agar tidak terpakai order lain sebelum dispatch
+func (r *transferOrderRepo) Approve(id uuid.UUID, apply func(order *models.TransferOrder, product *models.Product) error) (*models.TransferOrder, error) {
+	return r.withLockedOrder(id, func(tx *gorm.DB, order *models.TransferOrder) error {
+		product, err := lockProduct(tx, order.ProductID)
+		if err != nil {
+			return err
+		}
+		if err := apply(order, product); err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Product{}).Where("id = ?", product.ID).
+			Update("reserved_stock", gorm.Expr("reserved_stock + ?", order.Quantity)).Error
+	})
+}
+
+// Dispatch keluarkan stok dari gudang asal (reservasi dilepas) dan catat ledger transfer.
+// Sejak titik ini stok berstatus in transit.
+func (r *transferOrderRepo) Dispatch(id uuid.UUID, apply func(order *models.TransferOrder, product *models.Product) error) (*models.TransferOrder, error) {
+	return r.withLockedOrder(id, func(tx *gorm.DB, order *models.TransferOrder) error {
+		product, err := lockProduct(tx, order.ProductID)
+		if err != nil {
+			return err
+		}
+		if err := apply(order, product); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Product{}).Where("id = ?", product.ID).Updates(map[string]interface{}{
+			"stock":          gorm.Expr("stock - ?", order.Quantity),
+			"reserved_stock": gorm.Expr("reserved_stock - ?", order.Quantity),
+		}).Error; err != nil {
+			return err
+		}
+
+		toWarehouseID := order.ToWarehouseID
+		return tx.Create(&models.Transaction{
+			Type:            models.Transfer,
+			ProductID:       order.ProductID,
+			Quantity:        order.Quantity,
+			WarehouseID:     order.FromWarehouseID,
+			ToWarehouseID:   &toWarehouseID,
+			ReferenceNumber: order.TransferNumber,
+			Notes:           order.Notes,
+			CreatedBy:       *order.DispatchedBy,
+			TransferOrderID: &order.ID,
+		}).Error
+	})
+}
+
+func (r *transferOrderRepo) MarkInTransit(id uuid.UUID, apply func(order *models.TransferOrder) error) (*models.TransferOrder, error) {
+	return r.withLockedOrder(id, func(tx *gorm.DB, order *models.TransferOrder) error {
+		return apply(order)
+	})
+}
+
+// Receive tambah stok di gudang tujuan sebanyak receipt.Quantity. Product tujuan dicari
+// berdasarkan SKU, jika belum ada dibuat salinan dari product asal (seperti transfer_stock).
+func (r *transferOrderRepo) Receive(id uuid.UUID, receipt *models.TransferOrderReceipt, apply func(order *models.TransferOrder, warehouse *models.Warehouse) error) (*models.TransferOrder, error) {
+	return r.withLockedOrder(id, func(tx *gorm.DB, order *models.TransferOrder) error {
+		var warehouse models.Warehouse
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&warehouse, "id = ?", order.ToWarehouseID).Error; err != nil {
+			return err
+		}
+		if err := apply(order, &warehouse); err != nil {
+			return err
+		}
+
+		receipt.TransferOrderID = order.ID
+		if err := tx.Create(receipt).Error; err != nil {
+			return err
+		}
+		if receipt.Quantity == 0 {
+			return nil
+		}
+
+		destinationID, err := addDestinationStock(tx, order, receipt.Quantity)
+		if err != nil {
+			return err
+		}
+		order.DestinationProductID = &destinationID
+
+		return tx.Create(&models.Transaction{
+			Type:            models.InboundTransaction,
+			ProductID:       destinationID,
+			Quantity:        receipt.Quantity,
+			WarehouseID:     order.ToWarehouseID,
+			ReferenceNumber: order.TransferNumber,
+			Notes:           receipt.Notes,
+			CreatedBy:       receipt.ReceivedBy,
+			TransferOrderID: &order.ID,
+		}).Error
+	})
+}
+
+func addDestinationStock(tx *gorm.DB, order *models.TransferOrder, quantity int) (uuid.UUID, error) {
+	source, err := lockProduct(tx, order.ProductID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var destination models.Product
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("sku = ? AND warehouse_id = ?", source.SKU, order.ToWarehouseID).
+		First(&destination).Error
+	if err == nil {
+		err = tx.Model(&models.Product{}).Where("id = ?", destination.ID).
+			Update("stock", gorm.Expr("stock + ?", quantity)).Error
+		return destination.ID, err
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return uuid.Nil, err
+	}
+
+	var created struct{ ID uuid.UUID }
+	err = tx.Raw(`
+		INSERT INTO products (name, sku, description, price, stock, warehouse_id, category, min_stock, created_at, updated_at, is_active)
+		SELECT name, sku, description, price, ?, ?, category, min_stock, now(), now(), is_active
+		FROM products
+		WHERE id = ?
+		RETURNING id
+	`, quantity, order.ToWarehouseID, source.ID).Scan(&created).Error
+	return created.ID, err
+}
+
+// Cancel batalkan transfer sebelum dispatch, reservasi stok dilepas jika sudah di-approve
+func (r *transferOrderRepo) Cancel(id uuid.UUID, apply func(order *models.TransferOrder) error) (*models.TransferOrder, error) {
+	return r.withLockedOrder(id, func(tx *gorm.DB, order *models.TransferOrder) error {
+		wasApproved := order.Status == models.TransferStatusApproved
+		if err := apply(order); err != nil {
+			return err
+		}
+		if !wasApproved {
+			return nil
+		}
+
+		return tx.Model(&models.Product{}).Where("id = ?", order.ProductID).
+			Update("reserved_stock", gorm.Expr("reserved_stock - ?", order.Quantity)).Error
+	})
+}
@@ -1,17 +1,35 @@
 package repository
 
 import (
+	"time"
 	"wms-be/domain/models"
 	"wms-be/infrastructure/database"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
 	CreateUser(user *models.User) error
+	GetUsers(search, role, warehouseId string, page, limit int) ([]models.User, int, error)
 	GetUserByEmail(email string) (*models.User, error)
 	GetUserByID(id uuid.UUID) (*models.User, error)
 	UpdateUser(user *models.User) error
+	GetAssignedWarehouseIDs(userID uuid.UUID) ([]uuid.UUID, error)
+	SetAssignedWarehouses(userID uuid.UUID, warehouseIDs []uuid.UUID) error
+	RegisterLoginFailure(userID uuid.UUID, maxAttempts int, lockoutDuration time.Duration) (*models.User, error)
+	ResetLoginFailures(userID uuid.UUID) error
+	SetTOTPSecret(userID uuid.UUID, secret string) error
+	EnableTOTP(userID uuid.UUID) error
+	DisableTOTP(userID uuid.UUID) error
+	UseTOTPStep(userID uuid.UUID, step int64) (bool, error)
+}
+
+// kolom counter login dan 2FA diubah oleh method khusus, jangan ikut tertimpa Save
+var managedColumns = []string{
+	"failed_login_attempts", "last_failed_login_at", "locked_until",
+	"totp_secret", "totp_enabled", "totp_last_step",
 }
 
 type userRepository struct{}
@@ -21,7 +39,43 @@ func NewUserRepository() UserRepository {
 }
 
 func (r *userRepository) CreateUser(user *models.User) error {
-	return database.DB.Create(user).Error
+	return database.DB.Omit(clause.Associations).Create(user).Error
+}
+
+func (r *userRepository) GetUsers(search, role, warehouseId string, page, limit int) ([]models.User, int, error) {
+	var users []models.User
+	var total int64
+
+	// service account API key dikelola lewat /api_keys, tidak ikut daftar user
+	query := database.DB.Model(&models.User{}).Where("role <> ?", models.RoleService)
+
+	if search != "" {
+		searchPattern := "%" + search + "%"
+		query = query.Where("name ILIKE ? OR email ILIKE ?", searchPattern, searchPattern)
+	}
+
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+
+	if warehouseId != "" {
+		query = query.Where("warehouse_id = ?", warehouseId)
+	}
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = query.Preload("Warehouse").Preload("AssignedWarehouses").
+		Order("name ASC").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, int(total), nil
 }
 
 func (r *userRepository) GetUserByEmail(email string) (*models.User, error) {
@@ -34,12 +88,98 @@ func (r *userRepository) GetUserByEmail(email string) (*models.User, error) {
 
 func (r *userRepository) GetUserByID(id uuid.UUID) (*models.User, error) {
 	var user models.User
-	if err := database.DB.Preload("Warehouse").Where("id = ?", id).First(&user).Error; err != nil {
+	if err := database.DB.Preload("Warehouse").Preload("AssignedWarehouses").Where("id = ?", id).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+// UpdateUser simpan perubahan user tanpa ikut menyimpan relasi (warehouse, assigned warehouses)
+// dan tanpa menimpa counter login gagal / pengaturan 2FA
 func (r *userRepository) UpdateUser(user *models.User) error {
-	return database.DB.Save(user).Error
+	omit := append([]string{clause.Associations}, managedColumns...)
+	return database.DB.Omit(omit...).Save(user).Error
+}
+
+// RegisterLoginFailure tambah counter gagal secara atomik. Jika mencapai maxAttempts
+// akun dikunci selama lockoutDuration dan counter kembali ke 0.
+func (r *userRepository) RegisterLoginFailure(userID uuid.UUID, maxAttempts int, lockoutDuration time.Duration) (*models.User, error) {
+	var user models.User
+	now := time.Now()
+
+	err := database.DB.Raw(`
+		UPDATE users SET
+			failed_login_attempts = CASE WHEN failed_login_attempts + 1 >= ? THEN 0 ELSE failed_login_attempts + 1 END,
+			locked_until = CASE WHEN failed_login_attempts + 1 >= ? THEN ?::timestamptz ELSE locked_until END,
+			last_failed_login_at = ?
+		WHERE id = ?
+		RETURNING *
+	`, maxAttempts, maxAttempts, now.Add(lockoutDuration), now, userID).Scan(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ResetLoginFailures hapus counter gagal dan lockout (login sukses / unlock oleh admin)
+func (r *userRepository) ResetLoginFailures(userID uuid.UUID) error {
+	return database.DB.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"failed_login_attempts": 0,
+		"last_failed_login_at":  nil,
+		"locked_until":          nil,
+	}).Error
+}
+
+// GetAssignedWarehouseIDs ambil gudang tambahan yang di-assign ke user
+func (r *userRepository) GetAssignedWarehouseIDs(userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := database.DB.Model(&models.UserWarehouse{}).
+		Where("user_id = ?", userID).
+		Pluck("warehouse_id", &ids).Error
+	return ids, err
+}
+
+// SetAssignedWarehouses ganti seluruh gudang tambahan milik user
+func (r *userRepository) SetAssignedWarehouses(userID uuid.UUID, warehouseIDs []uuid.UUID) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserWarehouse{}).Error; err != nil {
+			return err
+		}
+		for _, warehouseID := range warehouseIDs {
+			assignment := models.UserWarehouse{UserID: userID, WarehouseID: warehouseID}
+			if err := tx.Create(&assignment).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SetTOTPSecret simpan secret enrolment baru, 2FA belum aktif sampai EnableTOTP
+func (r *userRepository) SetTOTPSecret(userID uuid.UUID, secret string) error {
+	return database.DB.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_enabled":   false,
+		"totp_last_step": nil,
+	}).Error
+}
+
+func (r *userRepository) EnableTOTP(userID uuid.UUID) error {
+	return database.DB.Model(&models.User{}).Where("id = ?", userID).Update("totp_enabled", true).Error
+}
+
+func (r *userRepository) DisableTOTP(userID uuid.UUID) error {
+	return database.DB.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"totp_secret":    nil,
+		"totp_enabled":   false,
+		"totp_last_step": nil,
+	}).Error
+}
+
+// UseTOTPStep tandai time step sudah dipakai. false berarti kode yang sama (atau lebih lama) sudah pernah dipakai.
+func (r *userRepository) UseTOTPStep(userID uuid.UUID, step int64) (bool, error) {
+	result := database.DB.Model(&models.User{}).
+		Where("id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)", userID, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected > 0, result.Error
 }
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"wms-be/domain/models"
+
+	"gorm.io/gorm"
+)
+
+// applyWarehouseScope batasi query hanya ke gudang dalam scope
+func applyWarehouseScope(query *gorm.DB, column string, scope models.WarehouseScope) *gorm.DB {
+	if scope.All {
+		return query
+	}
+	if len(scope.WarehouseIDs) == 0 {
+		// tidak punya akses gudang sama sekali
+		return query.Where("1 = 0")
+	}
+	return query.Where(column+" IN ?", scope.IDStrings())
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"wms-be/domain/models"
+	"wms-be/domain/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAPIKeyNotFound = fmt.Errorf("%w: api key not found", ErrNotFound)
+	ErrInvalidAPIKey  = errors.New("invalid, revoked or expired api key")
+)
+
+// apiKeyPrefix awalan key agar mudah dikenali (misal oleh secret scanner)
+const apiKeyPrefix = "wms_"
+
+// APIKeyInput data create/update API key dari admin
+type APIKeyInput struct {
+	Name          string
+	Permissions   []models.Permission
+	AllWarehouses bool
+	WarehouseIDs  []uuid.UUID
+	ExpiresAt     *time.Time
+}
+
+type APIKeyService interface {
+	GetAPIKeys(includeRevoked bool, page, limit int) ([]models.APIKey, int, error)
+	GetAPIKeyByID(id uuid.UUID) (*models.APIKey, error)
+	CreateAPIKey(input APIKeyInput, createdBy uuid.UUID) (*models.APIKey, string, error)
+	UpdateAPIKey(id uuid.UUID, input APIKeyInput) (*models.APIKey, error)
+	RevokeAPIKey(id uuid.UUID) error
+	AuthenticateAPIKey(rawKey, ipAddress string) (*models.APIKeyPrincipal, error)
+}
+
+type apiKeyService struct {
+	apiKeyRepo    repository.APIKeyRepository
+	warehouseRepo repository.WarehouseRepository
+}
+
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, warehouseRepo repository.WarehouseRepository) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo:    apiKeyRepo,
+		warehouseRepo: warehouseRepo,
+	}
+}
+
+// validateInput cek nama, permission yang boleh diberikan, gudang dan expiry
+func (s *apiKeyService) validateInput(input *APIKeyInput) error {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidInput)
+	}
+	if len(input.Name) > 100 {
+		return fmt.Errorf("%w: name must be at most 100 characters", ErrInvalidInput)
+	}
+
+	if len(input.Permissions) == 0 {
+		return fmt.Errorf("%w: at least one permission is required", ErrInvalidInput)
+	}
+	for _, p := range input.Permissions {
+		if !models.IsGrantableToAPIKey(p) {
+			return fmt.Errorf("%w: permission %q cannot be granted to an api key", ErrInvalidInput, p)
+		}
+	}
+
+	if input.AllWarehouses {
+		input.WarehouseIDs = nil
+	} else {
+		if len(input.WarehouseIDs) == 0 {
+			return fmt.Errorf("%w: warehouse_ids is required unless all_warehouses is true", ErrInvalidInput)
+		}
+		for _, id := range input.WarehouseIDs {
+			if _, err := s.warehouseRepo.GetWarehouseByID(id); err != nil {
+				return fmt.Errorf("%w: warehouse %s not found", ErrInvalidInput, id)
+			}
+		}
+	}
+
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("%w: expires_at must be in the future", ErrInvalidInput)
+	}
+	return nil
+}
+
+func (s *apiKeyService) GetAPIKeys(includeRevoked bool, page, limit int) ([]models.APIKey, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	return s.apiKeyRepo.GetAll(includeRevoked, page, limit)
+}
+
+func (s *apiKeyService) GetAPIKeyByID(id uuid.UUID) (*models.APIKey, error) {
+	apiKey, err := s.apiKeyRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+// CreateAPIKey buat key beserta service account-nya.
+// Key plaintext hanya dikembalikan sekali, yang disimpan hanya hash-nya.
+func (s *apiKeyService) CreateAPIKey(input APIKeyInput, createdBy uuid.UUID) (*models.APIKey, string, error) {
+	if err := s.validateInput(&input); err != nil {
+		return nil, "", err
+	}
+
+	prefix, err := generateSecureToken(6)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := generateSecureToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	keyPrefix := apiKeyPrefix + prefix
+	rawKey := keyPrefix + "." + secret
+
+	// service account tidak bisa login: password acak yang tidak pernah diketahui siapa pun
+	unusablePassword, err := generateSecureToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	passwordHash, err := hashPassword(unusablePassword)
+	if err != nil {
+		return nil, "", err
+	}
+
+	accountID := uuid.New()
+	serviceAccount := &models.User{
+		ID:           accountID,
+		Name:         truncate("API key: "+input.Name, 100),
+		Email:        "apikey-" + accountID.String() + "@service.local",
+		PasswordHash: passwordHash,
+		Role:         models.RoleService,
+		IsActive:     true,
+	}
+
+	apiKey := &models.APIKey{
+		Name:          input.Name,
+		KeyPrefix:     keyPrefix,
+		AllWarehouses: input.AllWarehouses,
+		ExpiresAt:     input.ExpiresAt,
+		CreatedBy:     &createdBy,
+	}
+	apiKey.SetPermissions(input.Permissions)
+
+	if err := s.apiKeyRepo.Create(apiKey, serviceAccount, rawKey, input.WarehouseIDs); err != nil {
+		return nil, "", err
+	}
+
+	created, err := s.GetAPIKeyByID(apiKey.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	return created, rawKey, nil
+}
+
+func (s *apiKeyService) UpdateAPIKey(id uuid.UUID, input APIKeyInput) (*models.APIKey, error) {
+	apiKey, err := s.GetAPIKeyByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, fmt.Errorf("%w: api key has been revoked", ErrConflict)
+	}
+
+	if err := s.validateInput(&input); err != nil {
+		return nil, err
+	}
+
+	apiKey.Name = input.Name
+	apiKey.AllWarehouses = input.AllWarehouses
+	apiKey.ExpiresAt = input.ExpiresAt
+	apiKey.SetPermissions(input.Permissions)
+
+	if err := s.apiKeyRepo.Update(apiKey, input.WarehouseIDs); err != nil {
+		return nil, err
+	}
+
+	return s.GetAPIKeyByID(id)
+}
+
+func (s *apiKeyService) RevokeAPIKey(id uuid.UUID) error {
+	revoked, err := s.apiKeyRepo.Revoke(id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateAPIKey dipakai AuthMiddleware untuk header X-API-Key
+func (s *apiKeyService) AuthenticateAPIKey(rawKey, ipAddress string) (*models.APIKeyPrincipal, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.apiKeyRepo.FindByKey(rawKey)
+	if err != nil || !apiKey.IsUsable(time.Now()) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// gagal mencatat pemakaian tidak boleh menggagalkan request
+	if err := s.apiKeyRepo.TouchLastUsed(apiKey.ID, ipAddress); err != nil {
+		log.Printf("[auth] failed to update api key last used: key_id=%s err=%v", apiKey.ID, err)
+	}
+
+	// permission yang sudah tidak boleh diberikan (misal aturan berubah) diabaikan
+	permissions := []models.Permission{}
+	for _, p := range apiKey.PermissionList() {
+		if models.IsGrantableToAPIKey(p) {
+			permissions = append(permissions, p)
+		}
+	}
+
+	return &models.APIKeyPrincipal{
+		KeyID:            apiKey.ID,
+		ServiceAccountID: apiKey.ServiceAccountID,
+		Permissions:      permissions,
+		Scope:            apiKey.WarehouseScope(),
+	}, nil
+}
+
+// truncate potong string ke max karakter (bukan byte)
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
@@ -2,30 +2,72 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"os"
 	"strconv"
+	"sync"
 	"time"
+	"wms-be/domain/models"
 	"wms-be/domain/repository"
 	"wms-be/infrastructure/jwt"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrRefreshTokenReused dikembalikan jika refresh token yang sudah dirotasi dipakai lagi.
+// Seluruh family token tersebut langsung dicabut.
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected, please login again")
+
+// ErrSessionNotFound sesi tidak ada, bukan milik user, atau sudah tidak aktif
+var ErrSessionNotFound = fmt.Errorf("%w: session not found", ErrNotFound)
+
 // AuthService interface
 type AuthService interface {
-	Login(email, password string) (string, string, error)
-	RefreshToken(refreshToken string) (string, string, error)
-	Logout(refreshToken string) error
+	Login(email, password string, client models.ClientInfo) (*LoginResult, error)
+	VerifyTwoFactor(challengeToken, code string, client models.ClientInfo) (*LoginResult, error)
+	GetTwoFactorStatus(userID uuid.UUID) (*TwoFactorStatus, error)
+	BeginTwoFactorEnrollment(userID uuid.UUID) (*TwoFactorSetup, error)
+	ConfirmTwoFactorEnrollment(userID uuid.UUID, code string) ([]string, error)
+	BeginChallengeEnrollment(challengeToken string) (*TwoFactorSetup, error)
+	ConfirmChallengeEnrollment(challengeToken, code string, client models.ClientInfo) (*LoginResult, []string, error)
+	DisableTwoFactor(userID uuid.UUID, password, code string) error
+	RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error)
+	RefreshToken(refreshToken string, client models.ClientInfo) (string, string, error)
+	Logout(refreshToken string, accessClaims *jwt.TokenClaims) error
+	ListSessions(userID uuid.UUID) ([]models.Session, error)
+	RevokeSession(userID, sessionID uuid.UUID) error
+	RevokeAllSessions(userID uuid.UUID) error
 }
 
 type authService struct {
 	userRepo         repository.UserRepository
 	refreshTokenRepo repository.RefreshTokenRepository
+	loginAttemptRepo repository.LoginAttemptRepository
+	recoveryCodeRepo repository.RecoveryCodeRepository
+	tokenDenylist    TokenDenylist
+	loginProtection  loginProtection
+	twoFactorPolicy  twoFactorPolicy
 }
 
 // Konstruktor
-func NewAuthService(userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository) AuthService {
-	return &authService{userRepo, refreshTokenRepo}
+func NewAuthService(
+	userRepo repository.UserRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	recoveryCodeRepo repository.RecoveryCodeRepository,
+	tokenDenylist TokenDenylist,
+) AuthService {
+	return &authService{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		tokenDenylist:    tokenDenylist,
+		loginProtection:  newLoginProtection(),
+		twoFactorPolicy:  newTwoFactorPolicy(),
+	}
 }
 
 // getEnvDuration membaca TTL dari .env
@@ -43,78 +85,346 @@ func getEnvDuration(key string, defaultVal int64) time.Duration {
 }
 
 // ===================== LOGIN =====================
-func (s *authService) Login(email, password string) (string, string, error) {
+func (s *authService) Login(email, password string, client models.ClientInfo) (*LoginResult, error) {
+	// blokir IP yang terlalu banyak gagal sebelum menyentuh akun
+	if err := s.checkIPThrottle(client.IPAddress); err != nil {
+		s.recordLoginAttempt(email, nil, client, models.LoginFailureThrottled)
+		return nil, err
+	}
+
 	user, err := s.userRepo.GetUserByEmail(email)
+	// service account (API key) tidak pernah bisa login dengan password
+	if err == nil && user.Role == models.RoleService {
+		err = errors.New("service account")
+	}
 	if err != nil {
-		return "", "", errors.New("invalid credentials: user not found")
+		// email tidak terdaftar mendapat jeda / lockout yang sama dengan akun,
+		// agar respon 429 tidak membocorkan email mana yang terdaftar
+		if err := s.checkUnknownEmailThrottle(email, client); err != nil {
+			return nil, err
+		}
+		// tetap jalankan bcrypt agar waktu respon sama dengan password salah
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		s.recordLoginAttempt(email, nil, client, models.LoginFailureUnknownEmail)
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := s.checkAccountThrottle(user, client); err != nil {
+		return nil, err
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", "", errors.New("invalid credentials: wrong password")
+		if err := s.registerLoginFailure(user, client, models.LoginFailureWrongPassword); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	if !user.IsActive {
+		s.recordLoginAttempt(email, &user.ID, client, models.LoginFailureInactive)
+		return nil, errors.New("account is inactive")
+	}
+
+	// password benar tapi 2FA aktif / wajib: counter gagal belum di-reset sampai langkah kedua selesai
+	if user.TOTPEnabled {
+		return s.issueChallenge(user, jwt.TokenTypeTwoFactor)
+	}
+	if s.twoFactorRequired(user) {
+		return s.issueChallenge(user, jwt.TokenTypeTwoFactorEnroll)
 	}
 
+	return s.completeLogin(user, client)
+}
+
+// completeLogin reset counter gagal, catat login sukses lalu terbitkan token
+func (s *authService) completeLogin(user *models.User, client models.ClientInfo) (*LoginResult, error) {
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		if err := s.userRepo.ResetLoginFailures(user.ID); err != nil {
+			return nil, err
+		}
+	}
+	s.recordLoginAttempt(user.Email, &user.ID, client, "")
+
+	accessToken, refreshToken, err := s.issueTokens(user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{User: user, AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// registerLoginFailure catat kegagalan kredensial dan tambah counter (bisa mengunci akun)
+func (s *authService) registerLoginFailure(user *models.User, client models.ClientInfo, reason string) error {
+	s.recordLoginAttempt(user.Email, &user.ID, client, reason)
+
+	updated, err := s.userRepo.RegisterLoginFailure(user.ID, s.loginProtection.maxFailedAttempts, s.loginProtection.lockoutDuration)
+	if err != nil {
+		return err
+	}
+	if updated.LockedUntil != nil && updated.LockedUntil.After(time.Now()) {
+		log.Printf("[auth] account locked after repeated failed logins: user_id=%s ip=%s until=%s",
+			user.ID, client.IPAddress, updated.LockedUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// issueTokens terbitkan access token + refresh token baru untuk sesi baru
+func (s *authService) issueTokens(user *models.User, client models.ClientInfo) (string, string, error) {
 	accessTTL := getEnvDuration("JWT_ACCESS_EXPIRE", 3600)
 	refreshTTL := getEnvDuration("JWT_REFRESH_EXPIRE", 604800)
 
-	accessToken, err := jwt.GenerateToken(user.ID.String(), time.Now().Add(accessTTL))
+	// setiap login memulai family refresh token baru, access token membawa family sebagai sid
+	familyID := uuid.New()
+	accessToken, err := jwt.GenerateAccessToken(user.ID.String(), user.Role, user.WarehouseID.String(), familyID.String(), time.Now().Add(accessTTL))
 	if err != nil {
 		return "", "", err
 	}
 
-	refreshToken, err := jwt.GenerateToken(user.ID.String(), time.Now().Add(refreshTTL))
+	refreshToken, err := jwt.GenerateRefreshToken(user.ID.String(), time.Now().Add(refreshTTL))
 	if err != nil {
 		return "", "", err
 	}
 
-	// simpan refresh token di DB (pakai method Store(userID, token, expiresAt))
-	if err := s.refreshTokenRepo.Store(user.ID, refreshToken, time.Now().Add(refreshTTL)); err != nil {
+	if err := s.refreshTokenRepo.Store(user.ID, familyID, refreshToken, time.Now().Add(refreshTTL), client); err != nil {
 		return "", "", err
 	}
 
 	return accessToken, refreshToken, nil
 }
 
+// checkIPThrottle tolak IP yang melewati batas gagal dalam window.
+// Tanpa jeda progresif per IP karena banyak staff gudang login dari IP (NAT) yang sama.
+func (s *authService) checkIPThrottle(ipAddress string) error {
+	if ipAddress == "" {
+		return nil
+	}
+
+	p := s.loginProtection
+	stats, err := s.loginAttemptRepo.GetIPFailureStats(ipAddress, time.Now().Add(-p.ipWindow))
+	if err != nil {
+		return err
+	}
+	if stats.FirstFailedAt == nil {
+		return nil
+	}
+
+	if stats.Count < p.ipMaxFailedAttempts {
+		return nil
+	}
+	if throttled := waitUntil(stats.FirstFailedAt.Add(p.ipWindow)); throttled != nil {
+		return throttled
+	}
+	return nil
+}
+
+// checkAccountThrottle tolak (dan catat) akun yang sedang terkunci atau belum lewat jeda progresif
+func (s *authService) checkAccountThrottle(user *models.User, client models.ClientInfo) error {
+	if user.LockedUntil != nil {
+		if throttled := waitUntil(*user.LockedUntil); throttled != nil {
+			s.recordLoginAttempt(user.Email, &user.ID, client, models.LoginFailureLocked)
+			return throttled
+		}
+	}
+
+	if user.FailedLoginAttempts > 0 && user.LastFailedLoginAt != nil {
+		delay := s.loginProtection.delayAfter(user.FailedLoginAttempts)
+		if throttled := waitUntil(user.LastFailedLoginAt.Add(delay)); throttled != nil {
+			s.recordLoginAttempt(user.Email, &user.ID, client, models.LoginFailureThrottled)
+			return throttled
+		}
+	}
+	return nil
+}
+
+// checkUnknownEmailThrottle tiru checkAccountThrottle untuk email yang tidak terdaftar memakai
+// login gagal ke email tersebut di login_attempts: jeda progresif, lalu terkunci selama
+// lockoutDuration setelah maxFailedAttempts kali gagal
+func (s *authService) checkUnknownEmailThrottle(email string, client models.ClientInfo) error {
+	p := s.loginProtection
+	stats, err := s.loginAttemptRepo.GetUnknownEmailFailureStats(email, time.Now().Add(-p.lockoutDuration))
+	if err != nil {
+		return err
+	}
+	if stats.Count == 0 || stats.LastFailedAt == nil {
+		return nil
+	}
+
+	if stats.Count >= p.maxFailedAttempts {
+		if throttled := waitUntil(stats.LastFailedAt.Add(p.lockoutDuration)); throttled != nil {
+			s.recordLoginAttempt(email, nil, client, models.LoginFailureLocked)
+			return throttled
+		}
+		return nil
+	}
+
+	if throttled := waitUntil(stats.LastFailedAt.Add(p.delayAfter(stats.Count))); throttled != nil {
+		s.recordLoginAttempt(email, nil, client, models.LoginFailureThrottled)
+		return throttled
+	}
+	return nil
+}
+
+// recordLoginAttempt simpan audit login, reason kosong berarti sukses.
+// Gagal mencatat tidak boleh menggagalkan login.
+func (s *authService) recordLoginAttempt(email string, userID *uuid.UUID, client models.ClientInfo, reason string) {
+	attempt := &models.LoginAttempt{
+		Email:         email,
+		UserID:        userID,
+		IPAddress:     client.IPAddress,
+		UserAgent:     client.UserAgent,
+		Success:       reason == "",
+		FailureReason: reason,
+	}
+	if err := s.loginAttemptRepo.Record(attempt); err != nil {
+		log.Printf("[auth] failed to record login attempt: %v", err)
+	}
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// dummyPasswordHash hash bcrypt acak untuk menyamakan waktu respon email yang tidak terdaftar
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte(uuid.New().String()), bcrypt.DefaultCost)
+	})
+	return dummyHash
+}
+
 // ===================== REFRESH TOKEN =====================
-func (s *authService) RefreshToken(refreshToken string) (string, string, error) {
-	// validasi JWT
-	_, err := jwt.ValidateToken(refreshToken)
+func (s *authService) RefreshToken(refreshToken string, client models.ClientInfo) (string, string, error) {
+	// validasi JWT (harus bertipe refresh, iss/aud sesuai)
+	claims, err := jwt.ValidateRefreshToken(refreshToken)
 	if err != nil {
 		return "", "", errors.New("invalid or expired refresh token")
 	}
 
-	// cek di DB
-	rt, err := s.refreshTokenRepo.FindByToken(refreshToken)
+	// cek di DB (termasuk token yang sudah revoked untuk deteksi reuse)
+	rt, err := s.refreshTokenRepo.FindByTokenIncludingRevoked(refreshToken)
 	if err != nil {
 		return "", "", errors.New("refresh token not found or revoked/expired")
 	}
 
+	if rt.UserID.String() != claims.UserID {
+		return "", "", errors.New("invalid or expired refresh token")
+	}
+
+	if rt.RevokedAt != nil {
+		return "", "", s.rejectRevokedRefreshToken(rt)
+	}
+	if !rt.ExpiresAt.After(time.Now()) {
+		return "", "", errors.New("refresh token not found or revoked/expired")
+	}
+
+	// ambil role terbaru dari DB, bukan dari token lama
+	user, err := s.userRepo.GetUserByID(rt.UserID)
+	if err != nil {
+		return "", "", errors.New("user not found")
+	}
+	if !user.IsActive {
+		return "", "", errors.New("account is inactive")
+	}
+
 	accessTTL := getEnvDuration("JWT_ACCESS_EXPIRE", 3600)
 	refreshTTL := getEnvDuration("JWT_REFRESH_EXPIRE", 604800)
 
-	accessToken, err := jwt.GenerateToken(rt.UserID.String(), time.Now().Add(accessTTL))
+	accessToken, err := jwt.GenerateAccessToken(user.ID.String(), user.Role, user.WarehouseID.String(), rt.FamilyID.String(), time.Now().Add(accessTTL))
 	if err != nil {
 		return "", "", err
 	}
 
-	newRefreshToken, err := jwt.GenerateToken(rt.UserID.String(), time.Now().Add(refreshTTL))
+	newRefreshToken, err := jwt.GenerateRefreshToken(user.ID.String(), time.Now().Add(refreshTTL))
 	if err != nil {
 		return "", "", err
 	}
 
-	// revoke token lama
-	if err := s.refreshTokenRepo.Revoke(refreshToken); err != nil {
+	// revoke token lama, jika ternyata sudah direvoke request lain berarti token dipakai dua kali
+	revoked, err := s.refreshTokenRepo.RevokeIfActive(refreshToken)
+	if err != nil {
 		return "", "", err
 	}
+	if !revoked {
+		current, err := s.refreshTokenRepo.FindByTokenIncludingRevoked(refreshToken)
+		if err != nil {
+			return "", "", err
+		}
+		return "", "", s.rejectRevokedRefreshToken(current)
+	}
 
-	// simpan token baru
-	if err := s.refreshTokenRepo.Store(rt.UserID, newRefreshToken, time.Now().Add(refreshTTL)); err != nil {
+	// simpan token baru dalam family yang sama
+	if err := s.refreshTokenRepo.Store(rt.UserID, rt.FamilyID, newRefreshToken, time.Now().Add(refreshTTL), client); err != nil {
 		return "", "", err
 	}
 
 	return accessToken, newRefreshToken, nil
 }
 
+// rejectRevokedRefreshToken tolak token yang sudah direvoke. Hanya token hasil rotasi yang
+// dianggap reuse; token yang dicabut lewat logout / sign out / perubahan akun cukup invalid.
+func (s *authService) rejectRevokedRefreshToken(rt *models.RefreshToken) error {
+	if rt.RevokedReason == models.RefreshTokenRevokedRotated {
+		return s.handleRefreshTokenReuse(rt.UserID, rt.FamilyID)
+	}
+	return errors.New("refresh token not found or revoked/expired")
+}
+
+// handleRefreshTokenReuse cabut seluruh family beserta access token-nya ketika token lama dipakai ulang
+func (s *authService) handleRefreshTokenReuse(userID, familyID uuid.UUID) error {
+	log.Printf("[auth] refresh token reuse detected: user_id=%s family_id=%s, revoking family", userID, familyID)
+
+	if err := s.refreshTokenRepo.RevokeFamily(familyID); err != nil {
+		return err
+	}
+	if err := s.revokeSessionAccessTokens(userID, familyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
 // ===================== LOGOUT =====================
-func (s *authService) Logout(refreshToken string) error {
-	return s.refreshTokenRepo.Revoke(refreshToken)
+// Logout cabut refresh token dan access token yang sedang dipakai.
+// Refresh token yang bukan milik user access token tidak ikut dicabut.
+func (s *authService) Logout(refreshToken string, accessClaims *jwt.TokenClaims) error {
+	userID, err := uuid.Parse(accessClaims.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.refreshTokenRepo.Revoke(userID, refreshToken); err != nil {
+		return err
+	}
+	return s.tokenDenylist.RevokeToken(accessClaims)
+}
+
+// ===================== SESSIONS =====================
+func (s *authService) ListSessions(userID uuid.UUID) ([]models.Session, error) {
+	return s.refreshTokenRepo.ListActiveSessions(userID)
+}
+
+// RevokeSession sign out satu sesi: seluruh family refresh token-nya dan access token
+// yang diterbitkan di sesi tersebut
+func (s *authService) RevokeSession(userID, sessionID uuid.UUID) error {
+	revoked, err := s.refreshTokenRepo.RevokeFamilyForUser(userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+	return s.revokeSessionAccessTokens(userID, sessionID)
+}
+
+// revokeSessionAccessTokens denylist sid sesi sampai access token terakhirnya pasti expired
+func (s *authService) revokeSessionAccessTokens(userID, sessionID uuid.UUID) error {
+	accessTTL := getEnvDuration("JWT_ACCESS_EXPIRE", 3600)
+	return s.tokenDenylist.RevokeSession(userID, sessionID, time.Now().Add(accessTTL))
+}
+
+// RevokeAllSessions sign out dari semua perangkat, termasuk access token yang masih berlaku
+func (s *authService) RevokeAllSessions(userID uuid.UUID) error {
+	if err := s.refreshTokenRepo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	return s.tokenDenylist.RevokeAllForUser(userID)
 }
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"wms-be/domain/models"
+	"wms-be/domain/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCustomerNotFound        = fmt.Errorf("%w: customer not found", ErrNotFound)
+	ErrCustomerAddressNotFound = fmt.Errorf("%w: customer address not found", ErrNotFound)
+	ErrCustomerCodeExists      = fmt.Errorf("%w: customer code already exists", ErrConflict)
+	ErrCustomerHasOrders       = fmt.Errorf("%w: customer has orders, deactivate it instead", ErrConflict)
+	ErrCreditLimitExceeded     = fmt.Errorf("%w: customer credit limit exceeded", ErrConflict)
+)
+
+// CustomerOutstanding tagihan customer yang belum dibayar dibanding credit limit
+type CustomerOutstanding struct {
+	CustomerID        uuid.UUID `json:"customer_id"`
+	OutstandingAmount float64   `json:"outstanding_amount"`
+	OutstandingOrders int64     `json:"outstanding_orders"`
+	CreditLimit       float64   `json:"credit_limit"`
+	AvailableCredit   *float64  `json:"available_credit"` // null jika tanpa credit limit
+}
+
+type CustomerService interface {
+	CreateCustomer(customer *models.Customer) (*models.Customer, error)
+	GetCustomers(page, limit int, filters map[string]interface{}) ([]models.Customer, int64, error)
+	GetCustomerByID(id uuid.UUID) (*models.Customer, error)
+	UpdateCustomer(customer *models.Customer) (*models.Customer, error)
+	DeleteCustomer(id uuid.UUID) error
+	AddAddress(address *models.CustomerAddress) (*models.Customer, error)
+	UpdateAddress(address *models.CustomerAddress) (*models.Customer, error)
+	DeleteAddress(customerID, addressID uuid.UUID) (*models.Customer, error)
+	GetCustomerOrders(id uuid.UUID, page, limit int, scope models.WarehouseScope) ([]models.Order, int64, error)
+	GetOutstanding(id uuid.UUID) (*CustomerOutstanding, error)
+}
+
+type customerService struct {
+	customerRepo repository.CustomerRepository
+	orderRepo    repository.OrderRepository
+}
+
+func NewCustomerService(customerRepo repository.CustomerRepository, orderRepo repository.OrderRepository) CustomerService {
+	return &customerService{
+		customerRepo: customerRepo,
+		orderRepo:    orderRepo,
+	}
+}
+
+func (s *customerService) CreateCustomer(customer *models.Customer) (*models.Customer, error) {
+	if err := validateCustomer(customer); err != nil {
+		return nil, err
+	}
+	defaults := map[string]bool{}
+	for i := range customer.Addresses {
+		address := &customer.Addresses[i]
+		if err := validateCustomerAddress(address); err != nil {
+			return nil, err
+		}
+		if address.IsDefault {
+			if defaults[address.AddressType] {
+				return nil, fmt.Errorf("%w: only one default %s address is allowed", ErrInvalidInput, address.AddressType)
+			}
+			defaults[address.AddressType] = true
+		}
+	}
+
+	if err := s.customerRepo.CreateCustomer(customer); err != nil {
+		return nil, mapCustomerError(err)
+	}
+	return s.GetCustomerByID(customer.ID)
+}
+
+func (s *customerService) GetCustomers(page, limit int, filters map[string]interface{}) ([]models.Customer, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	return s.customerRepo.GetCustomers(filters, page, limit)
+}
+
+func (s *customerService) GetCustomerByID(id uuid.UUID) (*models.Customer, error) {
+	customer, err := s.customerRepo.GetCustomerByID(id)
+	if err != nil {
+		return nil, mapCustomerError(err)
+	}
+	return customer, nil
+}
+
+func (s *customerService) UpdateCustomer(customer *models.Customer) (*models.Customer, error) {
+	if err := validateCustomer(customer); err != nil {
+		return nil, err
+	}
+	if err := s.customerRepo.UpdateCustomer(customer); err != nil {
+		return nil, mapCustomerError(err)
+	}
+	return s.GetCustomerByID(customer.ID)
+}
+
+func (s *customerService) DeleteCustomer(id uuid.UUID) error {
+	return mapCustomerError(s.customerRepo.DeleteCustomer(id))
+}
+
+func (s *customerService) AddAddress(address *models.CustomerAddress) (*models.Customer, error) {
+	if err := validateCustomerAddress(address); err != nil {
+		return nil, err
+	}
+	if err := s.customerRepo.CreateAddress(address); err != nil {
+		return nil, mapCustomerError(err)
+	}
+	return s.GetCustomerByID(address.CustomerID)
+}
+
+func (s *customerService) UpdateAddress(address *models.CustomerAddress) (*models.Customer, error) {
+	if err := validateCustomerAddress(address); err != nil {
+		return nil, err
+	}
+	if err := s.customerRepo.UpdateAddress(address); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCustomerAddressNotFound
+		}
+		return nil, mapCustomerError(err)
+	}
+	return s.GetCustomerByID(address.CustomerID)
+}
+
+func (s *customerService) DeleteAddress(customerID, addressID uuid.UUID) (*models.Customer, error) {
+	if err := s.customerRepo.DeleteAddress(customerID, addressID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCustomerAddressNotFound
+		}
+		return nil, err
+	}
+	return s.GetCustomerByID(customerID)
+}
+
+// GetCustomerOrders riwayat order customer (terbatas gudang yang boleh diakses user)
+func (s *customerService) GetCustomerOrders(id uuid.UUID, page, limit int, scope models.WarehouseScope) ([]models.Order, int64, error) {
+	if _, err := s.GetCustomerByID(id); err != nil {
+		return nil, 0, err
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	return s.orderRepo.GetOrders(page, limit, map[string]interface{}{"customer_id": id.String()}, scope)
+}
+
+func (s *customerService) GetOutstanding(id uuid.UUID) (*CustomerOutstanding, error) {
+	customer, err := s.GetCustomerByID(id)
+	if err != nil {
+		return nil, err
+	}
+	outstanding, err := s.customerRepo.GetOutstanding(id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CustomerOutstanding{
+		CustomerID:        customer.ID,
+		OutstandingAmount: outstanding.Amount,
+		OutstandingOrders: outstanding.Orders,
+		CreditLimit:       customer.CreditLimit,
+	}
+	if customer.CreditLimit > 0 {
+		available := customer.CreditLimit - outstanding.Amount
+		result.AvailableCredit = &available
+	}
+	return result, nil
+}
+
+func validateCustomer(customer *models.Customer) error {
+	customer.Code = strings.TrimSpace(customer.Code)
+	customer.Name = strings.TrimSpace(customer.Name)
+	if customer.Code == "" || customer.Name == "" {
+		return fmt.Errorf("%w: code and name are required", ErrInvalidInput)
+	}
+	if customer.CreditLimit < 0 {
+		return fmt.Errorf("%w: credit_limit must not be negative", ErrInvalidInput)
+	}
+	return nil
+}
+
+func validateCustomerAddress(address *models.CustomerAddress) error {
+	if address.AddressType != models.AddressShipping && address.AddressType != models.AddressBilling {
+		return fmt.Errorf("%w: address_type must be %s or %s", ErrInvalidInput, models.AddressShipping, models.AddressBilling)
+	}
+	address.Address = strings.TrimSpace(address.Address)
+	if address.Address == "" {
+		return fmt.Errorf("%w: address is required", ErrInvalidInput)
+	}
+	return nil
+}
+
+func mapCustomerError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrCustomerNotFound
+	case sqlState(err) == sqlStateUniqueViolation:
+		return ErrCustomerCodeExists
+	case sqlState(err) == sqlStateForeignKeyViolation:
+		return ErrCustomerHasOrders
+	}
+	return err
+}
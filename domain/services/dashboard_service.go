@@ -6,6 +6,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// dashboardID id tetap baris view dashboard_summary (dashboard_id di view turunan)
+const dashboardID = "ea39a1af-38e5-4874-a2b4-c85deab2179e"
+
 type DashboardService struct {
 	db *gorm.DB
 }
@@ -14,18 +17,28 @@ func NewDashboardService(db *gorm.DB) *DashboardService {
 	return &DashboardService{db: db}
 }
 
-func (d *DashboardService) GetDashboardSummary() (models.DashboardSummary, error) {
+// GetDashboardSummary ringkasan dashboard. Admin membaca view global, staff hanya melihat
+// angka, transaksi terakhir dan low stock dari gudang dalam scope-nya.
+func (d *DashboardService) GetDashboardSummary(scope models.WarehouseScope) (models.DashboardSummary, error) {
 	var summary models.DashboardSummary
 
-	// Ambil 1 baris dari view dashboard_summary
-	if err := d.db.Table("public.dashboard_summary").Take(&summary).Error; err != nil {
+	if scope.All {
+		// Ambil 1 baris dari view dashboard_summary
+		if err := d.db.Table("public.dashboard_summary").Take(&summary).Error; err != nil {
+			return summary, err
+		}
+	} else if err := d.countScopedSummary(&summary, scope); err != nil {
 		return summary, err
 	}
 
-	// Ambil 10 data terakhir dari transaction_histories
+	// Ambil 10 data terakhir dari transaction_histories (asal maupun tujuan transfer dalam scope)
 	var histories []models.TransactionHistory
-	if err := d.db.Table("public.transaction_histories").
-		Where("dashboard_id = ?", summary.ID).
+	historyQuery := d.db.Table("public.transaction_histories").Where("dashboard_id = ?", summary.ID)
+	if !scope.All {
+		ids := scope.IDStrings()
+		historyQuery = historyQuery.Where("warehouse_id IN ? OR to_warehouse_id IN ?", ids, ids)
+	}
+	if err := historyQuery.
 		Order("created_at DESC").
 		Limit(10).
 		Find(&histories).Error; err != nil {
@@ -34,7 +47,7 @@ func (d *DashboardService) GetDashboardSummary() (models.DashboardSummary, error
 
 	// Ambil 10 data low stock dari view low_stock_products
 	var lowStocks []models.LowStockProduct
-	if err := d.db.Table("public.low_stock_products").
+	if err := scopeDashboardQuery(d.db.Table("public.low_stock_products"), "warehouse_id", scope).
 		Where("dashboard_id = ?", summary.ID).
 		Order("available_stock ASC").
 		Limit(10).
@@ -48,3 +61,40 @@ func (d *DashboardService) GetDashboardSummary() (models.DashboardSummary, error
 
 	return summary, nil
 }
+
+// countScopedSummary hitung angka dashboard_summary hanya untuk gudang dalam scope
+func (d *DashboardService) countScopedSummary(summary *models.DashboardSummary, scope models.WarehouseScope) error {
+	summary.ID = dashboardID
+	ids := scope.IDStrings()
+
+	counts := []struct {
+		target *int
+		query  *gorm.DB
+	}{
+		{&summary.TotalProducts, scopeDashboardQuery(d.db.Table("public.products"), "warehouse_id", scope)},
+		{&summary.TotalWarehouses, scopeDashboardQuery(d.db.Table("public.warehouses"), "id", scope)},
+		{&summary.TotalOrders, scopeDashboardQuery(d.db.Table("public.orders"), "warehouse_id", scope)},
+		{&summary.TotalTransactions, d.db.Table("public.transactions").Where("warehouse_id IN ? OR to_warehouse_id IN ?", ids, ids)},
+		{&summary.ActiveWarehouses, scopeDashboardQuery(d.db.Table("public.warehouses"), "id", scope).Where("is_active = ?", true)},
+		{&summary.PendingOrders, scopeDashboardQuery(d.db.Table("public.orders"), "warehouse_id", scope).Where("status = ?", models.OrderStatusPendingPayment)},
+	}
+	for _, c := range counts {
+		var count int64
+		if err := c.query.Count(&count).Error; err != nil {
+			return err
+		}
+		*c.target = int(count)
+	}
+	return nil
+}
+
+// scopeDashboardQuery batasi query ke gudang dalam scope, scope kosong = tidak ada data
+func scopeDashboardQuery(query *gorm.DB, column string, scope models.WarehouseScope) *gorm.DB {
+	if scope.All {
+		return query
+	}
+	if len(scope.WarehouseIDs) == 0 {
+		return query.Where("1 = 0")
+	}
+	return query.Where(column+" IN ?", scope.IDStrings())
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"log"
+	"os"
+	"time"
+	"wms-be/domain/repository"
+	"wms-be/infrastructure/docnumber"
+)
+
+// Scheme default nomor dokumen, bisa diganti lewat env DOC_NUMBER_*_FORMAT
+const (
+	defaultOrderNumberFormat         = "SO-{WAREHOUSE_CODE}-{YYYYMM}-{SEQ:5}"
+	defaultInboundNumberFormat       = "GR-{WAREHOUSE_CODE}-{YYYYMM}-{SEQ:5}"
+	defaultOutboundNumberFormat      = "DO-{WAREHOUSE_CODE}-{YYYYMM}-{SEQ:5}"
+	defaultTransferNumberFormat      = "TRF-{WAREHOUSE_CODE}-{YYYYMM}-{SEQ:5}"
+	defaultPurchaseOrderNumberFormat = "PO-{WAREHOUSE_CODE}-{YYYYMM}-{SEQ:5}"
+)
+
+// documentNumberLocation zona waktu token tanggal, DOC_NUMBER_TIMEZONE (default Asia/Jakarta)
+func documentNumberLocation() *time.Location {
+	name := os.Getenv("DOC_NUMBER_TIMEZONE")
+	if name == "" {
+		name = "Asia/Jakarta"
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("[docnumber] unknown timezone %q, using UTC+7: %v", name, err)
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return loc
+}
+
+// documentNumbering scheme dari env, kembali ke default jika kosong / tidak valid
+func documentNumbering(docType, envKey, defaultFormat string) repository.DocumentNumbering {
+	scheme := docnumber.MustParse(defaultFormat)
+	if format := os.Getenv(envKey); format != "" {
+		parsed, err := docnumber.Parse(format)
+		if err != nil {
+			log.Printf("[docnumber] invalid %s %q, using default %q: %v", envKey, format, defaultFormat, err)
+		} else {
+			scheme = parsed
+		}
+	}
+
+	return repository.DocumentNumbering{
+		Type:     docType,
+		Scheme:   scheme,
+		Location: documentNumberLocation(),
+	}
+}
@@ -0,0 +1,25 @@
+package services
+
+import "errors"
+
+// Error umum yang dipetakan handler ke HTTP status code
+var (
+	ErrInvalidInput = errors.New("invalid input")
+	ErrNotFound     = errors.New("resource not found")
+	ErrConflict     = errors.New("resource conflict")
+)
+
+// SQLSTATE Postgres yang dipetakan ke error service
+const (
+	sqlStateUniqueViolation     = "23505"
+	sqlStateForeignKeyViolation = "23503"
+)
+
+// sqlState ambil SQLSTATE dari error Postgres (driver pgx), kosong jika bukan error Postgres
+func sqlState(err error) string {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		return pgErr.SQLState()
+	}
+	return ""
+}
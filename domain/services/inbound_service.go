@@ -1,26 +1,46 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
 	"wms-be/domain/models"
 	"wms-be/domain/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+var ErrInboundReceiptNotFound = fmt.Errorf("%w: inbound receipt not found", ErrNotFound)
+
 type IInboundService interface {
-	GetInbounds(search, warehouseId string, page, limit int) ([]models.Inbound, int, error)
+	GetInbounds(search, warehouseId, supplierId string, page, limit int, scope models.WarehouseScope) ([]models.Inbound, int, error)
 	GetAllInbounds() ([]models.Inbound, error)
-	CreateInbound(inbound models.Inbound) (models.Inbound, error)
+	CreateInbound(inbound models.Inbound, scope models.WarehouseScope) (models.Inbound, []repository.BackorderAllocation, error)
+	GetReceipts(page, limit int, filters map[string]interface{}, scope models.WarehouseScope) ([]models.InboundReceipt, int64, error)
+	GetReceiptByID(id uuid.UUID, scope models.WarehouseScope) (*models.InboundReceipt, error)
+	CreateReceipt(receipt models.InboundReceipt, scope models.WarehouseScope) (*models.InboundReceipt, []repository.BackorderAllocation, error)
 }
 
 type InboundService struct {
-	inboundRepo repository.InboundRepository
+	inboundRepo       repository.InboundRepository
+	supplierRepo      repository.SupplierRepository
+	purchaseOrderRepo repository.PurchaseOrderRepository
+	numbering         repository.DocumentNumbering
 }
 
 // Constructor
-func NewInboundService(inboundRepo repository.InboundRepository) *InboundService {
-	return &InboundService{inboundRepo: inboundRepo}
+func NewInboundService(inboundRepo repository.InboundRepository, supplierRepo repository.SupplierRepository, purchaseOrderRepo repository.PurchaseOrderRepository) *InboundService {
+	return &InboundService{
+		inboundRepo:       inboundRepo,
+		supplierRepo:      supplierRepo,
+		purchaseOrderRepo: purchaseOrderRepo,
+		numbering:         documentNumbering(repository.DocumentInbound, "DOC_NUMBER_INBOUND_FORMAT", defaultInboundNumberFormat),
+	}
 }
 
-func (s *InboundService) GetInbounds(search, warehouseId string, page, limit int) ([]models.Inbound, int, error) {
+func (s *InboundService) GetInbounds(search, warehouseId, supplierId string, page, limit int, scope models.WarehouseScope) ([]models.Inbound, int, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -28,7 +48,7 @@ func (s *InboundService) GetInbounds(search, warehouseId string, page, limit int
 		limit = 10
 	}
 
-	inbounds, total, err := s.inboundRepo.GetInbounds(search, warehouseId, page, limit)
+	inbounds, total, err := s.inboundRepo.GetInbounds(search, warehouseId, supplierId, page, limit, scope)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -36,17 +56,266 @@ func (s *InboundService) GetInbounds(search, warehouseId string, page, limit int
 }
 
 func (s *InboundService) GetAllInbounds() ([]models.Inbound, error) {
-	inbounds, _, err := s.inboundRepo.GetInbounds("", "", 0, 0)
+	inbounds, _, err := s.inboundRepo.GetInbounds("", "", "", 0, 0, models.AllWarehouses())
 	if err != nil {
 		return nil, err
 	}
 	return inbounds, nil
 }
 
-func (s *InboundService) CreateInbound(inbound models.Inbound) (models.Inbound, error) {
-	createdInbound, err := s.inboundRepo.CreateInbound(inbound)
+// CreateInbound simpan inbound; stok yang masuk langsung dialokasikan ke order backorder
+// (order terlama dulu) dan hasilnya dikembalikan. Inbound dengan purchase_order_line_id
+// dicatat sebagai penerimaan line PO tersebut.
+func (s *InboundService) CreateInbound(inbound models.Inbound, scope models.WarehouseScope) (models.Inbound, []repository.BackorderAllocation, error) {
+	var receive repository.PurchaseOrderReceiver
+	if inbound.PurchaseOrderLineID != nil {
+		if err := s.resolvePurchaseOrderLine(&inbound); err != nil {
+			return models.Inbound{}, nil, err
+		}
+		lineID, productID, quantity, userID := *inbound.PurchaseOrderLineID, inbound.ProductID, inbound.Quantity, inbound.CreatedBy
+		receive = func(order *models.PurchaseOrder) (*models.PurchaseOrderLine, error) {
+			return applyPurchaseOrderReceipt(order, lineID, productID, quantity, userID)
+		}
+	}
+
+	if err := checkWarehouseAccess(scope, inbound.WarehouseID); err != nil {
+		return models.Inbound{}, nil, err
+	}
+	if err := s.resolveInboundSupplier(&inbound); err != nil {
+		return models.Inbound{}, nil, err
+	}
+
+	createdInbound, allocations, err := s.inboundRepo.CreateInbound(inbound, s.numbering, func(product *models.Product) error {
+		if product.WarehouseID != inbound.WarehouseID {
+			return fmt.Errorf("%w: product does not belong to the selected warehouse", ErrInvalidInput)
+		}
+		return nil
+	}, receive)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Inbound{}, nil, fmt.Errorf("%w: product not found", ErrNotFound)
+		}
+		return models.Inbound{}, nil, err
+	}
+	for _, a := range allocations {
+		log.Printf("[inbound] %s allocated %d backordered unit(s) to order %s", createdInbound.ReceiptNumber, a.Quantity, a.OrderNumber)
+	}
+	return createdInbound, allocations, nil
+}
+
+func (s *InboundService) GetReceipts(page, limit int, filters map[string]interface{}, scope models.WarehouseScope) ([]models.InboundReceipt, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	return s.inboundRepo.GetReceipts(filters, page, limit, scope)
+}
+
+func (s *InboundService) GetReceiptByID(id uuid.UUID, scope models.WarehouseScope) (*models.InboundReceipt, error) {
+	receipt, err := s.inboundRepo.GetReceiptByID(id)
 	if err != nil {
-		return models.Inbound{}, err
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInboundReceiptNotFound
+		}
+		return nil, err
+	}
+	if err := checkWarehouseAccess(scope, receipt.WarehouseID); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// CreateReceipt simpan penerimaan satu pengiriman supplier (banyak product) secara atomik.
+// Gudang, supplier, nomor referensi dan tanggal terima header berlaku untuk semua line;
+// line dengan purchase_order_line_id diterima ke PO seperti CreateInbound.
+func (s *InboundService) CreateReceipt(receipt models.InboundReceipt, scope models.WarehouseScope) (*models.InboundReceipt, []repository.BackorderAllocation, error) {
+	if len(receipt.Lines) == 0 {
+		return nil, nil, fmt.Errorf("%w: receipt must have at least one line", ErrInvalidInput)
+	}
+	if err := checkWarehouseAccess(scope, receipt.WarehouseID); err != nil {
+		return nil, nil, err
+	}
+	supplier, err := s.getInboundSupplier(receipt.SupplierID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	receipt.SupplierName = supplier.Name
+	receipt.SupplierContact = strings.TrimSpace(receipt.SupplierContact)
+	if receipt.SupplierContact == "" {
+		receipt.SupplierContact = supplier.ContactPerson
+	}
+	receipt.TotalQuantity, receipt.TotalCost = 0, 0
+
+	receivers := make([]repository.PurchaseOrderReceiver, len(receipt.Lines))
+	poLines := map[uuid.UUID]bool{}
+	for i := range receipt.Lines {
+		line := &receipt.Lines[i]
+		line.WarehouseID = receipt.WarehouseID
+		line.SupplierID = receipt.SupplierID
+		line.SupplierContact = receipt.SupplierContact
+		line.ReferenceNumber = receipt.ReferenceNumber
+		line.ReceivedDate = receipt.ReceivedDate
+		line.CreatedBy = receipt.CreatedBy
+		line.CreatedAt = receipt.CreatedAt
+
+		if line.Quantity <= 0 {
+			return nil, nil, fmt.Errorf("line %d: %w: quantity must be greater than 0", i+1, ErrInvalidInput)
+		}
+
+		if line.PurchaseOrderLineID != nil {
+			// satu line PO sekali per penerimaan, agar PO tidak tertutup di tengah transaksi
+			if poLines[*line.PurchaseOrderLineID] {
+				return nil, nil, fmt.Errorf("line %d: %w: purchase_order_line_id appears more than once", i+1, ErrInvalidInput)
+			}
+			poLines[*line.PurchaseOrderLineID] = true
+
+			if err := s.resolvePurchaseOrderLine(line); err != nil {
+				return nil, nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			lineID, productID, quantity, userID := *line.PurchaseOrderLineID, line.ProductID, line.Quantity, line.CreatedBy
+			receivers[i] = func(order *models.PurchaseOrder) (*models.PurchaseOrderLine, error) {
+				return applyPurchaseOrderReceipt(order, lineID, productID, quantity, userID)
+			}
+		} else {
+			if line.ProductID == uuid.Nil {
+				return nil, nil, fmt.Errorf("line %d: %w: product_id is required", i+1, ErrInvalidInput)
+			}
+			line.TotalCost = line.UnitCost * float64(line.Quantity)
+		}
+
+		if err := applyInboundSupplier(line, supplier); err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		receipt.TotalQuantity += line.Quantity
+		receipt.TotalCost += line.TotalCost
+	}
+
+	created, allocations, err := s.inboundRepo.CreateReceipt(receipt, s.numbering, func(products map[uuid.UUID]*models.Product) error {
+		for i, line := range receipt.Lines {
+			product, ok := products[line.ProductID]
+			if !ok {
+				return fmt.Errorf("line %d: %w: product not found", i+1, ErrInvalidInput)
+			}
+			if product.WarehouseID != receipt.WarehouseID {
+				return fmt.Errorf("line %d: %w: product does not belong to the selected warehouse", i+1, ErrInvalidInput)
+			}
+		}
+		return nil
+	}, receivers)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, a := range allocations {
+		log.Printf("[inbound] %s allocated %d backordered unit(s) to order %s", created.ReceiptNumber, a.Quantity, a.OrderNumber)
+	}
+
+	result, err := s.inboundRepo.GetReceiptByID(created.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, allocations, nil
+}
+
+// resolvePurchaseOrderLine lengkapi inbound dari PO: product, gudang dan supplier (harus sama
+// jika diisi), unit cost dan tanggal pesan / janji kirim. Quantity divalidasi ulang terhadap
+// PO yang dikunci saat inbound disimpan.
+func (s *InboundService) resolvePurchaseOrderLine(inbound *models.Inbound) error {
+	order, err := s.purchaseOrderRepo.GetByLineID(*inbound.PurchaseOrderLineID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPurchaseOrderLineNotFound
+		}
+		return err
+	}
+	if !order.IsReceivable() {
+		return fmt.Errorf("%w: purchase order %s is %s", ErrConflict, order.PONumber, order.Status)
+	}
+
+	var line *models.PurchaseOrderLine
+	for i := range order.Lines {
+		if order.Lines[i].ID == *inbound.PurchaseOrderLineID {
+			line = &order.Lines[i]
+			break
+		}
+	}
+	if line == nil {
+		return ErrPurchaseOrderLineNotFound
+	}
+
+	for _, f := range []struct {
+		field string
+		value *uuid.UUID
+		po    uuid.UUID
+	}{
+		{"product_id", &inbound.ProductID, line.ProductID},
+		{"warehouse_id", &inbound.WarehouseID, order.WarehouseID},
+		{"supplier_id", &inbound.SupplierID, order.SupplierID},
+	} {
+		if *f.value == uuid.Nil {
+			*f.value = f.po
+		} else if *f.value != f.po {
+			return fmt.Errorf("%w: %s does not match purchase order %s", ErrInvalidInput, f.field, order.PONumber)
+		}
+	}
+
+	inbound.PurchaseOrderID = &order.ID
+	if inbound.UnitCost == 0 {
+		inbound.UnitCost = line.UnitCost
+	}
+	inbound.TotalCost = inbound.UnitCost * float64(inbound.Quantity)
+	if inbound.OrderedDate == nil {
+		orderDate := order.OrderDate
+		inbound.OrderedDate = &orderDate
+	}
+	if inbound.ExpectedDate == nil {
+		inbound.ExpectedDate = order.ExpectedDate
+	}
+	return nil
+}
+
+// resolveInboundSupplier cek supplier aktif, salin snapshot nama (dan kontak default) ke inbound,
+// dan isi expected_date dari lead time standar supplier jika hanya ordered_date yang diisi
+func (s *InboundService) resolveInboundSupplier(inbound *models.Inbound) error {
+	supplier, err := s.getInboundSupplier(inbound.SupplierID)
+	if err != nil {
+		return err
+	}
+	return applyInboundSupplier(inbound, supplier)
+}
+
+func (s *InboundService) getInboundSupplier(id uuid.UUID) (*models.Supplier, error) {
+	supplier, err := s.supplierRepo.GetSupplierByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: supplier not found", ErrInvalidInput)
+		}
+		return nil, err
+	}
+	return supplier, nil
+}
+
+func applyInboundSupplier(inbound *models.Inbound, supplier *models.Supplier) error {
+	// penerimaan PO yang sudah terbit tetap boleh walau supplier dinonaktifkan
+	if !supplier.IsActive && inbound.PurchaseOrderID == nil {
+		return ErrSupplierInactive
+	}
+
+	inbound.SupplierName = supplier.Name
+	if strings.TrimSpace(inbound.SupplierContact) == "" {
+		inbound.SupplierContact = supplier.ContactPerson
+	}
+
+	if inbound.OrderedDate != nil {
+		if inbound.OrderedDate.After(inbound.ReceivedDate) {
+			return fmt.Errorf("%w: ordered_date must not be after received_date", ErrInvalidInput)
+		}
+		if inbound.ExpectedDate == nil && supplier.LeadTimeDays > 0 {
+			expected := inbound.OrderedDate.AddDate(0, 0, supplier.LeadTimeDays)
+			inbound.ExpectedDate = &expected
+		}
 	}
-	return createdInbound, nil
+	return nil
 }
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidCredentials pesan seragam untuk email tidak terdaftar maupun password salah
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// LoginThrottledError login ditolak sementara (akun terkunci, IP diblokir atau belum lewat jeda)
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return "too many failed login attempts, please try again later"
+}
+
+// loginProtection konfigurasi proteksi brute-force dari .env
+type loginProtection struct {
+	maxFailedAttempts   int           // LOGIN_MAX_FAILED_ATTEMPTS, gagal berturut-turut sebelum akun dikunci
+	lockoutDuration     time.Duration // LOGIN_LOCKOUT_DURATION (detik)
+	ipMaxFailedAttempts int           // LOGIN_IP_MAX_FAILED_ATTEMPTS, gagal per IP dalam satu window
+	ipWindow            time.Duration // LOGIN_IP_WINDOW (detik)
+	baseDelay           time.Duration // LOGIN_DELAY_BASE (detik), jeda per akun setelah gagal pertama, berlipat dua tiap gagal
+	maxDelay            time.Duration // LOGIN_DELAY_MAX (detik)
+}
+
+func getEnvInt(key string, defaultVal int) int {
+	val, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || val <= 0 {
+		return defaultVal
+	}
+	return val
+}
+
+func newLoginProtection() loginProtection {
+	return loginProtection{
+		maxFailedAttempts:   getEnvInt("LOGIN_MAX_FAILED_ATTEMPTS", 5),
+		lockoutDuration:     getEnvDuration("LOGIN_LOCKOUT_DURATION", 900),
+		ipMaxFailedAttempts: getEnvInt("LOGIN_IP_MAX_FAILED_ATTEMPTS", 20),
+		ipWindow:            getEnvDuration("LOGIN_IP_WINDOW", 900),
+		baseDelay:           getEnvDuration("LOGIN_DELAY_BASE", 1),
+		maxDelay:            getEnvDuration("LOGIN_DELAY_MAX", 30),
+	}
+}
+
+// delayAfter jeda progresif setelah n kali gagal: base, 2x base, 4x base, ... maksimal maxDelay
+func (p loginProtection) delayAfter(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+
+	delay := p.baseDelay
+	for i := 1; i < failures && delay < p.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	return delay
+}
+
+// waitUntil sisa waktu tunggu sampai `until`, nil jika sudah lewat
+func waitUntil(until time.Time) *LoginThrottledError {
+	remaining := time.Until(until)
+	if remaining <= 0 {
+		return nil
+	}
+	return &LoginThrottledError{RetryAfter: remaining}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"fmt"
+	"wms-be/domain/models"
+
+	"github.com/google/uuid"
+)
+
+// Hasil alokasi satu item order
+const (
+	AllocationFull        = "allocated"
+	AllocationPartial     = "partial"
+	AllocationBackordered = "backordered"
+)
+
+// OrderLineAllocation hasil alokasi stok satu item order saat order dibuat
+type OrderLineAllocation struct {
+	ProductID   uuid.UUID `json:"product_id"`
+	SKU         string    `json:"sku"`
+	Requested   int       `json:"requested"`
+	Allocated   int       `json:"allocated"`
+	Backordered int       `json:"backordered"`
+	Available   int       `json:"available"` // available_stock sebelum item ini dialokasikan
+	Status      string    `json:"status"`
+}
+
+// OrderAllocationError order ditolak karena ada item yang stoknya kurang, Lines berisi hasil
+// alokasi semua item agar client tahu item mana yang bermasalah
+type OrderAllocationError struct {
+	Lines []OrderLineAllocation
+}
+
+func (e *OrderAllocationError) Error() string {
+	short := 0
+	for _, line := range e.Lines {
+		if line.Backordered > 0 {
+			short++
+		}
+	}
+	return fmt.Sprintf("insufficient stock for %d of %d order line(s)", short, len(e.Lines))
+}
+
+func (e *OrderAllocationError) Unwrap() error {
+	return ErrInsufficientStock
+}
+
+// allocateOrderItems alokasikan item order terhadap available_stock product yang sudah dikunci.
+// Product yang muncul di beberapa item dialokasikan berurutan dari sisa stok yang sama.
+// Tanpa allowPartial order ditolak jika ada item yang kurang; dengan allowPartial kekurangannya
+// dicatat sebagai backorder dan dialokasikan otomatis saat stok masuk.
+func allocateOrderItems(order *models.Order, products map[uuid.UUID]*models.Product, allowPartial bool) ([]OrderLineAllocation, error) {
+	remaining := make(map[uuid.UUID]int, len(products))
+	lines := make([]OrderLineAllocation, 0, len(order.OrderItems))
+	short := false
+
+	for i := range order.OrderItems {
+		item := &order.OrderItems[i]
+		product, ok := products[item.ProductID]
+		if !ok || product.WarehouseID != order.WarehouseID {
+			return nil, fmt.Errorf("%w: product %s not found in order warehouse", ErrInvalidInput, item.ProductID)
+		}
+
+		available, seen := remaining[product.ID]
+		if !seen {
+			available = max(product.Stock-product.ReservedStock, 0)
+		}
+		allocated := min(item.Quantity, available)
+		remaining[product.ID] = available - allocated
+
+		item.AllocatedQuantity = allocated
+		item.BackorderedQuantity = item.Quantity - allocated
+
+		line := OrderLineAllocation{
+			ProductID:   product.ID,
+			SKU:         product.SKU,
+			Requested:   item.Quantity,
+			Allocated:   allocated,
+			Backordered: item.BackorderedQuantity,
+			Available:   available,
+			Status:      AllocationFull,
+		}
+		switch {
+		case allocated == 0:
+			line.Status = AllocationBackordered
+		case allocated < item.Quantity:
+			line.Status = AllocationPartial
+		}
+		lines = append(lines, line)
+
+		if line.Backordered > 0 {
+			short = true
+		}
+	}
+
+	if short && !allowPartial {
+		return nil, &OrderAllocationError{Lines: lines}
+	}
+	return lines, nil
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"wms-be/domain/models"
+	"wms-be/domain/repository"
+	"wms-be/infrastructure/database"
+)
+
+// orderExpiryLockKey kunci advisory lock Postgres untuk job expiry,
+// hanya satu instance yang memproses order kedaluwarsa pada satu waktu
+const orderExpiryLockKey int64 = 0x574d530001
+
+// errOrderNotExpired order sudah berubah (dibayar / dibatalkan) sebelum sempat di-expire
+var errOrderNotExpired = errors.New("order is no longer expirable")
+
+// OrderExpiryStatus status job expiry, ditampilkan di endpoint admin
+type OrderExpiryStatus struct {
+	Enabled        bool       `json:"enabled"`
+	Running        bool       `json:"running"`
+	Interval       string     `json:"interval"`
+	BatchSize      int        `json:"batchSize"`
+	LastStartedAt  *time.Time `json:"lastStartedAt"`
+	LastFinishedAt *time.Time `json:"lastFinishedAt"`
+	LastDuration   string     `json:"lastDuration,omitempty"`
+	LastExpired    int        `json:"lastExpired"`
+	LastSkipped    int        `json:"lastSkipped"`
+	LastFailed     int        `json:"lastFailed"`
+	LastLockHeld   bool       `json:"lastLockHeld"` // run terakhir dilewati karena instance lain sedang jalan
+	LastError      string     `json:"lastError,omitempty"`
+	TotalExpired   int64      `json:"totalExpired"`
+	NextRunAt      *time.Time `json:"nextRunAt"`
+}
+
+// OrderExpiryScheduler job background yang memindahkan order pending_payment yang sudah
+// lewat expires_at ke expired. Reserved stock dilepas lewat jalur transisi status order
+// yang sama dengan endpoint (TransitionOrderStatus), jadi efeknya tidak dobel.
+type OrderExpiryScheduler struct {
+	orderRepo repository.OrderRepository
+	enabled   bool
+	interval  time.Duration
+	batchSize int
+	maxPerRun int
+
+	runMu  sync.Mutex // satu run per proses
+	mu     sync.RWMutex
+	status OrderExpiryStatus
+}
+
+// NewOrderExpiryScheduler konfigurasi dari env ORDER_EXPIRY_ENABLED (default true),
+// ORDER_EXPIRY_INTERVAL (detik, default 60), ORDER_EXPIRY_BATCH_SIZE (default 100)
+// dan ORDER_EXPIRY_MAX_PER_RUN (default 5000)
+func NewOrderExpiryScheduler(orderRepo repository.OrderRepository) *OrderExpiryScheduler {
+	enabled := true
+	if v, err := strconv.ParseBool(os.Getenv("ORDER_EXPIRY_ENABLED")); err == nil {
+		enabled = v
+	}
+
+	s := &OrderExpiryScheduler{
+		orderRepo: orderRepo,
+		enabled:   enabled,
+		interval:  getEnvDuration("ORDER_EXPIRY_INTERVAL", 60),
+		batchSize: getEnvInt("ORDER_EXPIRY_BATCH_SIZE", 100),
+		maxPerRun: getEnvInt("ORDER_EXPIRY_MAX_PER_RUN", 5000),
+	}
+	if s.interval <= 0 {
+		s.interval = time.Minute
+	}
+	s.status = OrderExpiryStatus{
+		Enabled:   s.enabled,
+		Interval:  s.interval.String(),
+		BatchSize: s.batchSize,
+	}
+	return s
+}
+
+// Start jalankan job secara periodik sampai ctx dibatalkan
+func (s *OrderExpiryScheduler) Start(ctx context.Context) {
+	if !s.enabled {
+		log.Printf("[order-expiry] scheduler disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.setNextRun(time.Now())
+		for {
+			if _, err := s.RunOnce(ctx); err != nil {
+				log.Printf("[order-expiry] run failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Status salinan status run terakhir
+func (s *OrderExpiryScheduler) Status() OrderExpiryStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+func (s *OrderExpiryScheduler) setNextRun(t time.Time) {
+	s.mu.Lock()
+	next := t
+	s.status.NextRunAt = &next
+	s.mu.Unlock()
+}
+
+// RunOnce proses order kedaluwarsa per batch. Dilewati (tanpa error) jika advisory lock
+// sedang dipegang instance lain.
+func (s *OrderExpiryScheduler) RunOnce(ctx context.Context) (OrderExpiryStatus, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	started := time.Now()
+	s.mu.Lock()
+	s.status.Running = true
+	s.status.LastStartedAt = &started
+	s.mu.Unlock()
+
+	var expired, skipped, failed int
+	acquired, err := database.WithAdvisoryLock(ctx, orderExpiryLockKey, func() error {
+		var runErr error
+		expired, skipped, failed, runErr = s.expireOverdueOrders(ctx, started)
+		return runErr
+	})
+
+	finished := time.Now()
+	next := finished.Add(s.interval)
+	s.mu.Lock()
+	s.status.Running = false
+	s.status.LastFinishedAt = &finished
+	s.status.LastDuration = finished.Sub(started).String()
+	s.status.LastExpired = expired
+	s.status.LastSkipped = skipped
+	s.status.LastFailed = failed
+	s.status.LastLockHeld = err == nil && !acquired
+	s.status.LastError = ""
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+	s.status.TotalExpired += int64(expired)
+	s.status.NextRunAt = &next
+	status := s.status
+	s.mu.Unlock()
+
+	if expired > 0 || failed > 0 {
+		log.Printf("[order-expiry] expired=%d skipped=%d failed=%d duration=%s", expired, skipped, failed, status.LastDuration)
+	}
+	return status, err
+}
+
+func (s *OrderExpiryScheduler) expireOverdueOrders(ctx context.Context, now time.Time) (expired, skipped, failed int, err error) {
+	for expired+skipped+failed < s.maxPerRun {
+		if err := ctx.Err(); err != nil {
+			return expired, skipped, failed, err
+		}
+
+		ids, err := s.orderRepo.GetExpiredPendingOrderIDs(now, s.batchSize)
+		if err != nil {
+			return expired, skipped, failed, err
+		}
+
+		progressed := false
+		for _, id := range ids {
+			switch err := s.expireOrder(id, now); {
+			case err == nil:
+				expired++
+				progressed = true
+			case errors.Is(err, errOrderNotExpired):
+				skipped++
+				progressed = true
+			default:
+				failed++
+				log.Printf("[order-expiry] failed to expire order %s: %v", id, err)
+			}
+		}
+
+		// batch terakhir, atau semua order di batch gagal (akan terpilih lagi, coba di run berikutnya)
+		if len(ids) < s.batchSize || !progressed {
+			break
+		}
+	}
+	return expired, skipped, failed, nil
+}
+
+// expireOrder cek ulang status & expires_at pada baris yang dikunci, karena order bisa saja
+// dibayar di antara query batch dan transisi
+func (s *OrderExpiryScheduler) expireOrder(id string, now time.Time) error {
+	_, err := s.orderRepo.TransitionOrderStatus(id, func(order *models.Order) (repository.OrderStatusChange, error) {
+		if order.Status != models.OrderStatusPendingPayment || order.ExpiresAt.IsZero() || order.ExpiresAt.After(now) {
+			return repository.OrderStatusChange{}, errOrderNotExpired
+		}
+		return orderStatusChange(order.Status, models.OrderStatusExpired)
+	})
+	return err
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"fmt"
+	"time"
+	"wms-be/domain/models"
+	"wms-be/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+// isOrderEditable item order hanya bisa diubah sebelum order diproses
+func isOrderEditable(status string) bool {
+	return status == models.OrderStatusPendingPayment || status == models.OrderStatusConfirmed
+}
+
+// AddOrderItem tambah product baru ke order; quantity dialokasikan dari available_stock
+// seperti saat order dibuat
+func (s *orderService) AddOrderItem(id string, productID uuid.UUID, quantity int, allowPartial bool, userID uuid.UUID, scope models.WarehouseScope) (*models.Order, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be greater than 0", ErrInvalidInput)
+	}
+
+	var credit orderCreditIncrease
+	order, err := s.orderRepo.EditOrderItem(id, productID, func(order *models.Order, product *models.Product) (*repository.OrderItemEdit, error) {
+		if err := checkOrderEditable(order, scope); err != nil {
+			return nil, err
+		}
+		if product == nil || product.WarehouseID != order.WarehouseID {
+			return nil, fmt.Errorf("%w: product %s not found in order warehouse", ErrInvalidInput, productID)
+		}
+		for _, item := range order.OrderItems {
+			if item.ProductID == product.ID {
+				return nil, fmt.Errorf("%w: product %s is already in the order, change its quantity instead", ErrConflict, product.SKU)
+			}
+		}
+
+		item := models.OrderItem{OrderID: order.ID, ProductID: product.ID}
+		reserved, err := resizeOrderItem(&item, product, quantity, allowPartial)
+		if err != nil {
+			return nil, err
+		}
+		// unit_price item baru diisi trigger dari harga product
+		credit.track(order, product.Price*float64(quantity))
+		return &repository.OrderItemEdit{
+			Item:          item,
+			ReservedDelta: reserved,
+			Change:        orderItemChange(models.OrderItemAdded, 0, quantity, userID),
+		}, nil
+	}, credit.check)
+	if err != nil {
+		return nil, mapOrderError(err)
+	}
+	return order, nil
+}
+
+// UpdateOrderItem ubah quantity item; reserved_stock disesuaikan sebesar selisihnya
+func (s *orderService) UpdateOrderItem(id string, itemID uuid.UUID, quantity int, allowPartial bool, userID uuid.UUID, scope models.WarehouseScope) (*models.Order, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be greater than 0, remove the item instead", ErrInvalidInput)
+	}
+	productID, err := s.orderItemProductID(id, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	var credit orderCreditIncrease
+	order, err := s.orderRepo.EditOrderItem(id, productID, func(order *models.Order, product *models.Product) (*repository.OrderItemEdit, error) {
+		if err := checkOrderEditable(order, scope); err != nil {
+			return nil, err
+		}
+		item, err := findOrderItemByID(order, itemID)
+		if err != nil {
+			return nil, err
+		}
+		if item.Quantity == quantity {
+			return nil, fmt.Errorf("%w: quantity is unchanged", ErrInvalidInput)
+		}
+
+		oldQuantity := item.Quantity
+		reserved, err := resizeOrderItem(item, product, quantity, allowPartial)
+		if err != nil {
+			return nil, err
+		}
+		credit.track(order, item.UnitPrice*float64(quantity-oldQuantity))
+		return &repository.OrderItemEdit{
+			Item:          *item,
+			ReservedDelta: reserved,
+			Change:        orderItemChange(models.OrderItemUpdated, oldQuantity, quantity, userID),
+		}, nil
+	}, credit.check)
+	if err != nil {
+		return nil, mapOrderError(err)
+	}
+	return order, nil
+}
+
+// RemoveOrderItem hapus item dari order dan lepas reservasinya. Item terakhir tidak bisa
+// dihapus, batalkan order-nya.
+func (s *orderService) RemoveOrderItem(id string, itemID uuid.UUID, userID uuid.UUID, scope models.WarehouseScope) (*models.Order, error) {
+	productID, err := s.orderItemProductID(id, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.orderRepo.EditOrderItem(id, productID, func(order *models.Order, product *models.Product) (*repository.OrderItemEdit, error) {
+		if err := checkOrderEditable(order, scope); err != nil {
+			return nil, err
+		}
+		item, err := findOrderItemByID(order, itemID)
+		if err != nil {
+			return nil, err
+		}
+		if len(order.OrderItems) == 1 {
+			return nil, fmt.Errorf("%w: cannot remove the last item, cancel the order instead", ErrConflict)
+		}
+
+		return &repository.OrderItemEdit{
+			Item:          *item,
+			ReservedDelta: -item.AllocatedQuantity,
+			Change:        orderItemChange(models.OrderItemRemoved, item.Quantity, 0, userID),
+		}, nil
+	}, nil)
+	if err != nil {
+		return nil, mapOrderError(err)
+	}
+	return order, nil
+}
+
+// GetOrderItemChanges riwayat perubahan item order
+func (s *orderService) GetOrderItemChanges(id string, scope models.WarehouseScope) ([]models.OrderItemChange, error) {
+	if _, err := s.GetOrderByID(id, scope); err != nil {
+		return nil, err
+	}
+	return s.orderRepo.GetOrderItemChanges(id)
+}
+
+// orderItemProductID product item order, dipakai untuk mengunci product sebelum item diubah
+// (product item tidak pernah berubah, jadi aman dibaca tanpa lock)
+func (s *orderService) orderItemProductID(id string, itemID uuid.UUID) (uuid.UUID, error) {
+	order, err := s.orderRepo.GetOrderByID(id)
+	if err != nil {
+		return uuid.Nil, mapOrderError(err)
+	}
+	item, err := findOrderItemByID(order, itemID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return item.ProductID, nil
+}
+
+// orderCreditIncrease kenaikan nilai order dari edit item, diperiksa terhadap credit limit
+// hanya untuk order pending_payment (yang dihitung sebagai outstanding customer)
+type orderCreditIncrease struct {
+	amount float64
+}
+
+func (c *orderCreditIncrease) track(order *models.Order, delta float64) {
+	c.amount = 0
+	if order.Status == models.OrderStatusPendingPayment {
+		c.amount = delta
+	}
+}
+
+func (c *orderCreditIncrease) check(customer *models.Customer, outstanding repository.CustomerOutstanding) error {
+	return checkCreditLimit(customer, outstanding, c.amount)
+}
+
+func checkOrderEditable(order *models.Order, scope models.WarehouseScope) error {
+	if err := checkWarehouseAccess(scope, order.WarehouseID); err != nil {
+		return err
+	}
+	if !isOrderEditable(order.Status) {
+		return fmt.Errorf("%w: items of an order in status %s cannot be changed", ErrConflict, order.Status)
+	}
+	return nil
+}
+
+func findOrderItemByID(order *models.Order, itemID uuid.UUID) (*models.OrderItem, error) {
+	for i := range order.OrderItems {
+		if order.OrderItems[i].ID == itemID {
+			return &order.OrderItems[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: order item %s not found in order", ErrNotFound, itemID)
+}
+
+// resizeOrderItem ubah quantity item dan kembalikan selisih reserved_stock. Tambahan quantity
+// dialokasikan dari available_stock (kekurangannya backorder jika allowPartial), pengurangan
+// memotong backorder dulu baru bagian yang teralokasi.
+func resizeOrderItem(item *models.OrderItem, product *models.Product, quantity int, allowPartial bool) (int, error) {
+	if quantity < item.ShippedQuantity {
+		return 0, fmt.Errorf("%w: quantity cannot be less than shipped quantity %d", ErrInvalidInput, item.ShippedQuantity)
+	}
+
+	delta := quantity - item.Quantity
+	reserved := 0
+	if delta > 0 {
+		available := max(product.Stock-product.ReservedStock, 0)
+		allocated := min(delta, available)
+		if allocated < delta && !allowPartial {
+			return 0, &InsufficientStockError{
+				ProductID: product.ID,
+				SKU:       product.SKU,
+				Requested: delta,
+				Available: available,
+				Stock:     product.Stock,
+				Reserved:  product.ReservedStock,
+			}
+		}
+		item.AllocatedQuantity += allocated
+		item.BackorderedQuantity += delta - allocated
+		reserved = allocated
+	} else {
+		fromBackorder := min(-delta, item.BackorderedQuantity)
+		fromAllocated := -delta - fromBackorder
+		item.BackorderedQuantity -= fromBackorder
+		item.AllocatedQuantity -= fromAllocated
+		item.PickedQuantity = min(item.PickedQuantity, item.AllocatedQuantity)
+		reserved = -fromAllocated
+	}
+	item.Quantity = quantity
+	return reserved, nil
+}
+
+func orderItemChange(action string, oldQuantity, newQuantity int, userID uuid.UUID) models.OrderItemChange {
+	return models.OrderItemChange{
+		Action:      action,
+		OldQuantity: oldQuantity,
+		NewQuantity: newQuantity,
+		ChangedBy:   userID,
+		ChangedAt:   time.Now(),
+	}
+}
@@ -2,68 +2,352 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 	"wms-be/domain/models"
 	"wms-be/domain/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+var ErrOrderNotFound = fmt.Errorf("%w: order not found", ErrNotFound)
+
 type OrderService interface {
-	CreateOrder(order *models.Order) (*models.Order, error)
-	UpdateOrderStatus(id string, status string) (*models.Order, error)
-	GetOrders(page, limit int, filters map[string]interface{}) ([]models.Order, int64, error)
-	GetOrderByID(id string) (*models.Order, error)
+	CreateOrder(order *models.Order, allowPartial bool, scope models.WarehouseScope) (*models.Order, []OrderLineAllocation, error)
+	UpdateOrderStatus(id string, status string, userID uuid.UUID, scope models.WarehouseScope) (*models.Order, error)
+	GetOrders(page, limit int, filters map[string]interface{}, scope models.WarehouseScope) ([]models.Order, int64, error)
+	GetOrderByID(id string, scope models.WarehouseScope) (*models.Order, error)
+	PickOrderItems(id string, lines []OrderLineQuantity, scope models.WarehouseScope) (*models.Order, error)
+	ShipOrder(id string, userID uuid.UUID, lines []OrderLineQuantity, notes string, scope models.WarehouseScope) (*models.Order, error)
+	AddOrderItem(id string, productID uuid.UUID, quantity int, allowPartial bool, userID uuid.UUID, scope models.WarehouseScope) (*models.Order, error)
+	UpdateOrderItem(id string, itemID uuid.UUID, quantity int, allowPartial bool, userID uuid.UUID, scope models.WarehouseScope) (*models.Order, error)
+	RemoveOrderItem(id string, itemID uuid.UUID, userID uuid.UUID, scope models.WarehouseScope) (*models.Order, error)
+	GetOrderItemChanges(id string, scope models.WarehouseScope) ([]models.OrderItemChange, error)
+}
+
+// OrderLineQuantity quantity untuk satu item order (pick / kirim)
+type OrderLineQuantity struct {
+	OrderItemID uuid.UUID
+	Quantity    int
 }
 
 type orderService struct {
-	orderRepo repository.OrderRepository
+	orderRepo         repository.OrderRepository
+	customerRepo      repository.CustomerRepository
+	numbering         repository.DocumentNumbering
+	shipmentNumbering repository.DocumentNumbering
 }
 
-func NewOrderService(orderRepo repository.OrderRepository) OrderService {
-	return &orderService{orderRepo: orderRepo}
+func NewOrderService(orderRepo repository.OrderRepository, customerRepo repository.CustomerRepository) OrderService {
+	return &orderService{
+		orderRepo:    orderRepo,
+		customerRepo: customerRepo,
+		numbering:    documentNumbering(repository.DocumentOrder, "DOC_NUMBER_ORDER_FORMAT", defaultOrderNumberFormat),
+		// pengiriman order memakai nomor surat jalan (outbound), satu urutan dengan outbound manual
+		shipmentNumbering: documentNumbering(repository.DocumentOutbound, "DOC_NUMBER_OUTBOUND_FORMAT", defaultOutboundNumberFormat),
+	}
 }
 
-func (s *orderService) CreateOrder(order *models.Order) (*models.Order, error) {
+// CreateOrder validasi dan alokasikan setiap item terhadap available_stock di gudang order
+// (baris product dikunci), lalu reservasi stok untuk quantity yang teralokasi.
+// allowPartial mengizinkan item yang kurang dialokasikan sebagian. Data customer dan alamat
+// disalin ke order, nilai order tidak boleh melewati sisa credit limit customer.
+func (s *orderService) CreateOrder(order *models.Order, allowPartial bool, scope models.WarehouseScope) (*models.Order, []OrderLineAllocation, error) {
 	if order == nil {
-		return nil, errors.New("order cannot be nil")
+		return nil, nil, errors.New("order cannot be nil")
+	}
+	if err := checkWarehouseAccess(scope, order.WarehouseID); err != nil {
+		return nil, nil, err
+	}
+	if len(order.OrderItems) == 0 {
+		return nil, nil, fmt.Errorf("%w: order must have at least one item", ErrInvalidInput)
+	}
+	for _, item := range order.OrderItems {
+		if item.Quantity <= 0 {
+			return nil, nil, fmt.Errorf("%w: quantity must be greater than 0", ErrInvalidInput)
+		}
+	}
+
+	if _, err := s.resolveOrderCustomer(order); err != nil {
+		return nil, nil, err
 	}
 
-	// Create order beserta items
-	createdOrder, err := s.orderRepo.CreateOrder(order)
+	var allocation []OrderLineAllocation
+	var value float64
+	// Create order beserta items, order_number selalu dari server
+	createdOrder, err := s.orderRepo.CreateOrder(order, s.numbering, func(order *models.Order, products map[uuid.UUID]*models.Product) error {
+		lines, err := allocateOrderItems(order, products, allowPartial)
+		if err != nil {
+			return err
+		}
+		allocation = lines
+
+		for _, item := range order.OrderItems {
+			value += products[item.ProductID].Price * float64(item.Quantity)
+		}
+		return nil
+	}, func(customer *models.Customer, outstanding repository.CustomerOutstanding) error {
+		return checkCreditLimit(customer, outstanding, value)
+	})
 	if err != nil {
-		return nil, err
+		return nil, nil, mapOrderError(err)
 	}
 
 	// Preload items setelah create agar response lengkap
 	createdOrderWithItems, err := s.orderRepo.GetOrderByID(createdOrder.ID.String())
 	if err != nil {
+		return nil, nil, err
+	}
+
+	return createdOrderWithItems, allocation, nil
+}
+
+// checkCreditLimit tolak tambahan nilai amount jika outstanding customer (order pending_payment,
+// dihitung di transaksi yang mengunci customer) ditambah amount melewati credit limit
+func checkCreditLimit(customer *models.Customer, outstanding repository.CustomerOutstanding, amount float64) error {
+	if customer.CreditLimit <= 0 || amount <= 0 {
+		return nil
+	}
+	if outstanding.Amount+amount > customer.CreditLimit {
+		return fmt.Errorf("%w: outstanding %.2f + order %.2f > limit %.2f", ErrCreditLimitExceeded, outstanding.Amount, amount, customer.CreditLimit)
+	}
+	return nil
+}
+
+// resolveOrderCustomer cek customer aktif dan alamat yang dipilih (default jika kosong),
+// lalu salin snapshot-nya ke order
+func (s *orderService) resolveOrderCustomer(order *models.Order) (*models.Customer, error) {
+	customer, err := s.customerRepo.GetCustomerByID(order.CustomerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: customer not found", ErrInvalidInput)
+		}
 		return nil, err
 	}
+	if !customer.IsActive {
+		return nil, fmt.Errorf("%w: customer %s is inactive", ErrInvalidInput, customer.Code)
+	}
 
-	return createdOrderWithItems, nil
+	shipping, err := pickCustomerAddress(customer, order.ShippingAddressID, models.AddressShipping)
+	if err != nil {
+		return nil, err
+	}
+	billing, err := pickCustomerAddress(customer, order.BillingAddressID, models.AddressBilling)
+	if err != nil {
+		return nil, err
+	}
+
+	order.CustomerCode = customer.Code
+	order.CustomerName = customer.Name
+	order.ShippingAddressID, order.ShippingAddress = nil, ""
+	if shipping != nil {
+		order.ShippingAddressID = &shipping.ID
+		order.ShippingAddress = shipping.FullAddress()
+	}
+	order.BillingAddressID, order.BillingAddress = nil, ""
+	if billing != nil {
+		order.BillingAddressID = &billing.ID
+		order.BillingAddress = billing.FullAddress()
+	}
+	return customer, nil
+}
+
+// pickCustomerAddress alamat customer dengan id dan jenis tersebut, atau alamat default
+// jenis itu jika id kosong (nil jika customer tidak punya alamat default)
+func pickCustomerAddress(customer *models.Customer, id *uuid.UUID, addressType string) (*models.CustomerAddress, error) {
+	for i := range customer.Addresses {
+		address := &customer.Addresses[i]
+		if address.AddressType != addressType {
+			continue
+		}
+		if (id != nil && address.ID == *id) || (id == nil && address.IsDefault) {
+			return address, nil
+		}
+	}
+	if id != nil {
+		return nil, fmt.Errorf("%w: %s address %s not found for customer", ErrInvalidInput, addressType, *id)
+	}
+	return nil, nil
 }
 
-func (s *orderService) UpdateOrderStatus(id string, status string) (*models.Order, error) {
+// UpdateOrderStatus pindahkan status order sesuai orderTransitions. Validasi dilakukan
+// terhadap baris order yang sudah dikunci, efek stok diterapkan di transaksi yang sama.
+// Status shipped mengirim semua sisa yang teralokasi dalam satu shipment.
+func (s *orderService) UpdateOrderStatus(id string, status string, userID uuid.UUID, scope models.WarehouseScope) (*models.Order, error) {
 	if id == "" {
-		return nil, errors.New("order ID cannot be empty")
+		return nil, fmt.Errorf("%w: order ID cannot be empty", ErrInvalidInput)
 	}
 	if status == "" {
-		return nil, errors.New("status cannot be empty")
+		return nil, fmt.Errorf("%w: status cannot be empty", ErrInvalidInput)
+	}
+	switch status {
+	case models.OrderStatusPartiallyShipped:
+		return nil, fmt.Errorf("%w: partial shipments are created via the shipments endpoint", ErrInvalidInput)
+	case models.OrderStatusShipped:
+		return s.shipOrder(id, userID, nil, "", true, scope)
+	}
+
+	order, err := s.orderRepo.TransitionOrderStatus(id, func(order *models.Order) (repository.OrderStatusChange, error) {
+		if err := checkWarehouseAccess(scope, order.WarehouseID); err != nil {
+			return repository.OrderStatusChange{}, err
+		}
+		return orderStatusChange(order.Status, status)
+	})
+	if err != nil {
+		return nil, mapOrderError(err)
 	}
-	return s.orderRepo.UpdateOrderStatus(id, status)
+	return order, nil
 }
 
-func (s *orderService) GetOrders(page, limit int, filters map[string]interface{}) ([]models.Order, int64, error) {
+func (s *orderService) GetOrders(page, limit int, filters map[string]interface{}, scope models.WarehouseScope) ([]models.Order, int64, error) {
 	if page <= 0 {
 		page = 1
 	}
 	if limit <= 0 {
 		limit = 10
 	}
-	return s.orderRepo.GetOrders(page, limit, filters)
+	return s.orderRepo.GetOrders(page, limit, filters, scope)
 }
 
-func (s *orderService) GetOrderByID(id string) (*models.Order, error) {
+func (s *orderService) GetOrderByID(id string, scope models.WarehouseScope) (*models.Order, error) {
 	if id == "" {
 		return nil, errors.New("order ID cannot be empty")
 	}
-	return s.orderRepo.GetOrderByID(id)
+	order, err := s.orderRepo.GetOrderByID(id)
+	if err != nil {
+		return nil, mapOrderError(err)
+	}
+	if err := checkWarehouseAccess(scope, order.WarehouseID); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// PickOrderItems catat quantity yang sudah diambil dari rak, maksimal sebanyak yang teralokasi
+func (s *orderService) PickOrderItems(id string, lines []OrderLineQuantity, scope models.WarehouseScope) (*models.Order, error) {
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: items are required", ErrInvalidInput)
+	}
+
+	order, err := s.orderRepo.UpdateOrderItems(id, func(order *models.Order) error {
+		if err := checkWarehouseAccess(scope, order.WarehouseID); err != nil {
+			return err
+		}
+		switch order.Status {
+		case models.OrderStatusConfirmed, models.OrderStatusProcessing, models.OrderStatusPartiallyShipped:
+		default:
+			return fmt.Errorf("%w: order in status %s cannot be picked", ErrConflict, order.Status)
+		}
+
+		for _, line := range lines {
+			item, err := findOrderItem(order, line)
+			if err != nil {
+				return err
+			}
+			if item.PickedQuantity+line.Quantity > item.AllocatedQuantity {
+				return fmt.Errorf("%w: picked quantity for item %s exceeds allocated quantity %d", ErrInvalidInput, item.ID, item.AllocatedQuantity)
+			}
+			item.PickedQuantity += line.Quantity
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, mapOrderError(err)
+	}
+	return order, nil
+}
+
+// ShipOrder buat satu pengiriman dari quantity yang teralokasi. lines kosong berarti kirim
+// semua yang teralokasi. Order menjadi shipped jika semua item sudah terkirim penuh,
+// selain itu partially_shipped (sisa backorder dikirim di pengiriman berikutnya).
+func (s *orderService) ShipOrder(id string, userID uuid.UUID, lines []OrderLineQuantity, notes string, scope models.WarehouseScope) (*models.Order, error) {
+	return s.shipOrder(id, userID, lines, notes, false, scope)
+}
+
+// shipOrder complete mewajibkan order terkirim penuh oleh pengiriman ini (tanpa sisa backorder)
+func (s *orderService) shipOrder(id string, userID uuid.UUID, lines []OrderLineQuantity, notes string, complete bool, scope models.WarehouseScope) (*models.Order, error) {
+	order, err := s.orderRepo.ShipOrder(id, s.shipmentNumbering, func(order *models.Order) (*models.OrderShipment, repository.OrderStatusChange, error) {
+		if err := checkWarehouseAccess(scope, order.WarehouseID); err != nil {
+			return nil, repository.OrderStatusChange{}, err
+		}
+		if order.Status != models.OrderStatusProcessing && order.Status != models.OrderStatusPartiallyShipped {
+			return nil, repository.OrderStatusChange{}, fmt.Errorf("%w: order in status %s cannot be shipped", ErrConflict, order.Status)
+		}
+
+		if len(lines) == 0 {
+			for _, item := range order.OrderItems {
+				if item.AllocatedQuantity > 0 {
+					lines = append(lines, OrderLineQuantity{OrderItemID: item.ID, Quantity: item.AllocatedQuantity})
+				}
+			}
+			if len(lines) == 0 {
+				return nil, repository.OrderStatusChange{}, fmt.Errorf("%w: order has no allocated quantity to ship", ErrConflict)
+			}
+		}
+
+		shipment := &models.OrderShipment{
+			Notes:     strings.TrimSpace(notes),
+			ShippedBy: userID,
+			ShippedAt: time.Now(),
+		}
+		for _, line := range lines {
+			item, err := findOrderItem(order, line)
+			if err != nil {
+				return nil, repository.OrderStatusChange{}, err
+			}
+			if line.Quantity > item.AllocatedQuantity {
+				return nil, repository.OrderStatusChange{}, fmt.Errorf("%w: shipped quantity for item %s exceeds allocated quantity %d", ErrInvalidInput, item.ID, item.AllocatedQuantity)
+			}
+			item.AllocatedQuantity -= line.Quantity
+			item.PickedQuantity = max(item.PickedQuantity-line.Quantity, 0)
+			item.ShippedQuantity += line.Quantity
+
+			shipment.Items = append(shipment.Items, models.OrderShipmentItem{
+				OrderItemID: item.ID,
+				ProductID:   item.ProductID,
+				Quantity:    line.Quantity,
+			})
+		}
+
+		next := models.OrderStatusShipped
+		for _, item := range order.OrderItems {
+			if item.OutstandingQuantity() > 0 {
+				next = models.OrderStatusPartiallyShipped
+				break
+			}
+		}
+		if complete && next != models.OrderStatusShipped {
+			return nil, repository.OrderStatusChange{}, fmt.Errorf("%w: order still has backordered quantity, ship it in partial shipments", ErrConflict)
+		}
+		change, err := orderStatusChange(order.Status, next)
+		return shipment, change, err
+	})
+	if err != nil {
+		return nil, mapOrderError(err)
+	}
+	return order, nil
+}
+
+// findOrderItem item order untuk line, quantity line harus positif
+func findOrderItem(order *models.Order, line OrderLineQuantity) (*models.OrderItem, error) {
+	if line.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be greater than 0", ErrInvalidInput)
+	}
+	item, err := findOrderItemByID(order, line.OrderItemID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: order item %s not found in order", ErrInvalidInput, line.OrderItemID)
+	}
+	return item, nil
+}
+
+func mapOrderError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrOrderNotFound
+	}
+	if sqlState(err) == sqlStateUniqueViolation {
+		return fmt.Errorf("%w: order number already exists", ErrConflict)
+	}
+	return err
 }
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"fmt"
+	"wms-be/domain/models"
+	"wms-be/domain/repository"
+)
+
+// orderTransitions status yang boleh dituju dari tiap status order.
+// delivered, cancelled dan expired adalah status akhir. partially_shipped dan shipped
+// hanya dicapai lewat pengiriman (shipment).
+var orderTransitions = map[string][]string{
+	models.OrderStatusPendingPayment:   {models.OrderStatusConfirmed, models.OrderStatusCancelled, models.OrderStatusExpired},
+	models.OrderStatusConfirmed:        {models.OrderStatusProcessing, models.OrderStatusCancelled},
+	models.OrderStatusProcessing:       {models.OrderStatusPartiallyShipped, models.OrderStatusShipped, models.OrderStatusCancelled},
+	models.OrderStatusPartiallyShipped: {models.OrderStatusPartiallyShipped, models.OrderStatusShipped, models.OrderStatusCancelled},
+	models.OrderStatusShipped:          {models.OrderStatusDelivered},
+}
+
+// isOrderStatus cek status dikenal (enum public.order_status)
+func isOrderStatus(status string) bool {
+	switch status {
+	case models.OrderStatusPendingPayment, models.OrderStatusConfirmed, models.OrderStatusProcessing,
+		models.OrderStatusPartiallyShipped, models.OrderStatusShipped, models.OrderStatusDelivered, models.OrderStatusCancelled, models.OrderStatusExpired:
+		return true
+	}
+	return false
+}
+
+// orderStatusChange validasi transisi from -> to dan tentukan efek stoknya.
+// Bagian item yang teralokasi mereservasi stok sampai dikirim, jadi cancelled / expired
+// melepas reservasi (dan backorder). Efek stok pengiriman diterapkan per shipment.
+func orderStatusChange(from, to string) (repository.OrderStatusChange, error) {
+	if !isOrderStatus(to) {
+		return repository.OrderStatusChange{}, fmt.Errorf("%w: unknown order status %q", ErrInvalidInput, to)
+	}
+
+	allowed := false
+	for _, s := range orderTransitions[from] {
+		if s == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return repository.OrderStatusChange{}, fmt.Errorf("%w: cannot change order status from %s to %s", ErrConflict, from, to)
+	}
+
+	change := repository.OrderStatusChange{Status: to}
+	if to == models.OrderStatusCancelled || to == models.OrderStatusExpired {
+		change.ReleaseReserved = true
+	}
+	return change, nil
+}
@@ -1,26 +1,36 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
 	"wms-be/domain/models"
 	"wms-be/domain/repository"
+
+	"gorm.io/gorm"
 )
 
 type IOutboundService interface {
-	GetOutbounds(search, warehouseId string, page, limit int) ([]models.Outbound, int, error)
+	GetOutbounds(search, warehouseId string, page, limit int, scope models.WarehouseScope) ([]models.Outbound, int, error)
 	GetAllOutbounds() ([]models.Outbound, error)
-	CreateOutbound(outbound models.Outbound) (models.Outbound, error)
+	CreateOutbound(outbound models.Outbound, scope models.WarehouseScope) (models.Outbound, error)
 }
 
 type OutboundService struct {
 	outboundRepo repository.OutboundRepository
+	numbering    repository.DocumentNumbering
 }
 
 // Constructor
 func NewOutboundService(outboundRepo repository.OutboundRepository) *OutboundService {
-	return &OutboundService{outboundRepo: outboundRepo}
+	return &OutboundService{
+		outboundRepo: outboundRepo,
+		numbering:    documentNumbering(repository.DocumentOutbound, "DOC_NUMBER_OUTBOUND_FORMAT", defaultOutboundNumberFormat),
+	}
 }
 
-func (s *OutboundService) GetOutbounds(search, warehouseId string, page, limit int) ([]models.Outbound, int, error) {
+func (s *OutboundService) GetOutbounds(search, warehouseId string, page, limit int, scope models.WarehouseScope) ([]models.Outbound, int, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -28,7 +38,7 @@ func (s *OutboundService) GetOutbounds(search, warehouseId string, page, limit i
 		limit = 10
 	}
 
-	outbounds, total, err := s.outboundRepo.GetOutbounds(search, warehouseId, page, limit)
+	outbounds, total, err := s.outboundRepo.GetOutbounds(search, warehouseId, page, limit, scope)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -36,16 +46,52 @@ func (s *OutboundService) GetOutbounds(search, warehouseId string, page, limit i
 }
 
 func (s *OutboundService) GetAllOutbounds() ([]models.Outbound, error) {
-	outbounds, _, err := s.outboundRepo.GetOutbounds("", "", 0, 0)
+	outbounds, _, err := s.outboundRepo.GetOutbounds("", "", 0, 0, models.AllWarehouses())
 	if err != nil {
 		return nil, err
 	}
 	return outbounds, nil
 }
 
-func (s *OutboundService) CreateOutbound(outbound models.Outbound) (models.Outbound, error) {
-	createdOutbound, err := s.outboundRepo.CreateOutbound(outbound)
+// CreateOutbound validasi quantity terhadap available_stock product (dikunci di repo).
+// Jika outbound.StockOverride di-set (hanya admin, dicek di handler) validasi stok dilewati
+// dan alasan override wajib diisi.
+func (s *OutboundService) CreateOutbound(outbound models.Outbound, scope models.WarehouseScope) (models.Outbound, error) {
+	if err := checkWarehouseAccess(scope, outbound.WarehouseID); err != nil {
+		return models.Outbound{}, err
+	}
+	if outbound.Quantity <= 0 {
+		return models.Outbound{}, fmt.Errorf("%w: quantity must be greater than 0", ErrInvalidInput)
+	}
+
+	outbound.OverrideReason = strings.TrimSpace(outbound.OverrideReason)
+	if outbound.StockOverride {
+		if outbound.OverrideReason == "" {
+			return models.Outbound{}, fmt.Errorf("%w: override_reason is required when overriding the stock check", ErrInvalidInput)
+		}
+		overrideBy := outbound.CreatedBy
+		outbound.OverrideBy = &overrideBy
+	} else {
+		outbound.OverrideReason = ""
+		outbound.OverrideBy = nil
+	}
+
+	createdOutbound, err := s.outboundRepo.CreateOutbound(outbound, s.numbering, func(product *models.Product) error {
+		if product.WarehouseID != outbound.WarehouseID {
+			return fmt.Errorf("%w: product does not belong to the selected warehouse", ErrInvalidInput)
+		}
+		err := checkAvailableStock(product, outbound.Quantity)
+		if err != nil && outbound.StockOverride {
+			log.Printf("[outbound] stock check overridden: user_id=%s sku=%s requested=%d available=%d reason=%q",
+				outbound.CreatedBy, product.SKU, outbound.Quantity, product.Stock-product.ReservedStock, outbound.OverrideReason)
+			return nil
+		}
+		return err
+	})
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Outbound{}, fmt.Errorf("%w: product not found", ErrNotFound)
+		}
 		return models.Outbound{}, err
 	}
 	return createdOutbound, nil
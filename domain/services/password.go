@@ -0,0 +1,49 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"unicode"
+)
+
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72 // batas input bcrypt
+)
+
+// validatePasswordStrength aturan minimal password:
+// 8-72 karakter, mengandung huruf besar, huruf kecil, dan angka
+func validatePasswordStrength(password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("%w: password must be at least %d characters", ErrInvalidInput, minPasswordLength)
+	}
+	if len(password) > maxPasswordLength {
+		return fmt.Errorf("%w: password must be at most %d characters", ErrInvalidInput, maxPasswordLength)
+	}
+
+	var hasUpper, hasLower, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasUpper || !hasLower || !hasDigit {
+		return fmt.Errorf("%w: password must contain uppercase, lowercase and numeric characters", ErrInvalidInput)
+	}
+	return nil
+}
+
+// generateSecureToken buat token acak (base64 url-safe) dari n byte
+func generateSecureToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -10,12 +10,12 @@ import (
 )
 
 type IProductService interface {
-	GetProducts(search, warehouseId, category string, page, limit int) ([]models.Product, int, error)
+	GetProducts(search, warehouseId, category string, page, limit int, scope models.WarehouseScope) ([]models.Product, int, error)
 	GetAllProducts() ([]models.Product, error)
-	GetProductByID(id string) (models.Product, error)
-	CreateProduct(product models.Product) (models.Product, error)
-	UpdateProduct(productId string, product models.Product) (models.Product, error)
-	DeleteProduct(productId string) error
+	GetProductByID(id string, scope models.WarehouseScope) (models.Product, error)
+	CreateProduct(product models.Product, scope models.WarehouseScope) (models.Product, error)
+	UpdateProduct(productId string, product models.Product, scope models.WarehouseScope) (models.Product, error)
+	DeleteProduct(productId string, scope models.WarehouseScope) error
 }
 
 type ProductService struct {
@@ -27,7 +27,7 @@ func NewProductService(productRepo repository.ProductRepository) *ProductService
 	return &ProductService{productRepo: productRepo}
 }
 
-func (s *ProductService) GetProducts(search, warehouseId, category string, page, limit int) ([]models.Product, int, error) {
+func (s *ProductService) GetProducts(search, warehouseId, category string, page, limit int, scope models.WarehouseScope) ([]models.Product, int, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -35,7 +35,7 @@ func (s *ProductService) GetProducts(search, warehouseId, category string, page,
 		limit = 10
 	}
 
-	products, total, err := s.productRepo.GetProducts(search, warehouseId, category, page, limit)
+	products, total, err := s.productRepo.GetProducts(search, warehouseId, category, page, limit, scope)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -43,14 +43,18 @@ func (s *ProductService) GetProducts(search, warehouseId, category string, page,
 }
 
 func (s *ProductService) GetAllProducts() ([]models.Product, error) {
-	products, _, err := s.productRepo.GetProducts("", "", "", 0, 0)
+	products, _, err := s.productRepo.GetProducts("", "", "", 0, 0, models.AllWarehouses())
 	if err != nil {
 		return nil, err
 	}
 	return products, nil
 }
 
-func (s *ProductService) CreateProduct(product models.Product) (models.Product, error) {
+func (s *ProductService) CreateProduct(product models.Product, scope models.WarehouseScope) (models.Product, error) {
+	if err := checkWarehouseAccess(scope, product.WarehouseID); err != nil {
+		return models.Product{}, err
+	}
+
 	existingProduct, err := s.productRepo.GetProductBySKU(product.SKU)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return models.Product{}, err
@@ -66,7 +70,17 @@ func (s *ProductService) CreateProduct(product models.Product) (models.Product,
 	return createdProduct, nil
 }
 
-func (s *ProductService) UpdateProduct(productId string, product models.Product) (models.Product, error) {
+func (s *ProductService) UpdateProduct(productId string, product models.Product, scope models.WarehouseScope) (models.Product, error) {
+	// pastikan produk lama dan gudang tujuan masih dalam scope user
+	if _, err := s.GetProductByID(productId, scope); err != nil {
+		return models.Product{}, err
+	}
+	if product.WarehouseID != uuid.Nil {
+		if err := checkWarehouseAccess(scope, product.WarehouseID); err != nil {
+			return models.Product{}, err
+		}
+	}
+
 	updatedProduct, err := s.productRepo.UpdateProduct(productId, product)
 	if err != nil {
 		return models.Product{}, err
@@ -74,14 +88,20 @@ func (s *ProductService) UpdateProduct(productId string, product models.Product)
 	return updatedProduct, nil
 }
 
-func (s *ProductService) DeleteProduct(productId string) error {
+func (s *ProductService) DeleteProduct(productId string, scope models.WarehouseScope) error {
+	if _, err := s.GetProductByID(productId, scope); err != nil {
+		return err
+	}
 	return s.productRepo.DeleteProduct(productId)
 }
 
-func (s *ProductService) GetProductByID(id string) (models.Product, error) {
+func (s *ProductService) GetProductByID(id string, scope models.WarehouseScope) (models.Product, error) {
 	product, err := s.productRepo.GetProductByID(id)
 	if err != nil {
 		return models.Product{}, err
 	}
+	if err := checkWarehouseAccess(scope, product.WarehouseID); err != nil {
+		return models.Product{}, err
+	}
 	return product, nil
 }
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"wms-be/domain/models"
+	"wms-be/domain/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPurchaseOrderNotFound     = fmt.Errorf("%w: purchase order not found", ErrNotFound)
+	ErrPurchaseOrderLineNotFound = fmt.Errorf("%w: purchase order line not found", ErrInvalidInput)
+	ErrPurchaseOrderOverReceipt  = fmt.Errorf("%w: received quantity exceeds purchase order tolerance", ErrInvalidInput)
+)
+
+// ReceiptToleranceInput toleransi penerimaan PO dalam persen, nil = default dari env
+type ReceiptToleranceInput struct {
+	Over  *float64
+	Under *float64
+}
+
+// OpenPurchaseOrderReport line PO yang masih menunggu barang beserta ringkasannya
+type OpenPurchaseOrderReport struct {
+	PurchaseOrders           int                                `json:"purchase_orders"`
+	OverdueLines             int                                `json:"overdue_lines"`
+	TotalOutstandingQuantity int                                `json:"total_outstanding_quantity"`
+	TotalOutstandingValue    float64                            `json:"total_outstanding_value"`
+	Lines                    []repository.OpenPurchaseOrderLine `json:"lines"`
+}
+
+type PurchaseOrderService interface {
+	CreatePurchaseOrder(order *models.PurchaseOrder, tolerance ReceiptToleranceInput, scope models.WarehouseScope) (*models.PurchaseOrder, error)
+	GetPurchaseOrders(page, limit int, filters map[string]interface{}, scope models.WarehouseScope) ([]models.PurchaseOrder, int64, error)
+	GetPurchaseOrderByID(id uuid.UUID, scope models.WarehouseScope) (*models.PurchaseOrder, error)
+	ClosePurchaseOrder(id, userID uuid.UUID, reason string, scope models.WarehouseScope) (*models.PurchaseOrder, error)
+	CancelPurchaseOrder(id, userID uuid.UUID, reason string, scope models.WarehouseScope) (*models.PurchaseOrder, error)
+	GetOpenPurchaseOrderReport(filters map[string]interface{}, scope models.WarehouseScope) (*OpenPurchaseOrderReport, error)
+}
+
+type purchaseOrderService struct {
+	purchaseOrderRepo repository.PurchaseOrderRepository
+	supplierRepo      repository.SupplierRepository
+	productRepo       repository.ProductRepository
+	numbering         repository.DocumentNumbering
+	overTolerance     float64 // PO_OVER_RECEIPT_TOLERANCE (persen, default 0)
+	underTolerance    float64 // PO_UNDER_RECEIPT_TOLERANCE (persen, default 0)
+}
+
+func NewPurchaseOrderService(purchaseOrderRepo repository.PurchaseOrderRepository, supplierRepo repository.SupplierRepository, productRepo repository.ProductRepository) PurchaseOrderService {
+	return &purchaseOrderService{
+		purchaseOrderRepo: purchaseOrderRepo,
+		supplierRepo:      supplierRepo,
+		productRepo:       productRepo,
+		numbering:         documentNumbering(repository.DocumentPurchaseOrder, "DOC_NUMBER_PURCHASE_ORDER_FORMAT", defaultPurchaseOrderNumberFormat),
+		overTolerance:     getEnvPercent("PO_OVER_RECEIPT_TOLERANCE", 0),
+		underTolerance:    getEnvPercent("PO_UNDER_RECEIPT_TOLERANCE", 0),
+	}
+}
+
+// getEnvPercent persen 0-100 dari env, default jika kosong / tidak valid
+func getEnvPercent(key string, defaultVal float64) float64 {
+	val, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || val < 0 || val > 100 {
+		return defaultVal
+	}
+	return val
+}
+
+func (s *purchaseOrderService) CreatePurchaseOrder(order *models.PurchaseOrder, tolerance ReceiptToleranceInput, scope models.WarehouseScope) (*models.PurchaseOrder, error) {
+	if err := checkWarehouseAccess(scope, order.WarehouseID); err != nil {
+		return nil, err
+	}
+	if len(order.Lines) == 0 {
+		return nil, fmt.Errorf("%w: purchase order must have at least one line", ErrInvalidInput)
+	}
+
+	supplier, err := s.supplierRepo.GetSupplierByID(order.SupplierID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: supplier not found", ErrInvalidInput)
+		}
+		return nil, err
+	}
+	if !supplier.IsActive {
+		return nil, ErrSupplierInactive
+	}
+
+	order.OverReceiptTolerance, order.UnderReceiptTolerance = s.overTolerance, s.underTolerance
+	if tolerance.Over != nil {
+		order.OverReceiptTolerance = *tolerance.Over
+	}
+	if tolerance.Under != nil {
+		order.UnderReceiptTolerance = *tolerance.Under
+	}
+	if order.OverReceiptTolerance < 0 || order.OverReceiptTolerance > 100 || order.UnderReceiptTolerance < 0 || order.UnderReceiptTolerance > 100 {
+		return nil, fmt.Errorf("%w: receipt tolerance must be between 0 and 100 percent", ErrInvalidInput)
+	}
+
+	if order.OrderDate.IsZero() {
+		order.OrderDate = time.Now()
+	}
+	if order.ExpectedDate == nil && supplier.LeadTimeDays > 0 {
+		expected := order.OrderDate.AddDate(0, 0, supplier.LeadTimeDays)
+		order.ExpectedDate = &expected
+	}
+	if order.ExpectedDate != nil && order.ExpectedDate.Before(order.OrderDate) {
+		return nil, fmt.Errorf("%w: expected_date must not be before order_date", ErrInvalidInput)
+	}
+
+	seen := map[uuid.UUID]bool{}
+	order.TotalAmount = 0
+	for i := range order.Lines {
+		line := &order.Lines[i]
+		if line.OrderedQuantity <= 0 {
+			return nil, fmt.Errorf("%w: ordered_quantity must be greater than 0", ErrInvalidInput)
+		}
+		if line.UnitCost < 0 {
+			return nil, fmt.Errorf("%w: unit_cost must not be negative", ErrInvalidInput)
+		}
+		if seen[line.ProductID] {
+			return nil, fmt.Errorf("%w: product %s appears more than once", ErrInvalidInput, line.ProductID)
+		}
+		seen[line.ProductID] = true
+
+		product, err := s.productRepo.GetProductByID(line.ProductID.String())
+		if err != nil || product.WarehouseID != order.WarehouseID {
+			return nil, fmt.Errorf("%w: product %s not found in warehouse", ErrInvalidInput, line.ProductID)
+		}
+
+		line.ReceivedQuantity = 0
+		line.TotalCost = line.UnitCost * float64(line.OrderedQuantity)
+		order.TotalAmount += line.TotalCost
+	}
+
+	order.Status = models.PurchaseOrderStatusOpen
+	order.ReferenceNumber = strings.TrimSpace(order.ReferenceNumber)
+
+	if err := s.purchaseOrderRepo.Create(order, s.numbering); err != nil {
+		return nil, err
+	}
+	return s.purchaseOrderRepo.GetByID(order.ID)
+}
+
+func (s *purchaseOrderService) GetPurchaseOrders(page, limit int, filters map[string]interface{}, scope models.WarehouseScope) ([]models.PurchaseOrder, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	return s.purchaseOrderRepo.GetAll(filters, page, limit, scope)
+}
+
+func (s *purchaseOrderService) GetPurchaseOrderByID(id uuid.UUID, scope models.WarehouseScope) (*models.PurchaseOrder, error) {
+	order, err := s.purchaseOrderRepo.GetByID(id)
+	if err != nil {
+		return nil, mapPurchaseOrderError(err)
+	}
+	if err := checkWarehouseAccess(scope, order.WarehouseID); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// ClosePurchaseOrder tutup PO sebelum semua barang datang (short close), alasan wajib
+// jika masih ada line yang belum lengkap
+func (s *purchaseOrderService) ClosePurchaseOrder(id, userID uuid.UUID, reason string, scope models.WarehouseScope) (*models.PurchaseOrder, error) {
+	reason = strings.TrimSpace(reason)
+	order, err := s.purchaseOrderRepo.Close(id, func(order *models.PurchaseOrder) error {
+		if err := checkWarehouseAccess(scope, order.WarehouseID); err != nil {
+			return err
+		}
+		if !order.IsReceivable() {
+			return fmt.Errorf("%w: purchase order in status %s cannot be closed", ErrConflict, order.Status)
+		}
+		if reason == "" && !purchaseOrderFulfilled(order) {
+			return fmt.Errorf("%w: reason is required when closing a purchase order with outstanding quantity", ErrInvalidInput)
+		}
+
+		now := time.Now()
+		order.Status = models.PurchaseOrderStatusClosed
+		order.ClosedBy = &userID
+		order.ClosedAt = &now
+		order.CloseReason = reason
+		return nil
+	})
+	if err != nil {
+		return nil, mapPurchaseOrderError(err)
+	}
+	return order, nil
+}
+
+// CancelPurchaseOrder hanya untuk PO yang belum menerima barang sama sekali
+func (s *purchaseOrderService) CancelPurchaseOrder(id, userID uuid.UUID, reason string, scope models.WarehouseScope) (*models.PurchaseOrder, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidInput)
+	}
+
+	order, err := s.purchaseOrderRepo.Cancel(id, func(order *models.PurchaseOrder) error {
+		if err := checkWarehouseAccess(scope, order.WarehouseID); err != nil {
+			return err
+		}
+		if order.Status != models.PurchaseOrderStatusOpen {
+			return fmt.Errorf("%w: purchase order in status %s cannot be cancelled", ErrConflict, order.Status)
+		}
+
+		now := time.Now()
+		order.Status = models.PurchaseOrderStatusCancelled
+		order.CancelledBy = &userID
+		order.CancelledAt = &now
+		order.CancelReason = reason
+		return nil
+	})
+	if err != nil {
+		return nil, mapPurchaseOrderError(err)
+	}
+	return order, nil
+}
+
+func (s *purchaseOrderService) GetOpenPurchaseOrderReport(filters map[string]interface{}, scope models.WarehouseScope) (*OpenPurchaseOrderReport, error) {
+	lines, err := s.purchaseOrderRepo.GetOpenLines(filters, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &OpenPurchaseOrderReport{Lines: lines}
+	if report.Lines == nil {
+		report.Lines = []repository.OpenPurchaseOrderLine{}
+	}
+	orders := map[uuid.UUID]bool{}
+	for _, line := range lines {
+		orders[line.PurchaseOrderID] = true
+		report.TotalOutstandingQuantity += line.OutstandingQuantity
+		report.TotalOutstandingValue += line.OutstandingValue
+		if line.DaysOverdue > 0 {
+			report.OverdueLines++
+		}
+	}
+	report.PurchaseOrders = len(orders)
+	return report, nil
+}
+
+// applyPurchaseOrderReceipt validasi penerimaan quantity product pada line PO yang sudah
+// dikunci: total diterima tidak boleh melebihi toleransi over-receipt. Status PO diperbarui,
+// PO tertutup otomatis jika semua line lengkap.
+func applyPurchaseOrderReceipt(order *models.PurchaseOrder, lineID, productID uuid.UUID, quantity int, userID uuid.UUID) (*models.PurchaseOrderLine, error) {
+	if !order.IsReceivable() {
+		return nil, fmt.Errorf("%w: purchase order %s is %s", ErrConflict, order.PONumber, order.Status)
+	}
+	if quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be greater than 0", ErrInvalidInput)
+	}
+
+	var line *models.PurchaseOrderLine
+	for i := range order.Lines {
+		if order.Lines[i].ID == lineID {
+			line = &order.Lines[i]
+			break
+		}
+	}
+	if line == nil {
+		return nil, ErrPurchaseOrderLineNotFound
+	}
+	if line.ProductID != productID {
+		return nil, fmt.Errorf("%w: product does not match purchase order line", ErrInvalidInput)
+	}
+
+	maxQuantity := line.MaxReceivableQuantity(order.OverReceiptTolerance)
+	if line.ReceivedQuantity+quantity > maxQuantity {
+		return nil, fmt.Errorf("%w: ordered %d, received %d, receiving %d, maximum %d (%.2f%% tolerance)",
+			ErrPurchaseOrderOverReceipt, line.OrderedQuantity, line.ReceivedQuantity, quantity, maxQuantity, order.OverReceiptTolerance)
+	}
+	line.ReceivedQuantity += quantity
+
+	if purchaseOrderFulfilled(order) {
+		now := time.Now()
+		order.Status = models.PurchaseOrderStatusClosed
+		order.ClosedBy = &userID
+		order.ClosedAt = &now
+	} else {
+		order.Status = models.PurchaseOrderStatusPartiallyReceived
+	}
+	return line, nil
+}
+
+// purchaseOrderFulfilled semua line sudah diterima dalam toleransi under-receipt
+func purchaseOrderFulfilled(order *models.PurchaseOrder) bool {
+	for i := range order.Lines {
+		if !order.Lines[i].IsFulfilled(order.UnderReceiptTolerance) {
+			return false
+		}
+	}
+	return true
+}
+
+func mapPurchaseOrderError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPurchaseOrderNotFound
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"wms-be/domain/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrInsufficientStock dikembalikan jika quantity melebihi available_stock (stock - reserved_stock)
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// InsufficientStockError detail stok saat permintaan ditolak, dikirim ke client apa adanya
+type InsufficientStockError struct {
+	ProductID uuid.UUID `json:"product_id"`
+	SKU       string    `json:"sku"`
+	Requested int       `json:"requested"`
+	Available int       `json:"available"`
+	Stock     int       `json:"stock"`
+	Reserved  int       `json:"reserved"`
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for product %s: requested %d, available %d", e.SKU, e.Requested, e.Available)
+}
+
+func (e *InsufficientStockError) Unwrap() error {
+	return ErrInsufficientStock
+}
+
+// checkAvailableStock cek quantity terhadap stok product yang sudah dikunci (FOR UPDATE)
+func checkAvailableStock(product *models.Product, quantity int) error {
+	available := product.Stock - product.ReservedStock
+	if quantity > available {
+		return &InsufficientStockError{
+			ProductID: product.ID,
+			SKU:       product.SKU,
+			Requested: quantity,
+			Available: available,
+			Stock:     product.Stock,
+			Reserved:  product.ReservedStock,
+		}
+	}
+	return nil
+}
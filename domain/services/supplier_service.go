@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"wms-be/domain/models"
+	"wms-be/domain/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSupplierNotFound     = fmt.Errorf("%w: supplier not found", ErrNotFound)
+	ErrSupplierCodeExists   = fmt.Errorf("%w: supplier code already exists", ErrConflict)
+	ErrSupplierHasInbounds  = fmt.Errorf("%w: supplier has inbounds or purchase orders, deactivate or merge it instead", ErrConflict)
+	ErrSupplierInactive     = fmt.Errorf("%w: supplier is inactive", ErrInvalidInput)
+	ErrSupplierMergeToSelf  = fmt.Errorf("%w: cannot merge a supplier into itself", ErrInvalidInput)
+	ErrSupplierMergeMissing = fmt.Errorf("%w: supplier_ids is required", ErrInvalidInput)
+)
+
+// SupplierMergeResult hasil penggabungan supplier duplikat
+type SupplierMergeResult struct {
+	Supplier      *models.Supplier `json:"supplier"`
+	MergedCount   int              `json:"merged_count"`
+	MovedInbounds int64            `json:"moved_inbounds"`
+}
+
+type SupplierService interface {
+	CreateSupplier(supplier *models.Supplier) (*models.Supplier, error)
+	GetSuppliers(page, limit int, filters map[string]interface{}) ([]models.Supplier, int64, error)
+	GetSupplierByID(id uuid.UUID) (*models.Supplier, error)
+	UpdateSupplier(supplier *models.Supplier) (*models.Supplier, error)
+	DeleteSupplier(id uuid.UUID) error
+	MergeSuppliers(targetID uuid.UUID, sourceIDs []uuid.UUID) (*SupplierMergeResult, error)
+	GetPerformance(filters map[string]interface{}, scope models.WarehouseScope) ([]repository.SupplierPerformance, error)
+}
+
+type supplierService struct {
+	supplierRepo repository.SupplierRepository
+}
+
+func NewSupplierService(supplierRepo repository.SupplierRepository) SupplierService {
+	return &supplierService{supplierRepo: supplierRepo}
+}
+
+func (s *supplierService) CreateSupplier(supplier *models.Supplier) (*models.Supplier, error) {
+	if err := validateSupplier(supplier); err != nil {
+		return nil, err
+	}
+	if err := s.supplierRepo.CreateSupplier(supplier); err != nil {
+		return nil, mapSupplierError(err)
+	}
+	return s.GetSupplierByID(supplier.ID)
+}
+
+func (s *supplierService) GetSuppliers(page, limit int, filters map[string]interface{}) ([]models.Supplier, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	return s.supplierRepo.GetSuppliers(filters, page, limit)
+}
+
+func (s *supplierService) GetSupplierByID(id uuid.UUID) (*models.Supplier, error) {
+	supplier, err := s.supplierRepo.GetSupplierByID(id)
+	if err != nil {
+		return nil, mapSupplierError(err)
+	}
+	return supplier, nil
+}
+
+func (s *supplierService) UpdateSupplier(supplier *models.Supplier) (*models.Supplier, error) {
+	if err := validateSupplier(supplier); err != nil {
+		return nil, err
+	}
+	if err := s.supplierRepo.UpdateSupplier(supplier); err != nil {
+		return nil, mapSupplierError(err)
+	}
+	return s.GetSupplierByID(supplier.ID)
+}
+
+func (s *supplierService) DeleteSupplier(id uuid.UUID) error {
+	return mapSupplierError(s.supplierRepo.DeleteSupplier(id))
+}
+
+// MergeSuppliers gabungkan supplier duplikat ke targetID; inbound dan PO supplier sumber
+// dipindah ke target (snapshot supplier_name di inbound tidak diubah)
+func (s *supplierService) MergeSuppliers(targetID uuid.UUID, sourceIDs []uuid.UUID) (*SupplierMergeResult, error) {
+	seen := map[uuid.UUID]bool{}
+	sources := make([]uuid.UUID, 0, len(sourceIDs))
+	for _, id := range sourceIDs {
+		if id == targetID {
+			return nil, ErrSupplierMergeToSelf
+		}
+		if !seen[id] {
+			seen[id] = true
+			sources = append(sources, id)
+		}
+	}
+	if len(sources) == 0 {
+		return nil, ErrSupplierMergeMissing
+	}
+
+	moved, err := s.supplierRepo.MergeSuppliers(targetID, sources)
+	if err != nil {
+		return nil, mapSupplierError(err)
+	}
+	supplier, err := s.GetSupplierByID(targetID)
+	if err != nil {
+		return nil, err
+	}
+	return &SupplierMergeResult{Supplier: supplier, MergedCount: len(sources), MovedInbounds: moved}, nil
+}
+
+// GetPerformance laporan performa supplier, on_time_rate dalam persen dari pengiriman yang
+// punya expected_date
+func (s *supplierService) GetPerformance(filters map[string]interface{}, scope models.WarehouseScope) ([]repository.SupplierPerformance, error) {
+	rows, err := s.supplierRepo.GetPerformance(filters, scope)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		if rows[i].ScheduledReceipts > 0 {
+			rate := float64(rows[i].OnTimeReceipts) * 100 / float64(rows[i].ScheduledReceipts)
+			rows[i].OnTimeRate = &rate
+		}
+	}
+	return rows, nil
+}
+
+func validateSupplier(supplier *models.Supplier) error {
+	supplier.Code = strings.TrimSpace(supplier.Code)
+	supplier.Name = strings.TrimSpace(supplier.Name)
+	if supplier.Code == "" || supplier.Name == "" {
+		return fmt.Errorf("%w: code and name are required", ErrInvalidInput)
+	}
+	if supplier.LeadTimeDays < 0 {
+		return fmt.Errorf("%w: lead_time_days must not be negative", ErrInvalidInput)
+	}
+	return nil
+}
+
+func mapSupplierError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrSupplierNotFound
+	case sqlState(err) == sqlStateUniqueViolation:
+		return ErrSupplierCodeExists
+	case sqlState(err) == sqlStateForeignKeyViolation:
+		return ErrSupplierHasInbounds
+	}
+	return err
+}
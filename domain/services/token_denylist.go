@@ -0,0 +1,222 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+	"wms-be/domain/repository"
+	"wms-be/infrastructure/jwt"
+
+	"github.com/google/uuid"
+)
+
+// TokenDenylist mencabut access token sebelum exp.
+// Dicek oleh AuthMiddleware di setiap request.
+type TokenDenylist interface {
+	IsRevoked(claims *jwt.TokenClaims) (bool, error)
+	RevokeToken(claims *jwt.TokenClaims) error
+	RevokeSession(userID, sessionID uuid.UUID, expiresAt time.Time) error
+	RevokeAllForUser(userID uuid.UUID) error
+}
+
+type cachedRevocation struct {
+	revoked  bool
+	cachedAt time.Time
+}
+
+type cachedCutoff struct {
+	cutoff   *time.Time
+	cachedAt time.Time
+}
+
+type tokenDenylist struct {
+	repo     repository.RevokedAccessTokenRepository
+	cacheTTL time.Duration
+
+	mu       sync.Mutex
+	tokens   map[string]cachedRevocation
+	sessions map[string]cachedRevocation
+	cutoffs  map[string]cachedCutoff
+}
+
+// NewTokenDenylist membuat denylist berbasis Postgres.
+// TOKEN_DENYLIST_CACHE_TTL (detik, default 0 = tanpa cache) mengaktifkan cache in-process;
+// pencabutan dari instance lain baru terlihat setelah TTL habis.
+func NewTokenDenylist(repo repository.RevokedAccessTokenRepository) TokenDenylist {
+	return &tokenDenylist{
+		repo:     repo,
+		cacheTTL: getEnvDuration("TOKEN_DENYLIST_CACHE_TTL", 0),
+		tokens:   make(map[string]cachedRevocation),
+		sessions: make(map[string]cachedRevocation),
+		cutoffs:  make(map[string]cachedCutoff),
+	}
+}
+
+func (d *tokenDenylist) fresh(cachedAt time.Time) bool {
+	return d.cacheTTL > 0 && time.Since(cachedAt) < d.cacheTTL
+}
+
+// IsRevoked cek jti dan sesi (sid) di denylist serta batas pencabutan milik user
+func (d *tokenDenylist) IsRevoked(claims *jwt.TokenClaims) (bool, error) {
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return true, nil
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return true, nil
+	}
+
+	revoked, err := d.isTokenRevoked(jti)
+	if err != nil || revoked {
+		return revoked, err
+	}
+
+	if claims.SessionID != "" {
+		sessionID, err := uuid.Parse(claims.SessionID)
+		if err != nil {
+			return true, nil
+		}
+		revoked, err := d.isSessionRevoked(sessionID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	cutoff, err := d.userCutoff(userID)
+	if err != nil || cutoff == nil {
+		return false, err
+	}
+
+	// token yang terbit pada/sebelum cutoff ditolak. iat presisi milidetik (iat_ms), cutoff
+	// dibulatkan ke milidetik sehingga login ulang setelah pencabutan tetap berlaku
+	return !claims.IssuedAt.After(*cutoff), nil
+}
+
+func (d *tokenDenylist) isTokenRevoked(jti uuid.UUID) (bool, error) {
+	key := jti.String()
+
+	d.mu.Lock()
+	entry, ok := d.tokens[key]
+	d.mu.Unlock()
+	if ok && d.fresh(entry.cachedAt) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := d.repo.IsRevoked(jti)
+	if err != nil {
+		return false, err
+	}
+
+	if d.cacheTTL > 0 {
+		d.mu.Lock()
+		d.tokens[key] = cachedRevocation{revoked: revoked, cachedAt: time.Now()}
+		d.mu.Unlock()
+	}
+	return revoked, nil
+}
+
+func (d *tokenDenylist) isSessionRevoked(sessionID uuid.UUID) (bool, error) {
+	key := sessionID.String()
+
+	d.mu.Lock()
+	entry, ok := d.sessions[key]
+	d.mu.Unlock()
+	if ok && d.fresh(entry.cachedAt) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := d.repo.IsSessionRevoked(sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	if d.cacheTTL > 0 {
+		d.mu.Lock()
+		d.sessions[key] = cachedRevocation{revoked: revoked, cachedAt: time.Now()}
+		d.mu.Unlock()
+	}
+	return revoked, nil
+}
+
+func (d *tokenDenylist) userCutoff(userID uuid.UUID) (*time.Time, error) {
+	key := userID.String()
+
+	d.mu.Lock()
+	entry, ok := d.cutoffs[key]
+	d.mu.Unlock()
+	if ok && d.fresh(entry.cachedAt) {
+		return entry.cutoff, nil
+	}
+
+	cutoff, err := d.repo.GetUserCutoff(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.cacheTTL > 0 {
+		d.mu.Lock()
+		d.cutoffs[key] = cachedCutoff{cutoff: cutoff, cachedAt: time.Now()}
+		d.mu.Unlock()
+	}
+	return cutoff, nil
+}
+
+// RevokeToken cabut satu access token (logout)
+func (d *tokenDenylist) RevokeToken(claims *jwt.TokenClaims) error {
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return err
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return err
+	}
+
+	if err := d.repo.Revoke(jti, userID, claims.ExpiresAt); err != nil {
+		return err
+	}
+
+	if d.cacheTTL > 0 {
+		d.mu.Lock()
+		d.tokens[jti.String()] = cachedRevocation{revoked: true, cachedAt: time.Now()}
+		d.mu.Unlock()
+	}
+
+	// bersihkan entry yang token-nya sudah expired, tidak perlu menggagalkan logout
+	if err := d.repo.DeleteExpired(); err != nil {
+		log.Printf("[auth] failed to clean expired denylist entries: %v", err)
+	}
+	return nil
+}
+
+// RevokeSession cabut semua access token satu sesi (sign out perangkat).
+// expiresAt batas exp access token terakhir yang mungkin terbit di sesi tersebut.
+func (d *tokenDenylist) RevokeSession(userID, sessionID uuid.UUID, expiresAt time.Time) error {
+	if err := d.repo.RevokeSession(sessionID, userID, expiresAt); err != nil {
+		return err
+	}
+
+	if d.cacheTTL > 0 {
+		d.mu.Lock()
+		d.sessions[sessionID.String()] = cachedRevocation{revoked: true, cachedAt: time.Now()}
+		d.mu.Unlock()
+	}
+	return nil
+}
+
+// RevokeAllForUser cabut semua access token user yang sudah terbit (nonaktif / ganti password)
+func (d *tokenDenylist) RevokeAllForUser(userID uuid.UUID) error {
+	now := time.Now()
+	cutoff := now.Truncate(time.Millisecond)
+	if err := d.repo.RevokeAllForUser(userID, cutoff); err != nil {
+		return err
+	}
+
+	if d.cacheTTL > 0 {
+		d.mu.Lock()
+		d.cutoffs[userID.String()] = cachedCutoff{cutoff: &cutoff, cachedAt: now}
+		d.mu.Unlock()
+	}
+	return nil
+}
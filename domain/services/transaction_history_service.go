@@ -23,6 +23,7 @@ func (s *TransactionHistoryService) GetTransactions(
 	dateTo string,
 	page int,
 	limit int,
+	scope models.WarehouseScope,
 ) ([]models.TransactionHistory, int64, error) {
 
 	var transactions []models.TransactionHistory
@@ -30,6 +31,16 @@ func (s *TransactionHistoryService) GetTransactions(
 
 	query := s.db.Model(&models.TransactionHistory{})
 
+	// Batasi ke gudang dalam scope (asal maupun tujuan transfer)
+	if !scope.All {
+		ids := scope.IDStrings()
+		if len(ids) == 0 {
+			query = query.Where("1 = 0")
+		} else {
+			query = query.Where("warehouse_id IN ? OR to_warehouse_id IN ?", ids, ids)
+		}
+	}
+
 	// Apply filters
 	if txType != "" {
 		query = query.Where("type = ?", txType)
@@ -1,52 +1,113 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"wms-be/domain/models"
 	"wms-be/domain/repository"
-	"wms-be/infrastructure/database"
+
+	"gorm.io/gorm"
+)
+
+// Error transfer stok, dipetakan dari SQLSTATE yang di-raise transfer_stock (migration 016)
+var (
+	ErrTransferSameWarehouse     = fmt.Errorf("%w: source and destination warehouse must be different", ErrInvalidInput)
+	ErrTransferProductNotFound   = fmt.Errorf("%w: product not found in source warehouse", ErrNotFound)
+	ErrTransferWarehouseNotFound = fmt.Errorf("%w: warehouse not found", ErrNotFound)
+	ErrWarehouseCapacityExceeded = fmt.Errorf("%w: destination warehouse does not have enough capacity", ErrConflict)
+	errTransferInsufficientStock = fmt.Errorf("%w: not enough available stock in source warehouse", ErrInsufficientStock)
+	errTransferInvalidParameter  = fmt.Errorf("%w: invalid transfer parameters", ErrInvalidInput)
+)
+
+// SQLSTATE yang di-raise oleh public.transfer_stock
+const (
+	sqlStateTransferInsufficientStock = "WT001"
+	sqlStateTransferCapacityExceeded  = "WT002"
+	sqlStateTransferWarehouseNotFound = "WT003"
+	sqlStateTransferProductNotFound   = "WT004"
+	sqlStateInvalidParameterValue     = "22023"
 )
 
 type TransactionService interface {
-	CreateTransaction(transaction *models.Transaction) (*models.Transaction, error)
+	CreateTransaction(transaction *models.Transaction, scope models.WarehouseScope) (*models.Transaction, error)
+	CreateTransfer(transaction *models.Transaction, scope models.WarehouseScope) (*models.Transaction, error)
 }
 
 type transactionService struct {
-	repo repository.TransactionRepository
+	repo      repository.TransactionRepository
+	numbering repository.DocumentNumbering
 }
 
 func NewTransactionService(repo repository.TransactionRepository) TransactionService {
 	return &transactionService{
-		repo: repo,
+		repo:      repo,
+		numbering: documentNumbering(repository.DocumentTransfer, "DOC_NUMBER_TRANSFER_FORMAT", defaultTransferNumberFormat),
 	}
 }
 
-func (s *transactionService) CreateTransaction(transaction *models.Transaction) (*models.Transaction, error) {
+func (s *transactionService) CreateTransaction(transaction *models.Transaction, scope models.WarehouseScope) (*models.Transaction, error) {
+	// transfer selalu lewat jalur atomik (ledger + perpindahan stok satu transaksi DB)
+	if transaction.Type == models.Transfer {
+		return s.CreateTransfer(transaction, scope)
+	}
+
+	// gudang asal harus dalam scope user, gudang tujuan boleh gudang lain
+	if err := checkWarehouseAccess(scope, transaction.WarehouseID); err != nil {
+		return nil, err
+	}
+
 	createdTransaction, err := s.repo.CreateTransaction(transaction)
 	if err != nil {
 		return nil, err
 	}
-	if transaction.Type == "transfer" {
-		err := s.transferStock(transaction)
-		if err != nil {
-			return nil, fmt.Errorf("failed to execute transfer stock procedure: %v", err)
-		}
-	}
-
 	return createdTransaction, nil
 }
 
-func (s *transactionService) transferStock(transaction *models.Transaction) error {
-	db := database.GetDB()
+// CreateTransfer pindahkan stok antar gudang. Baris ledger hanya tersimpan jika
+// perpindahan stok berhasil.
+func (s *transactionService) CreateTransfer(transaction *models.Transaction, scope models.WarehouseScope) (*models.Transaction, error) {
+	transaction.Type = models.Transfer
 
-	err := db.Exec(`
-    SELECT public.transfer_stock(?, ?, ?, ?, ?, ?, ?);
-`, transaction.ProductID, transaction.WarehouseID, transaction.ToWarehouseID, transaction.Quantity,
-		transaction.ReferenceNumber, transaction.Notes, transaction.CreatedBy).Error
+	// gudang asal harus dalam scope user, gudang tujuan boleh gudang lain
+	if err := checkWarehouseAccess(scope, transaction.WarehouseID); err != nil {
+		return nil, err
+	}
+	if transaction.ToWarehouseID == nil {
+		return nil, fmt.Errorf("%w: to_warehouse_id is required", ErrInvalidInput)
+	}
+	if *transaction.ToWarehouseID == transaction.WarehouseID {
+		return nil, ErrTransferSameWarehouse
+	}
+	if transaction.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be greater than 0", ErrInvalidInput)
+	}
 
+	createdTransaction, err := s.repo.CreateTransfer(transaction, s.numbering, func(product *models.Product) error {
+		return checkAvailableStock(product, transaction.Quantity)
+	})
 	if err != nil {
-		return fmt.Errorf("error executing transfer stock procedure: %v", err)
+		return nil, mapTransferError(err)
 	}
+	return createdTransaction, nil
+}
 
-	return nil
+// mapTransferError ubah error DB dari transfer menjadi error bertipe
+func mapTransferError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTransferProductNotFound
+	}
+
+	switch sqlState(err) {
+	case sqlStateTransferInsufficientStock:
+		return errTransferInsufficientStock
+	case sqlStateTransferCapacityExceeded:
+		return ErrWarehouseCapacityExceeded
+	case sqlStateTransferWarehouseNotFound:
+		return ErrTransferWarehouseNotFound
+	case sqlStateTransferProductNotFound:
+		return ErrTransferProductNotFound
+	case sqlStateInvalidParameterValue:
+		return errTransferInvalidParameter
+	}
+	return err
 }
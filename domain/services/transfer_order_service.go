@@ -0,0 +1,280 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"wms-be/domain/models"
+	"wms-be/domain/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrTransferOrderNotFound = fmt.Errorf("%w: transfer order not found", ErrNotFound)
+
+// ReceiveTransferInput satu penerimaan di gudang tujuan. Close menandai penerimaan terakhir:
+// sisa yang belum datang dicatat sebagai selisih (discrepancy) dan transfer selesai.
+type ReceiveTransferInput struct {
+	Quantity         int
+	Notes            string
+	Close            bool
+	DiscrepancyNotes string
+}
+
+type TransferOrderService interface {
+	CreateTransferOrder(order *models.TransferOrder, scope models.WarehouseScope) (*models.TransferOrder, error)
+	GetTransferOrders(page, limit int, filters map[string]interface{}, scope models.WarehouseScope) ([]models.TransferOrder, int64, error)
+	GetTransferOrderByID(id uuid.UUID, scope models.WarehouseScope) (*models.TransferOrder, error)
+	ApproveTransferOrder(id, userID uuid.UUID, scope models.WarehouseScope) (*models.TransferOrder, error)
+	DispatchTransferOrder(id, userID uuid.UUID, scope models.WarehouseScope) (*models.TransferOrder, error)
+	MarkTransferOrderInTransit(id, userID uuid.UUID, scope models.WarehouseScope) (*models.TransferOrder, error)
+	ReceiveTransferOrder(id, userID uuid.UUID, input ReceiveTransferInput, scope models.WarehouseScope) (*models.TransferOrder, error)
+	CancelTransferOrder(id, userID uuid.UUID, reason string, scope models.WarehouseScope) (*models.TransferOrder, error)
+}
+
+type transferOrderService struct {
+	transferOrderRepo repository.TransferOrderRepository
+	productRepo       repository.ProductRepository
+	warehouseRepo     repository.WarehouseRepository
+	numbering         repository.DocumentNumbering
+}
+
+func NewTransferOrderService(transferOrderRepo repository.TransferOrderRepository, productRepo repository.ProductRepository, warehouseRepo repository.WarehouseRepository) TransferOrderService {
+	return &transferOrderService{
+		transferOrderRepo: transferOrderRepo,
+		productRepo:       productRepo,
+		warehouseRepo:     warehouseRepo,
+		numbering:         documentNumbering(repository.DocumentTransfer, "DOC_NUMBER_TRANSFER_FORMAT", defaultTransferNumberFormat),
+	}
+}
+
+func (s *transferOrderService) CreateTransferOrder(order *models.TransferOrder, scope models.WarehouseScope) (*models.TransferOrder, error) {
+	// gudang asal harus dalam scope user, gudang tujuan boleh gudang lain
+	if err := checkWarehouseAccess(scope, order.FromWarehouseID); err != nil {
+		return nil, err
+	}
+	if order.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be greater than 0", ErrInvalidInput)
+	}
+	if order.FromWarehouseID == order.ToWarehouseID {
+		return nil, ErrTransferSameWarehouse
+	}
+
+	product, err := s.productRepo.GetProductByID(order.ProductID.String())
+	if err != nil || product.WarehouseID != order.FromWarehouseID {
+		return nil, ErrTransferProductNotFound
+	}
+	if _, err := s.warehouseRepo.GetWarehouseByID(order.ToWarehouseID); err != nil {
+		return nil, ErrTransferWarehouseNotFound
+	}
+
+	order.Status = models.TransferStatusRequested
+	order.RequestedAt = time.Now()
+
+	if err := s.transferOrderRepo.Create(order, s.numbering); err != nil {
+		return nil, err
+	}
+	return s.transferOrderRepo.GetByID(order.ID)
+}
+
+func (s *transferOrderService) GetTransferOrders(page, limit int, filters map[string]interface{}, scope models.WarehouseScope) ([]models.TransferOrder, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	return s.transferOrderRepo.GetAll(filters, page, limit, scope)
+}
+
+// GetTransferOrderByID boleh dilihat dari gudang asal maupun tujuan
+func (s *transferOrderService) GetTransferOrderByID(id uuid.UUID, scope models.WarehouseScope) (*models.TransferOrder, error) {
+	order, err := s.transferOrderRepo.GetByID(id)
+	if err != nil {
+		return nil, mapTransferOrderError(err)
+	}
+	if !scope.Allows(order.FromWarehouseID) && !scope.Allows(order.ToWarehouseID) {
+		return nil, ErrWarehouseAccessDenied
+	}
+	return order, nil
+}
+
+// checkTransferTransition validasi status & scope pada baris transfer order yang sudah dikunci
+func checkTransferTransition(order *models.TransferOrder, to string, scope models.WarehouseScope, warehouseID uuid.UUID) error {
+	if err := checkWarehouseAccess(scope, warehouseID); err != nil {
+		return err
+	}
+	if !models.CanTransferOrderTransition(order.Status, to) {
+		return fmt.Errorf("%w: cannot change transfer order from %s to %s", ErrConflict, order.Status, to)
+	}
+	return nil
+}
+
+// ApproveTransferOrder reservasi stok di gudang asal
+func (s *transferOrderService) ApproveTransferOrder(id, userID uuid.UUID, scope models.WarehouseScope) (*models.TransferOrder, error) {
+	order, err := s.transferOrderRepo.Approve(id, func(order *models.TransferOrder, product *models.Product) error {
+		if err := checkTransferTransition(order, models.TransferStatusApproved, scope, order.FromWarehouseID); err != nil {
+			return err
+		}
+		if err := checkAvailableStock(product, order.Quantity); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		order.Status = models.TransferStatusApproved
+		order.ApprovedBy = &userID
+		order.ApprovedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, mapTransferOrderError(err)
+	}
+	return order, nil
+}
+
+// DispatchTransferOrder stok keluar dari gudang asal dan menjadi in transit
+func (s *transferOrderService) DispatchTransferOrder(id, userID uuid.UUID, scope models.WarehouseScope) (*models.TransferOrder, error) {
+	order, err := s.transferOrderRepo.Dispatch(id, func(order *models.TransferOrder, product *models.Product) error {
+		if err := checkTransferTransition(order, models.TransferStatusDispatched, scope, order.FromWarehouseID); err != nil {
+			return err
+		}
+		// stok sudah direservasi saat approve, cukup pastikan stok fisik masih ada
+		// (bisa berkurang lewat outbound dengan override)
+		if product.Stock < order.Quantity {
+			return &InsufficientStockError{
+				ProductID: product.ID,
+				SKU:       product.SKU,
+				Requested: order.Quantity,
+				Available: product.Stock,
+				Stock:     product.Stock,
+				Reserved:  product.ReservedStock,
+			}
+		}
+
+		now := time.Now()
+		order.Status = models.TransferStatusDispatched
+		order.DispatchedBy = &userID
+		order.DispatchedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, mapTransferOrderError(err)
+	}
+	return order, nil
+}
+
+// MarkTransferOrderInTransit konfirmasi barang sudah di perjalanan (diambil kurir)
+func (s *transferOrderService) MarkTransferOrderInTransit(id, userID uuid.UUID, scope models.WarehouseScope) (*models.TransferOrder, error) {
+	order, err := s.transferOrderRepo.MarkInTransit(id, func(order *models.TransferOrder) error {
+		if order.Status != models.TransferStatusDispatched {
+			return fmt.Errorf("%w: cannot change transfer order from %s to %s", ErrConflict, order.Status, models.TransferStatusInTransit)
+		}
+		if err := checkWarehouseAccess(scope, order.FromWarehouseID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		order.Status = models.TransferStatusInTransit
+		order.InTransitBy = &userID
+		order.InTransitAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, mapTransferOrderError(err)
+	}
+	return order, nil
+}
+
+// ReceiveTransferOrder terima barang (parsial) di gudang tujuan. Transfer selesai jika semua
+// quantity sudah diterima, atau input.Close dengan sisa dicatat sebagai selisih.
+func (s *transferOrderService) ReceiveTransferOrder(id, userID uuid.UUID, input ReceiveTransferInput, scope models.WarehouseScope) (*models.TransferOrder, error) {
+	if input.Quantity < 0 {
+		return nil, fmt.Errorf("%w: quantity must not be negative", ErrInvalidInput)
+	}
+	if input.Quantity == 0 && !input.Close {
+		return nil, fmt.Errorf("%w: quantity must be greater than 0", ErrInvalidInput)
+	}
+
+	now := time.Now()
+	receipt := &models.TransferOrderReceipt{
+		Quantity:   input.Quantity,
+		Notes:      strings.TrimSpace(input.Notes),
+		ReceivedBy: userID,
+		ReceivedAt: now,
+	}
+
+	order, err := s.transferOrderRepo.Receive(id, receipt, func(order *models.TransferOrder, warehouse *models.Warehouse) error {
+		if err := checkWarehouseAccess(scope, order.ToWarehouseID); err != nil {
+			return err
+		}
+		if order.Status != models.TransferStatusDispatched && order.Status != models.TransferStatusInTransit {
+			return fmt.Errorf("%w: transfer order in status %s cannot be received", ErrConflict, order.Status)
+		}
+
+		outstanding := order.OutstandingQuantity()
+		if input.Quantity > outstanding {
+			return fmt.Errorf("%w: received quantity %d exceeds outstanding quantity %d", ErrInvalidInput, input.Quantity, outstanding)
+		}
+		if warehouse.CurrentUtilization+input.Quantity > warehouse.Capacity {
+			return ErrWarehouseCapacityExceeded
+		}
+
+		order.QuantityReceived += input.Quantity
+		remaining := order.OutstandingQuantity()
+		if remaining > 0 && !input.Close {
+			// penerimaan parsial, sisanya masih dalam perjalanan
+			order.Status = models.TransferStatusInTransit
+			return nil
+		}
+
+		if remaining > 0 {
+			if strings.TrimSpace(input.DiscrepancyNotes) == "" {
+				return fmt.Errorf("%w: discrepancy_notes is required when closing a transfer with missing quantity", ErrInvalidInput)
+			}
+			order.DiscrepancyQuantity = remaining
+			order.DiscrepancyNotes = strings.TrimSpace(input.DiscrepancyNotes)
+		}
+		order.Status = models.TransferStatusReceived
+		order.ReceivedBy = &userID
+		order.ReceivedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, mapTransferOrderError(err)
+	}
+	return order, nil
+}
+
+// CancelTransferOrder hanya sebelum dispatch; reservasi stok dilepas oleh repository
+func (s *transferOrderService) CancelTransferOrder(id, userID uuid.UUID, reason string, scope models.WarehouseScope) (*models.TransferOrder, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidInput)
+	}
+
+	order, err := s.transferOrderRepo.Cancel(id, func(order *models.TransferOrder) error {
+		if err := checkTransferTransition(order, models.TransferStatusCancelled, scope, order.FromWarehouseID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		order.Status = models.TransferStatusCancelled
+		order.CancelledBy = &userID
+		order.CancelledAt = &now
+		order.CancelReason = reason
+		return nil
+	})
+	if err != nil {
+		return nil, mapTransferOrderError(err)
+	}
+	return order, nil
+}
+
+func mapTransferOrderError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTransferOrderNotFound
+	}
+	return err
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"wms-be/domain/models"
+	"wms-be/infrastructure/jwt"
+	"wms-be/infrastructure/totp"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidChallenge        = errors.New("two-factor challenge is invalid or expired, please login again")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorAlreadyEnabled = fmt.Errorf("%w: two-factor authentication is already enabled", ErrConflict)
+	ErrTwoFactorNotEnabled     = fmt.Errorf("%w: two-factor authentication is not enabled", ErrInvalidInput)
+	ErrTwoFactorNotEnrolling   = fmt.Errorf("%w: start two-factor enrollment first", ErrInvalidInput)
+	ErrTwoFactorRequired       = fmt.Errorf("%w: two-factor authentication is required for this account", ErrInvalidInput)
+)
+
+const recoveryCodeCount = 10
+
+// LoginResult hasil login. Jika ChallengeToken terisi, user harus menyelesaikan langkah 2FA
+// (ChallengeType jwt.TokenTypeTwoFactor = verifikasi kode, jwt.TokenTypeTwoFactorEnroll = wajib enrolment dulu).
+type LoginResult struct {
+	User               *models.User
+	AccessToken        string
+	RefreshToken       string
+	ChallengeToken     string
+	ChallengeType      string
+	ChallengeExpiresIn int64
+}
+
+// TwoFactorSetup secret dan URI QR code untuk aplikasi authenticator
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TwoFactorStatus status 2FA milik user
+type TwoFactorStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// twoFactorPolicy konfigurasi 2FA dari .env
+type twoFactorPolicy struct {
+	requireForAdmin bool          // AUTH_REQUIRE_2FA_FOR_ADMIN
+	challengeTTL    time.Duration // AUTH_2FA_CHALLENGE_EXPIRE (detik)
+	issuer          string        // TOTP_ISSUER, nama yang tampil di aplikasi authenticator
+}
+
+func newTwoFactorPolicy() twoFactorPolicy {
+	requireForAdmin, _ := strconv.ParseBool(os.Getenv("AUTH_REQUIRE_2FA_FOR_ADMIN"))
+
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "WMS"
+	}
+
+	return twoFactorPolicy{
+		requireForAdmin: requireForAdmin,
+		challengeTTL:    getEnvDuration("AUTH_2FA_CHALLENGE_EXPIRE", 300),
+		issuer:          issuer,
+	}
+}
+
+func (s *authService) twoFactorRequired(user *models.User) bool {
+	return s.twoFactorPolicy.requireForAdmin && user.Role == models.RoleAdmin
+}
+
+// issueChallenge terbitkan challenge token berumur pendek untuk langkah kedua login
+func (s *authService) issueChallenge(user *models.User, challengeType string) (*LoginResult, error) {
+	ttl := s.twoFactorPolicy.challengeTTL
+
+	token, err := jwt.GenerateChallengeToken(user.ID.String(), challengeType, time.Now().Add(ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{
+		User:               user,
+		ChallengeToken:     token,
+		ChallengeType:      challengeType,
+		ChallengeExpiresIn: int64(ttl.Seconds()),
+	}, nil
+}
+
+// userFromChallenge validasi challenge token dan ambil user aktif pemiliknya
+func (s *authService) userFromChallenge(challengeToken, challengeType string) (*models.User, error) {
+	claims, err := jwt.ValidateChallengeToken(challengeToken, challengeType)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil || !user.IsActive {
+		return nil, ErrInvalidChallenge
+	}
+	return user, nil
+}
+
+// ===================== 2FA LOGIN =====================
+func (s *authService) VerifyTwoFactor(challengeToken, code string, client models.ClientInfo) (*LoginResult, error) {
+	if err := s.checkIPThrottle(client.IPAddress); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userFromChallenge(challengeToken, jwt.TokenTypeTwoFactor)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrInvalidChallenge
+	}
+
+	// kode 2FA salah ikut menambah counter gagal & lockout seperti password salah
+	if err := s.checkAccountThrottle(user, client); err != nil {
+		return nil, err
+	}
+
+	valid, err := s.verifyTwoFactorCode(user, code, true)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		if err := s.registerLoginFailure(user, client, models.LoginFailureInvalidTwoFactor); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	return s.completeLogin(user, client)
+}
+
+// verifyTwoFactorCode cek kode TOTP (sekali pakai per time step) atau recovery code
+func (s *authService) verifyTwoFactorCode(user *models.User, code string, allowRecoveryCode bool) (bool, error) {
+	if user.TOTPSecret == nil {
+		return false, nil
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(*user.TOTPSecret, code, time.Now(), 1)
+		if !ok {
+			return false, nil
+		}
+		// tolak kode yang sudah pernah dipakai (replay)
+		return s.userRepo.UseTOTPStep(user.ID, step)
+	}
+
+	if !allowRecoveryCode {
+		return false, nil
+	}
+	return s.recoveryCodeRepo.Use(user.ID, normalizeRecoveryCode(code))
+}
+
+// ===================== 2FA ENROLLMENT =====================
+func (s *authService) GetTwoFactorStatus(userID uuid.UUID) (*TwoFactorStatus, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	remaining, err := s.recoveryCodeRepo.CountUnused(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorStatus{
+		Enabled:                user.TOTPEnabled,
+		Required:               s.twoFactorRequired(user),
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+// BeginTwoFactorEnrollment buat secret baru untuk user yang sudah login
+func (s *authService) BeginTwoFactorEnrollment(userID uuid.UUID) (*TwoFactorSetup, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.beginEnrollment(user)
+}
+
+// ConfirmTwoFactorEnrollment aktifkan 2FA setelah kode pertama valid, mengembalikan recovery code
+func (s *authService) ConfirmTwoFactorEnrollment(userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.confirmEnrollment(user, code)
+}
+
+// BeginChallengeEnrollment enrolment saat login untuk akun yang wajib 2FA
+func (s *authService) BeginChallengeEnrollment(challengeToken string) (*TwoFactorSetup, error) {
+	user, err := s.userFromChallenge(challengeToken, jwt.TokenTypeTwoFactorEnroll)
+	if err != nil {
+		return nil, err
+	}
+	return s.beginEnrollment(user)
+}
+
+// ConfirmChallengeEnrollment selesaikan enrolment saat login lalu terbitkan token
+func (s *authService) ConfirmChallengeEnrollment(challengeToken, code string, client models.ClientInfo) (*LoginResult, []string, error) {
+	user, err := s.userFromChallenge(challengeToken, jwt.TokenTypeTwoFactorEnroll)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recoveryCodes, err := s.confirmEnrollment(user, code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := s.completeLogin(user, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, recoveryCodes, nil
+}
+
+func (s *authService) beginEnrollment(user *models.User) (*TwoFactorSetup, error) {
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetTOTPSecret(user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, s.twoFactorPolicy.issuer, user.Email),
+	}, nil
+}
+
+func (s *authService) confirmEnrollment(user *models.User, code string) ([]string, error) {
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == nil {
+		return nil, ErrTwoFactorNotEnrolling
+	}
+
+	valid, err := s.verifyTwoFactorCode(user, code, false)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	if err := s.userRepo.EnableTOTP(user.ID); err != nil {
+		return nil, err
+	}
+	user.TOTPEnabled = true
+
+	return s.replaceRecoveryCodes(user.ID)
+}
+
+// ===================== 2FA MANAGEMENT =====================
+
+// DisableTwoFactor matikan 2FA, butuh password dan kode 2FA saat ini
+func (s *authService) DisableTwoFactor(userID uuid.UUID, password, code string) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !user.TOTPEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if s.twoFactorRequired(user) {
+		return ErrTwoFactorRequired
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return ErrWrongPassword
+	}
+
+	valid, err := s.verifyTwoFactorCode(user, code, true)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return ErrInvalidTwoFactorCode
+	}
+
+	if err := s.userRepo.DisableTOTP(user.ID); err != nil {
+		return err
+	}
+	return s.recoveryCodeRepo.DeleteForUser(user.ID)
+}
+
+// RegenerateRecoveryCodes ganti semua recovery code, butuh kode TOTP saat ini
+func (s *authService) RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	valid, err := s.verifyTwoFactorCode(user, code, false)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	return s.replaceRecoveryCodes(user.ID)
+}
+
+// replaceRecoveryCodes buat recovery code baru, plaintext hanya ditampilkan sekali
+func (s *authService) replaceRecoveryCodes(userID uuid.UUID) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	normalized := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		normalized[i] = normalizeRecoveryCode(code)
+	}
+
+	if err := s.recoveryCodeRepo.ReplaceForUser(userID, normalized); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// generateRecoveryCode format xxxxx-xxxxx tanpa karakter yang mirip (0/o, 1/l/i)
+func generateRecoveryCode() (string, error) {
+	var sb strings.Builder
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			sb.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,443 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"wms-be/domain/models"
+	"wms-be/domain/repository"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound       = fmt.Errorf("%w: user not found", ErrNotFound)
+	ErrEmailAlreadyExists = fmt.Errorf("%w: email is already registered", ErrConflict)
+	ErrWrongPassword      = fmt.Errorf("%w: current password is incorrect", ErrInvalidInput)
+	ErrInvalidResetToken  = fmt.Errorf("%w: reset token is invalid or expired", ErrInvalidInput)
+)
+
+// UserInput data untuk create/update user dari admin
+type UserInput struct {
+	Name        string
+	Email       string
+	Password    string
+	Role        string
+	WarehouseID uuid.UUID
+}
+
+type UserService interface {
+	GetUsers(search, role, warehouseId string, page, limit int) ([]models.User, int, error)
+	GetUserByID(id uuid.UUID) (*models.User, error)
+	CreateUser(input UserInput) (*models.User, error)
+	UpdateUser(id uuid.UUID, input UserInput) (*models.User, error)
+	SetUserActive(id uuid.UUID, active bool) (*models.User, error)
+	AssignWarehouses(id uuid.UUID, warehouseID uuid.UUID, additionalWarehouseIDs []uuid.UUID) (*models.User, error)
+	ChangePassword(id uuid.UUID, currentPassword, newPassword string) error
+	IssuePasswordReset(id uuid.UUID, issuedBy uuid.UUID) (string, time.Time, error)
+	ResetPassword(token, newPassword string) error
+	UnlockUser(id uuid.UUID) (*models.User, error)
+	GetLoginAttempts(id uuid.UUID, page, limit int) ([]models.LoginAttempt, int, error)
+	ResetTwoFactor(id uuid.UUID) (*models.User, error)
+}
+
+type userService struct {
+	userRepo         repository.UserRepository
+	warehouseRepo    repository.WarehouseRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	resetTokenRepo   repository.PasswordResetTokenRepository
+	loginAttemptRepo repository.LoginAttemptRepository
+	recoveryCodeRepo repository.RecoveryCodeRepository
+	tokenDenylist    TokenDenylist
+}
+
+func NewUserService(
+	userRepo repository.UserRepository,
+	warehouseRepo repository.WarehouseRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	resetTokenRepo repository.PasswordResetTokenRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	recoveryCodeRepo repository.RecoveryCodeRepository,
+	tokenDenylist TokenDenylist,
+) UserService {
+	return &userService{
+		userRepo:         userRepo,
+		warehouseRepo:    warehouseRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		resetTokenRepo:   resetTokenRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		tokenDenylist:    tokenDenylist,
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func validateRole(role string) error {
+	if role != models.RoleAdmin && role != models.RoleStaff {
+		return fmt.Errorf("%w: role must be one of %s, %s", ErrInvalidInput, models.RoleAdmin, models.RoleStaff)
+	}
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// ensureEmailAvailable cek email belum dipakai user lain
+func (s *userService) ensureEmailAvailable(email string, exceptID uuid.UUID) error {
+	existing, err := s.userRepo.GetUserByEmail(email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if existing.ID != exceptID {
+		return ErrEmailAlreadyExists
+	}
+	return nil
+}
+
+// mapUserEmailError email yang diambil request lain di antara cek dan simpan kena unique constraint
+func mapUserEmailError(err error) error {
+	if sqlState(err) == sqlStateUniqueViolation {
+		return ErrEmailAlreadyExists
+	}
+	return err
+}
+
+func (s *userService) ensureWarehouseExists(warehouseID uuid.UUID) error {
+	if _, err := s.warehouseRepo.GetWarehouseByID(warehouseID); err != nil {
+		return fmt.Errorf("%w: warehouse %s not found", ErrInvalidInput, warehouseID)
+	}
+	return nil
+}
+
+func (s *userService) GetUsers(search, role, warehouseId string, page, limit int) ([]models.User, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	return s.userRepo.GetUsers(search, role, warehouseId, page, limit)
+}
+
+func (s *userService) GetUserByID(id uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	// service account hanya dikelola lewat API key
+	if user.Role == models.RoleService {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *userService) CreateUser(input UserInput) (*models.User, error) {
+	email := normalizeEmail(input.Email)
+	name := strings.TrimSpace(input.Name)
+
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidInput)
+	}
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, fmt.Errorf("%w: a valid email is required", ErrInvalidInput)
+	}
+	if err := validatePasswordStrength(input.Password); err != nil {
+		return nil, err
+	}
+	if input.Role == "" {
+		input.Role = models.RoleStaff
+	}
+	if err := validateRole(input.Role); err != nil {
+		return nil, err
+	}
+	if input.WarehouseID == uuid.Nil {
+		return nil, fmt.Errorf("%w: warehouse is required", ErrInvalidInput)
+	}
+	if err := s.ensureWarehouseExists(input.WarehouseID); err != nil {
+		return nil, err
+	}
+	if err := s.ensureEmailAvailable(email, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := hashPassword(input.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Name:         name,
+		Email:        email,
+		PasswordHash: passwordHash,
+		Role:         input.Role,
+		WarehouseID:  input.WarehouseID,
+		IsActive:     true,
+	}
+	if err := s.userRepo.CreateUser(user); err != nil {
+		return nil, mapUserEmailError(err)
+	}
+
+	return s.GetUserByID(user.ID)
+}
+
+// UpdateUser update data user, field kosong tidak diubah. Perubahan role / gudang
+// mencabut semua token agar hak akses lama di access token tidak terus berlaku.
+func (s *userService) UpdateUser(id uuid.UUID, input UserInput) (*models.User, error) {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	previousRole, previousWarehouseID := user.Role, user.WarehouseID
+
+	if name := strings.TrimSpace(input.Name); name != "" {
+		user.Name = name
+	}
+	if input.Email != "" {
+		email := normalizeEmail(input.Email)
+		if !strings.Contains(email, "@") {
+			return nil, fmt.Errorf("%w: a valid email is required", ErrInvalidInput)
+		}
+		if err := s.ensureEmailAvailable(email, user.ID); err != nil {
+			return nil, err
+		}
+		user.Email = email
+	}
+	if input.Role != "" {
+		if err := validateRole(input.Role); err != nil {
+			return nil, err
+		}
+		user.Role = input.Role
+	}
+	if input.WarehouseID != uuid.Nil {
+		if err := s.ensureWarehouseExists(input.WarehouseID); err != nil {
+			return nil, err
+		}
+		user.WarehouseID = input.WarehouseID
+	}
+
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return nil, mapUserEmailError(err)
+	}
+
+	if user.Role != previousRole || user.WarehouseID != previousWarehouseID {
+		if err := s.revokeAllTokens(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetUserByID(user.ID)
+}
+
+// SetUserActive aktifkan / nonaktifkan user. User nonaktif tidak bisa login
+// dan semua refresh token serta access token-nya dicabut.
+func (s *userService) SetUserActive(id uuid.UUID, active bool) (*models.User, error) {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.IsActive = active
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return nil, err
+	}
+
+	if !active {
+		if err := s.revokeAllTokens(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetUserByID(user.ID)
+}
+
+// AssignWarehouses pindahkan user ke gudang utama baru dan atur gudang tambahan
+func (s *userService) AssignWarehouses(id uuid.UUID, warehouseID uuid.UUID, additionalWarehouseIDs []uuid.UUID) (*models.User, error) {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if warehouseID != uuid.Nil && warehouseID != user.WarehouseID {
+		if err := s.ensureWarehouseExists(warehouseID); err != nil {
+			return nil, err
+		}
+		user.WarehouseID = warehouseID
+		if err := s.userRepo.UpdateUser(user); err != nil {
+			return nil, err
+		}
+		// warehouse_id di access token lama sudah tidak berlaku
+		if err := s.revokeAllTokens(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// gudang utama tidak perlu disimpan ulang sebagai gudang tambahan
+	additional := make([]uuid.UUID, 0, len(additionalWarehouseIDs))
+	seen := map[uuid.UUID]bool{user.WarehouseID: true}
+	for _, additionalID := range additionalWarehouseIDs {
+		if seen[additionalID] {
+			continue
+		}
+		if err := s.ensureWarehouseExists(additionalID); err != nil {
+			return nil, err
+		}
+		seen[additionalID] = true
+		additional = append(additional, additionalID)
+	}
+
+	if err := s.userRepo.SetAssignedWarehouses(user.ID, additional); err != nil {
+		return nil, err
+	}
+
+	return s.GetUserByID(user.ID)
+}
+
+// ChangePassword ganti password user yang sedang login
+func (s *userService) ChangePassword(id uuid.UUID, currentPassword, newPassword string) error {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)); err != nil {
+		return ErrWrongPassword
+	}
+	if currentPassword == newPassword {
+		return fmt.Errorf("%w: new password must be different from the current password", ErrInvalidInput)
+	}
+
+	return s.setPassword(user, newPassword)
+}
+
+// IssuePasswordReset buat token reset sekali pakai oleh admin.
+// Token asli hanya dikembalikan sekali, di DB disimpan hash-nya.
+func (s *userService) IssuePasswordReset(id uuid.UUID, issuedBy uuid.UUID) (string, time.Time, error) {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	// token lama yang belum dipakai tidak berlaku lagi
+	if err := s.resetTokenRepo.InvalidateForUser(user.ID); err != nil {
+		return "", time.Time{}, err
+	}
+
+	token, err := generateSecureToken(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(getEnvDuration("PASSWORD_RESET_EXPIRE", 3600))
+	if err := s.resetTokenRepo.Store(user.ID, &issuedBy, token, expiresAt); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// ResetPassword set password baru memakai token reset. Token diklaim dan password disimpan
+// di transaksi yang sama sehingga token hanya bisa dipakai sekali.
+func (s *userService) ResetPassword(token, newPassword string) error {
+	if err := validatePasswordStrength(newPassword); err != nil {
+		return err
+	}
+
+	passwordHash, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	userID, err := s.resetTokenRepo.Redeem(token, passwordHash)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.revokeAllTokens(userID)
+}
+
+// setPassword validasi, hash, simpan password baru lalu cabut semua sesi user
+func (s *userService) setPassword(user *models.User, newPassword string) error {
+	if err := validatePasswordStrength(newPassword); err != nil {
+		return err
+	}
+
+	passwordHash, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = passwordHash
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return err
+	}
+
+	return s.revokeAllTokens(user.ID)
+}
+
+// revokeAllTokens cabut semua refresh token dan access token yang sudah terbit
+func (s *userService) revokeAllTokens(userID uuid.UUID) error {
+	if err := s.refreshTokenRepo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	return s.tokenDenylist.RevokeAllForUser(userID)
+}
+
+// UnlockUser buka lockout akun dan reset counter login gagal
+func (s *userService) UnlockUser(id uuid.UUID) (*models.User, error) {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.ResetLoginFailures(user.ID); err != nil {
+		return nil, err
+	}
+
+	return s.GetUserByID(user.ID)
+}
+
+// GetLoginAttempts riwayat percobaan login user (audit)
+func (s *userService) GetLoginAttempts(id uuid.UUID, page, limit int) ([]models.LoginAttempt, int, error) {
+	if _, err := s.GetUserByID(id); err != nil {
+		return nil, 0, err
+	}
+	return s.loginAttemptRepo.GetByUser(id, page, limit)
+}
+
+// ResetTwoFactor matikan 2FA user yang kehilangan perangkat authenticator.
+// Jika 2FA wajib untuk role-nya, user akan diminta enrolment ulang saat login berikutnya.
+func (s *userService) ResetTwoFactor(id uuid.UUID) (*models.User, error) {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.DisableTOTP(user.ID); err != nil {
+		return nil, err
+	}
+	if err := s.recoveryCodeRepo.DeleteForUser(user.ID); err != nil {
+		return nil, err
+	}
+
+	return s.GetUserByID(user.ID)
+}
@@ -0,0 +1,18 @@
+package services
+
+import (
+	"errors"
+	"wms-be/domain/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrWarehouseAccessDenied dikembalikan jika user mengakses data gudang di luar scope-nya
+var ErrWarehouseAccessDenied = errors.New("you do not have access to this warehouse")
+
+func checkWarehouseAccess(scope models.WarehouseScope, warehouseID uuid.UUID) error {
+	if !scope.Allows(warehouseID) {
+		return ErrWarehouseAccessDenied
+	}
+	return nil
+}
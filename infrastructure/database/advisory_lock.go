@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"log"
+)
+
+// WithAdvisoryLock jalankan fn hanya jika Postgres session advisory lock key berhasil diambil
+// (non-blocking). Dipakai agar job background hanya jalan di satu instance pada satu waktu.
+// false berarti lock sedang dipegang instance lain dan fn tidak dijalankan.
+func WithAdvisoryLock(ctx context.Context, key int64, fn func() error) (bool, error) {
+	sqlDB, err := GetDB().DB()
+	if err != nil {
+		return false, err
+	}
+
+	// session lock terikat ke koneksi, jadi lock & unlock harus di koneksi yang sama
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		return false, err
+	}
+	if !acquired {
+		return false, nil
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			log.Printf("[database] failed to release advisory lock %d: %v", key, err)
+		}
+	}()
+
+	return true, fn()
+}
@@ -0,0 +1,144 @@
+// Package docnumber format nomor dokumen (order, inbound, outbound, transfer) dari scheme
+// seperti "SO-{WAREHOUSE_CODE}-{YYYYMM}-{SEQ:5}". Nomor urut (SEQ) dialokasikan di DB.
+package docnumber
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Token yang didukung di scheme
+const (
+	TokenWarehouseCode = "WAREHOUSE_CODE"
+	TokenYear          = "YYYY"
+	TokenYearShort     = "YY"
+	TokenMonth         = "MM"
+	TokenDay           = "DD"
+	TokenYearMonth     = "YYYYMM"
+	TokenDate          = "YYYYMMDD"
+	TokenSeq           = "SEQ"
+
+	defaultSeqWidth = 5
+)
+
+var tokenPattern = regexp.MustCompile(`\{([A-Z_]+)(?::(\d+))?\}`)
+
+// Scheme scheme nomor dokumen yang sudah divalidasi
+type Scheme struct {
+	format   string
+	seqWidth int
+	period   string // layout time untuk reset nomor urut: "", "2006", "200601" atau "20060102"
+}
+
+// Parse validasi scheme: wajib mengandung {SEQ} dan {WAREHOUSE_CODE} (nomor urut dihitung per
+// gudang, tanpa kode gudang nomor yang sama akan terbit di setiap gudang) dan hanya token yang
+// dikenal. Nomor urut di-reset mengikuti token tanggal terkecil di scheme (harian / bulanan /
+// tahunan), jadi token tanggal harus lengkap sampai tahun: {DD} butuh bulan dan tahun, {MM}
+// butuh tahun.
+func Parse(format string) (Scheme, error) {
+	s := Scheme{format: format, seqWidth: defaultSeqWidth}
+
+	hasSeq, hasWarehouse := false, false
+	var hasYear, hasMonth, hasDay bool
+	for _, m := range tokenPattern.FindAllStringSubmatch(format, -1) {
+		switch m[1] {
+		case TokenSeq:
+			hasSeq = true
+			if m[2] != "" {
+				width, _ := strconv.Atoi(m[2])
+				if width < 1 || width > 12 {
+					return Scheme{}, fmt.Errorf("invalid sequence width in %q", format)
+				}
+				s.seqWidth = width
+			}
+		case TokenWarehouseCode:
+			hasWarehouse = true
+		case TokenYear, TokenYearShort:
+			hasYear = true
+		case TokenYearMonth:
+			hasYear, hasMonth = true, true
+		case TokenMonth:
+			hasMonth = true
+		case TokenDate:
+			hasYear, hasMonth, hasDay = true, true, true
+		case TokenDay:
+			hasDay = true
+		default:
+			return Scheme{}, fmt.Errorf("unknown token {%s} in %q", m[1], format)
+		}
+		if m[2] != "" && m[1] != TokenSeq {
+			return Scheme{}, fmt.Errorf("token {%s} does not accept a width in %q", m[1], format)
+		}
+	}
+	if !hasSeq {
+		return Scheme{}, errors.New("document number format must contain {SEQ}")
+	}
+	if !hasWarehouse {
+		return Scheme{}, errors.New("document number format must contain {WAREHOUSE_CODE}")
+	}
+	if (hasDay && !(hasMonth && hasYear)) || (hasMonth && !hasYear) {
+		return Scheme{}, fmt.Errorf("date tokens in %q must include the year (and month for {DD}) so numbers stay unique across periods", format)
+	}
+
+	switch {
+	case hasDay:
+		s.period = "20060102"
+	case hasMonth:
+		s.period = "200601"
+	case hasYear:
+		s.period = "2006"
+	}
+	return s, nil
+}
+
+// MustParse seperti Parse tapi panic jika scheme tidak valid (untuk default bawaan)
+func MustParse(format string) Scheme {
+	s, err := Parse(format)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// String scheme aslinya
+func (s Scheme) String() string {
+	return s.format
+}
+
+// Period kunci periode nomor urut untuk waktu t ("" jika tidak pernah di-reset)
+func (s Scheme) Period(t time.Time) string {
+	if s.period == "" {
+		return ""
+	}
+	return t.Format(s.period)
+}
+
+// Format susun nomor dokumen
+func (s Scheme) Format(warehouseCode string, t time.Time, seq int64) string {
+	return tokenPattern.ReplaceAllStringFunc(s.format, func(token string) string {
+		m := tokenPattern.FindStringSubmatch(token)
+		switch m[1] {
+		case TokenWarehouseCode:
+			return strings.ToUpper(warehouseCode)
+		case TokenYear:
+			return t.Format("2006")
+		case TokenYearShort:
+			return t.Format("06")
+		case TokenMonth:
+			return t.Format("01")
+		case TokenDay:
+			return t.Format("02")
+		case TokenYearMonth:
+			return t.Format("200601")
+		case TokenDate:
+			return t.Format("20060102")
+		case TokenSeq:
+			return fmt.Sprintf("%0*d", s.seqWidth, seq)
+		}
+		return token
+	})
+}
@@ -7,56 +7,173 @@ import (
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 )
 
-var secretKey []byte
+// Jenis token, disimpan di claim "typ" agar refresh token tidak bisa dipakai sebagai access token
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
 
-// Init loads the secret key from the environment variable
+	// challenge login 2 langkah: verifikasi kode TOTP, atau enrolment wajib (policy admin)
+	TokenTypeTwoFactor       = "2fa"
+	TokenTypeTwoFactorEnroll = "2fa_enroll"
+)
+
+// Claims isi payload JWT yang diterbitkan service ini
+type Claims struct {
+	jwt.StandardClaims
+	Type        string `json:"typ"`
+	UserID      string `json:"user_id"`
+	Role        string `json:"role,omitempty"`
+	WarehouseID string `json:"warehouse_id,omitempty"`
+	// sid = family refresh token (sesi) tempat access token diterbitkan, untuk sign out per sesi
+	SessionID string `json:"sid,omitempty"`
+	// iat presisi milidetik, iat standar hanya presisi detik sehingga token yang terbit
+	// sesaat setelah pencabutan tidak bisa dibedakan dari token sebelum pencabutan
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
+}
+
+// TokenClaims berisi data yang dibawa oleh token
+type TokenClaims struct {
+	ID          string // jti, dipakai untuk denylist saat token dicabut
+	Type        string
+	UserID      string
+	Role        string
+	WarehouseID string
+	SessionID   string // family refresh token, kosong untuk token lama
+	IssuedAt    time.Time
+	ExpiresAt   time.Time
+}
+
+var (
+	issuer   = "wms-be"
+	audience = "wms-api"
+)
+
+// Init loads the signing keys, issuer and audience from the environment variables
 func Init() {
 	_ = godotenv.Load() // Tidak perlu panic kalau .env tidak ditemukan (bisa di-load manual lewat env)
-	secretKey = []byte(os.Getenv("JWT_SECRET_KEY"))
-	if len(secretKey) == 0 {
-		panic("JWT_SECRET_KEY environment variable not set or is empty")
+
+	ks, err := loadKeySet()
+	if err != nil {
+		panic(err.Error())
+	}
+	keys = ks
+
+	if v := os.Getenv("JWT_ISSUER"); v != "" {
+		issuer = v
+	}
+	if v := os.Getenv("JWT_AUDIENCE"); v != "" {
+		audience = v
 	}
 }
 
-// GenerateToken generates a JWT token with userID and expiration time
-func GenerateToken(userID string, expiration time.Time) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     expiration.Unix(),
-		"iat":     time.Now().Unix(),
+// GenerateAccessToken generates an access token carrying role, primary warehouse and session (refresh token family)
+func GenerateAccessToken(userID, role, warehouseID, sessionID string, expiration time.Time) (string, error) {
+	claims := newClaims(TokenTypeAccess, userID, expiration)
+	claims.Role = role
+	claims.WarehouseID = warehouseID
+	claims.SessionID = sessionID
+	return keys.sign(claims)
+}
+
+// GenerateRefreshToken generates a refresh token, hanya berisi identitas user
+func GenerateRefreshToken(userID string, expiration time.Time) (string, error) {
+	return keys.sign(newClaims(TokenTypeRefresh, userID, expiration))
+}
+
+func newClaims(tokenType, userID string, expiration time.Time) *Claims {
+	now := time.Now()
+	return &Claims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
+			Subject:   userID,
+			Issuer:    issuer,
+			Audience:  audience,
+			ExpiresAt: expiration.Unix(),
+			IssuedAt:  now.Unix(),
+		},
+		Type:       tokenType,
+		UserID:     userID,
+		IssuedAtMs: now.UnixMilli(),
 	}
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secretKey)
+// GenerateChallengeToken generates a short-lived 2FA challenge token (TokenTypeTwoFactor / TokenTypeTwoFactorEnroll)
+func GenerateChallengeToken(userID, tokenType string, expiration time.Time) (string, error) {
+	return keys.sign(newClaims(tokenType, userID, expiration))
 }
 
-// ValidateToken validates the JWT token and returns the user ID if valid
-func ValidateToken(tokenString string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
-		}
-		return secretKey, nil
-	})
+// ValidateChallengeToken validates a 2FA challenge token of the given type
+func ValidateChallengeToken(tokenString, tokenType string) (*TokenClaims, error) {
+	return validateToken(tokenString, tokenType)
+}
+
+// ValidateAccessToken validates a bearer token, refresh token ditolak
+func ValidateAccessToken(tokenString string) (*TokenClaims, error) {
+	return validateToken(tokenString, TokenTypeAccess)
+}
 
+// ValidateRefreshToken validates a refresh token, access token ditolak
+func ValidateRefreshToken(tokenString string) (*TokenClaims, error) {
+	return validateToken(tokenString, TokenTypeRefresh)
+}
+
+// validateToken cek signature, exp, iss, aud, typ dan claim wajib lainnya
+func validateToken(tokenString, expectedType string) (*TokenClaims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, keys.keyFunc)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return "", errors.New("invalid token")
+	if claims.Type != expectedType {
+		return nil, errors.New("invalid token type")
+	}
+	if claims.Issuer != issuer {
+		return nil, errors.New("invalid token issuer")
+	}
+	if !claims.VerifyAudience(audience, true) {
+		return nil, errors.New("invalid token audience")
+	}
+	if claims.ExpiresAt == 0 || claims.IssuedAt == 0 {
+		return nil, errors.New("exp or iat not found in token claims")
+	}
+	// jti wajib ada, token tanpa jti tidak bisa dicabut
+	if claims.Id == "" {
+		return nil, errors.New("jti not found in token claims")
+	}
+	if claims.UserID == "" || claims.UserID != claims.Subject {
+		return nil, errors.New("user_id not found in token claims")
+	}
+	if expectedType == TokenTypeAccess && claims.Role == "" {
+		return nil, errors.New("role not found in token claims")
 	}
 
-	userID, ok := claims["user_id"].(string)
-	if !ok {
-		return "", errors.New("user_id not found in token claims")
+	// token lama tanpa iat_ms tetap presisi detik
+	issuedAt := time.Unix(claims.IssuedAt, 0)
+	if claims.IssuedAtMs != 0 {
+		if claims.IssuedAtMs/1000 != claims.IssuedAt {
+			return nil, errors.New("iat_ms does not match iat")
+		}
+		issuedAt = time.UnixMilli(claims.IssuedAtMs)
 	}
 
-	return userID, nil
+	return &TokenClaims{
+		ID:          claims.Id,
+		Type:        claims.Type,
+		UserID:      claims.UserID,
+		Role:        claims.Role,
+		WarehouseID: claims.WarehouseID,
+		SessionID:   claims.SessionID,
+		IssuedAt:    issuedAt,
+		ExpiresAt:   time.Unix(claims.ExpiresAt, 0),
+	}, nil
 }
 
 // GetUserIDFromContext extracts the user_id from Gin context (set by middleware)
@@ -73,3 +190,33 @@ func GetUserIDFromContext(c *gin.Context) (string, error) {
 
 	return userID, nil
 }
+
+// GetRoleFromContext extracts the role from Gin context (set by middleware)
+func GetRoleFromContext(c *gin.Context) (string, error) {
+	val, exists := c.Get("role")
+	if !exists {
+		return "", errors.New("role not found in context")
+	}
+
+	role, ok := val.(string)
+	if !ok {
+		return "", errors.New("invalid role type in context")
+	}
+
+	return role, nil
+}
+
+// GetClaimsFromContext extracts the validated token claims from Gin context (set by middleware)
+func GetClaimsFromContext(c *gin.Context) (*TokenClaims, error) {
+	val, exists := c.Get("token_claims")
+	if !exists {
+		return nil, errors.New("token claims not found in context")
+	}
+
+	claims, ok := val.(*TokenClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims type in context")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,189 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// signingKey satu kunci asimetris yang dikenali lewat kid.
+// privateKey nil untuk kunci lama yang hanya dipakai validasi (rotasi).
+type signingKey struct {
+	kid        string
+	alg        string
+	privateKey crypto.Signer
+	publicKey  crypto.PublicKey
+}
+
+func (k *signingKey) method() jwt.SigningMethod {
+	if k.alg == AlgEdDSA {
+		return signingMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// keySet semua kunci yang dikenal beserta kunci aktif untuk tanda tangan
+type keySet struct {
+	alg       string
+	secretKey []byte
+	active    *signingKey
+	keys      map[string]*signingKey
+}
+
+var keys = &keySet{keys: map[string]*signingKey{}}
+
+// loadKeySet membaca konfigurasi kunci dari env:
+//   - JWT_SIGNING_ALG  : HS256 (default), RS256 atau EdDSA
+//   - JWT_SECRET_KEY   : secret HS256; jika diisi token HS256 lama tetap valid
+//   - JWT_KEYS_DIR     : folder berisi <kid>.pem (private key, atau public key untuk kunci lama)
+//   - JWT_ACTIVE_KID   : kid yang dipakai untuk menandatangani token baru
+func loadKeySet() (*keySet, error) {
+	ks := &keySet{
+		alg:       strings.TrimSpace(os.Getenv("JWT_SIGNING_ALG")),
+		secretKey: []byte(os.Getenv("JWT_SECRET_KEY")),
+		keys:      map[string]*signingKey{},
+	}
+	if ks.alg == "" {
+		ks.alg = AlgHS256
+	}
+
+	if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+		if err := ks.loadDir(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	switch ks.alg {
+	case AlgHS256:
+		if len(ks.secretKey) == 0 {
+			return nil, errors.New("JWT_SECRET_KEY environment variable not set or is empty")
+		}
+	case AlgRS256, AlgEdDSA:
+		kid := os.Getenv("JWT_ACTIVE_KID")
+		active, ok := ks.keys[kid]
+		if kid == "" || !ok {
+			return nil, fmt.Errorf("JWT_ACTIVE_KID %q not found in JWT_KEYS_DIR", kid)
+		}
+		if active.alg != ks.alg {
+			return nil, fmt.Errorf("active key %q is %s, expected %s", kid, active.alg, ks.alg)
+		}
+		if active.privateKey == nil {
+			return nil, fmt.Errorf("active key %q has no private key", kid)
+		}
+		ks.active = active
+	default:
+		return nil, fmt.Errorf("unsupported JWT_SIGNING_ALG %q", ks.alg)
+	}
+
+	return ks, nil
+}
+
+// loadDir muat semua file *.pem, nama file (tanpa .pem) menjadi kid
+func (ks *keySet) loadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := parseKey(kid, data)
+		if err != nil {
+			return fmt.Errorf("jwt key %s: %w", file, err)
+		}
+		ks.keys[kid] = key
+	}
+	return nil
+}
+
+// parseKey dukung RSA (PKCS#1 / PKCS#8 / PKIX) dan Ed25519 (PKCS#8 / PKIX)
+func parseKey(kid string, data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid PEM")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{kid: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.alg, key.privateKey, key.publicKey = AlgRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.alg, key.publicKey = AlgRS256, k
+	case ed25519.PrivateKey:
+		key.alg, key.privateKey, key.publicKey = AlgEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.alg, key.publicKey = AlgEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
+
+// sign tanda tangani claims dengan kunci aktif (atau secret HS256)
+func (ks *keySet) sign(claims jwt.Claims) (string, error) {
+	if ks.active == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.secretKey)
+	}
+
+	token := jwt.NewWithClaims(ks.active.method(), claims)
+	token.Header["kid"] = ks.active.kid
+	return token.SignedString(ks.active.privateKey)
+}
+
+// keyFunc pilih kunci verifikasi berdasarkan alg dan kid di header token
+func (ks *keySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if token.Method.Alg() != AlgHS256 || len(ks.secretKey) == 0 {
+			return nil, errors.New("invalid signing method")
+		}
+		return ks.secretKey, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.alg {
+		return nil, errors.New("invalid signing method")
+	}
+	return key.publicKey, nil
+}
@@ -3,13 +3,37 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"wms-be/domain/models"
 	"wms-be/infrastructure/jwt"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+const (
+	apiKeyHeader   = "X-API-Key"
+	apiKeyIDKey    = "api_key_id"
+	permissionsKey = "permissions"
+)
+
+// TokenRevocationChecker cek apakah access token sudah dicabut (logout, user dinonaktifkan, reset password)
+type TokenRevocationChecker interface {
+	IsRevoked(claims *jwt.TokenClaims) (bool, error)
+}
+
+// APIKeyAuthenticator validasi API key dari header X-API-Key
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(rawKey, ipAddress string) (*models.APIKeyPrincipal, error)
+}
+
+// AuthMiddleware menerima bearer JWT atau API key (header X-API-Key).
+// Request dengan API key berjalan atas nama service account milik key tersebut.
+func AuthMiddleware(revocations TokenRevocationChecker, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if rawKey := c.GetHeader(apiKeyHeader); rawKey != "" {
+			authenticateAPIKey(c, apiKeys, rawKey)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -33,7 +57,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		token := parts[1]
 
 		// ✅ Validasi token
-		userID, err := jwt.ValidateToken(token)
+		claims, err := jwt.ValidateAccessToken(token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
@@ -43,8 +67,70 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// ✅ Simpan user_id di context
-		c.Set("user_id", userID)
+		// ✅ Cek denylist
+		revoked, err := revocations.IsRevoked(claims)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to verify token",
+			})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "Token has been revoked",
+			})
+			c.Abort()
+			return
+		}
+
+		// ✅ Simpan user_id & role di context
+		c.Set("user_id", claims.UserID)
+		c.Set("role", claims.Role)
+		c.Set("token_claims", claims)
+		c.Next()
+	}
+}
+
+func authenticateAPIKey(c *gin.Context, apiKeys APIKeyAuthenticator, rawKey string) {
+	principal, err := apiKeys.AuthenticateAPIKey(rawKey, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Invalid or expired API key",
+		})
+		c.Abort()
+		return
+	}
+
+	// ✅ Simpan identitas service account, permission & scope gudang milik key
+	c.Set("user_id", principal.ServiceAccountID.String())
+	c.Set("role", models.RoleService)
+	c.Set(apiKeyIDKey, principal.KeyID.String())
+	c.Set(permissionsKey, principal.Permissions)
+	c.Set(warehouseScopeKey, principal.Scope)
+	c.Next()
+}
+
+// IsAPIKeyRequest cek request diautentikasi dengan API key (bukan sesi user)
+func IsAPIKeyRequest(c *gin.Context) bool {
+	_, exists := c.Get(apiKeyIDKey)
+	return exists
+}
+
+// UserSessionOnly tolak API key untuk route yang hanya masuk akal bagi user (profil, sesi, 2FA)
+func UserSessionOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsAPIKeyRequest(c) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "This endpoint is not available for API keys",
+			})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
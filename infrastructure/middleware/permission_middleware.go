@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"wms-be/domain/models"
+	"wms-be/infrastructure/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission memastikan role user memiliki semua permission yang diminta.
+// Harus dipasang setelah AuthMiddleware.
+func RequirePermission(permissions ...models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, permission := range permissions {
+			if !HasPermission(c, permission) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"success":             false,
+					"message":             "You do not have permission to perform this action",
+					"required_permission": permission,
+				})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// HasPermission cek permission user yang sedang login (dipakai juga di handler).
+// Untuk API key permission diambil dari key, bukan dari role.
+func HasPermission(c *gin.Context, permission models.Permission) bool {
+	if val, exists := c.Get(permissionsKey); exists {
+		permissions, _ := val.([]models.Permission)
+		for _, p := range permissions {
+			if p == permission {
+				return true
+			}
+		}
+		return false
+	}
+
+	role, err := jwt.GetRoleFromContext(c)
+	if err != nil {
+		return false
+	}
+	return models.RoleHasPermission(role, permission)
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"wms-be/domain/models"
+	"wms-be/domain/repository"
+	"wms-be/infrastructure/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const warehouseScopeKey = "warehouse_scope"
+
+// WarehouseScopeMiddleware menentukan gudang yang boleh diakses user.
+// Admin bebas mengakses semua gudang, staff dibatasi ke gudangnya sendiri
+// ditambah gudang yang di-assign di tabel user_warehouses.
+// Harus dipasang setelah AuthMiddleware.
+func WarehouseScopeMiddleware(userRepo repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// API key: scope sudah ditentukan oleh key di AuthMiddleware
+		if IsAPIKeyRequest(c) {
+			c.Next()
+			return
+		}
+
+		role, _ := jwt.GetRoleFromContext(c)
+		if role == models.RoleAdmin {
+			c.Set(warehouseScopeKey, models.AllWarehouses())
+			c.Next()
+			return
+		}
+
+		userIDStr, err := jwt.GetUserIDFromContext(c)
+		if err != nil {
+			abortUnauthorized(c)
+			return
+		}
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			abortUnauthorized(c)
+			return
+		}
+
+		user, err := userRepo.GetUserByID(userID)
+		if err != nil {
+			abortUnauthorized(c)
+			return
+		}
+
+		scope := models.WarehouseScope{}
+		if user.WarehouseID != uuid.Nil {
+			scope.WarehouseIDs = append(scope.WarehouseIDs, user.WarehouseID)
+		}
+
+		assigned, err := userRepo.GetAssignedWarehouseIDs(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to resolve warehouse access",
+			})
+			c.Abort()
+			return
+		}
+		for _, id := range assigned {
+			if !scope.Allows(id) {
+				scope.WarehouseIDs = append(scope.WarehouseIDs, id)
+			}
+		}
+
+		c.Set(warehouseScopeKey, scope)
+		c.Next()
+	}
+}
+
+// GetWarehouseScope ambil scope gudang dari context.
+// Jika middleware tidak terpasang, kembalikan scope kosong (tidak ada akses).
+func GetWarehouseScope(c *gin.Context) models.WarehouseScope {
+	val, exists := c.Get(warehouseScopeKey)
+	if !exists {
+		return models.WarehouseScope{}
+	}
+	scope, ok := val.(models.WarehouseScope)
+	if !ok {
+		return models.WarehouseScope{}
+	}
+	return scope
+}
+
+func abortUnauthorized(c *gin.Context) {
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"success": false,
+		"message": "Unauthorized",
+	})
+	c.Abort()
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"wms-be/domain/models"
+	"wms-be/domain/services"
+	"wms-be/interfaces/http/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// APIKeyHandler handles API key management requests (admin only)
+type APIKeyHandler struct {
+	apiKeyService services.APIKeyService
+}
+
+// Constructor
+func NewAPIKeyHandler(apiKeyService services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// APIKeyResponse represents API response for an API key (tanpa key/hash)
+type APIKeyResponse struct {
+	ID               string              `json:"id"`
+	Name             string              `json:"name"`
+	KeyPrefix        string              `json:"keyPrefix"`
+	ServiceAccountID string              `json:"serviceAccountId"`
+	Permissions      []models.Permission `json:"permissions"`
+	AllWarehouses    bool                `json:"allWarehouses"`
+	WarehouseIDs     []string            `json:"warehouseIds"`
+	ExpiresAt        *string             `json:"expiresAt"`
+	LastUsedAt       *string             `json:"lastUsedAt"`
+	LastUsedIP       string              `json:"lastUsedIp"`
+	RevokedAt        *string             `json:"revokedAt"`
+	CreatedAt        string              `json:"createdAt"`
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
+}
+
+func mapAPIKeyToResponse(apiKey models.APIKey) APIKeyResponse {
+	warehouseIDs := make([]string, 0, len(apiKey.Warehouses))
+	for _, w := range apiKey.Warehouses {
+		warehouseIDs = append(warehouseIDs, w.WarehouseID.String())
+	}
+
+	return APIKeyResponse{
+		ID:               apiKey.ID.String(),
+		Name:             apiKey.Name,
+		KeyPrefix:        apiKey.KeyPrefix,
+		ServiceAccountID: apiKey.ServiceAccountID.String(),
+		Permissions:      apiKey.PermissionList(),
+		AllWarehouses:    apiKey.AllWarehouses,
+		WarehouseIDs:     warehouseIDs,
+		ExpiresAt:        formatOptionalTime(apiKey.ExpiresAt),
+		LastUsedAt:       formatOptionalTime(apiKey.LastUsedAt),
+		LastUsedIP:       apiKey.LastUsedIP,
+		RevokedAt:        formatOptionalTime(apiKey.RevokedAt),
+		CreatedAt:        apiKey.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+type apiKeyRequest struct {
+	Name          string   `json:"name"`
+	Permissions   []string `json:"permissions"`
+	AllWarehouses bool     `json:"allWarehouses"`
+	WarehouseIDs  []string `json:"warehouseIds"`
+	ExpiresAt     string   `json:"expiresAt"` // RFC3339, kosong = tidak expired
+}
+
+func (req apiKeyRequest) toInput() (services.APIKeyInput, error) {
+	input := services.APIKeyInput{
+		Name:          req.Name,
+		AllWarehouses: req.AllWarehouses,
+	}
+
+	for _, p := range req.Permissions {
+		input.Permissions = append(input.Permissions, models.Permission(p))
+	}
+
+	for _, idStr := range req.WarehouseIDs {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return input, errors.New("invalid warehouse id " + idStr)
+		}
+		input.WarehouseIDs = append(input.WarehouseIDs, id)
+	}
+
+	if req.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			return input, errors.New("expiresAt must be an RFC3339 timestamp")
+		}
+		input.ExpiresAt = &expiresAt
+	}
+
+	return input, nil
+}
+
+func parseAPIKeyID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, errors.New("invalid api key id"), http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// GetAPIKeys handles GET /api_keys
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	includeRevoked := c.Query("includeRevoked") == "true"
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	apiKeys, total, err := h.apiKeyService.GetAPIKeys(includeRevoked, page, limit)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]APIKeyResponse, len(apiKeys))
+	for i, k := range apiKeys {
+		resp[i] = mapAPIKeyToResponse(k)
+	}
+
+	response.PaginatedResponse(c, "apiKeys", resp, total, page, limit)
+}
+
+// GetAPIKeyByID handles GET /api_keys/:id
+func (h *APIKeyHandler) GetAPIKeyByID(c *gin.Context) {
+	id, ok := parseAPIKeyID(c)
+	if !ok {
+		return
+	}
+
+	apiKey, err := h.apiKeyService.GetAPIKeyByID(id)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapAPIKeyToResponse(*apiKey), "API key fetched successfully")
+}
+
+// CreateAPIKey handles POST /api_keys
+// Key plaintext hanya dikembalikan di response ini.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req apiKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	input, err := req.toInput()
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	adminID, err := requestActorID(c)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	apiKey, rawKey, err := h.apiKeyService.CreateAPIKey(input, adminID)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, gin.H{
+		"apiKey": mapAPIKeyToResponse(*apiKey),
+		"key":    rawKey,
+	}, "API key created successfully, store the key now as it will not be shown again")
+}
+
+// UpdateAPIKey handles PUT /api_keys/:id
+func (h *APIKeyHandler) UpdateAPIKey(c *gin.Context) {
+	id, ok := parseAPIKeyID(c)
+	if !ok {
+		return
+	}
+
+	var req apiKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	input, err := req.toInput()
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	apiKey, err := h.apiKeyService.UpdateAPIKey(id, input)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapAPIKeyToResponse(*apiKey), "API key updated successfully")
+}
+
+// RevokeAPIKey handles POST /api_keys/:id/revoke
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, ok := parseAPIKeyID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(id); err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessMessageResponse(c, "API key revoked successfully")
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"math"
 	"net/http"
 	"os"
 	"strconv"
@@ -26,20 +28,29 @@ func getAccessExpire() int64 {
 	return val
 }
 
+func clientInfo(c *gin.Context) models.ClientInfo {
+	return models.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
+
 func buildUserResponse(user *models.User) gin.H {
 	return gin.H{
-		"id":            user.ID.String(),
-		"email":         user.Email,
-		"name":          user.Name,
-		"role":          user.Role,
-		"warehouseId":   user.WarehouseID.String(),
-		"warehouseName": user.Warehouse.Name,
-		"createdAt":     user.CreatedAt,
+		"id":               user.ID.String(),
+		"email":            user.Email,
+		"name":             user.Name,
+		"role":             user.Role,
+		"permissions":      models.PermissionsForRole(user.Role),
+		"warehouseId":      user.WarehouseID.String(),
+		"warehouseName":    user.Warehouse.Name,
+		"twoFactorEnabled": user.TOTPEnabled,
+		"createdAt":        user.CreatedAt,
 	}
 }
 
 // ===================== LOGIN =====================
-func Login(c *gin.Context, authService services.AuthService, userRepo repository.UserRepository) {
+func Login(c *gin.Context, authService services.AuthService) {
 	var req struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
@@ -50,30 +61,60 @@ func Login(c *gin.Context, authService services.AuthService, userRepo repository
 		return
 	}
 
-	accessToken, refreshToken, err := authService.Login(req.Email, req.Password)
+	result, err := authService.Login(req.Email, req.Password, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": err.Error()})
+		loginErrorResponse(c, err)
 		return
 	}
 
-	user, err := userRepo.GetUserByEmail(req.Email)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "User not found"})
+	// 2FA aktif / wajib: kirim challenge token untuk langkah kedua
+	if result.ChallengeToken != "" {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Two-factor authentication required",
+			"data": gin.H{
+				"two_factor_required": true,
+				"enrollment_required": result.ChallengeType == jwt.TokenTypeTwoFactorEnroll,
+				"challenge_token":     result.ChallengeToken,
+				"expires_in":          result.ChallengeExpiresIn,
+			},
+		})
+		return
+	}
+
+	loginSuccessResponse(c, result, "Login successful", nil)
+}
+
+// loginErrorResponse 429 + Retry-After jika di-throttle, selain itu 401
+func loginErrorResponse(c *gin.Context, err error) {
+	var throttled *services.LoginThrottledError
+	if errors.As(err, &throttled) {
+		retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"success": false, "message": err.Error(), "retry_after": retryAfter})
 		return
 	}
+	c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": err.Error()})
+}
+
+func loginSuccessResponse(c *gin.Context, result *services.LoginResult, message string, extra gin.H) {
+	data := gin.H{
+		"user": buildUserResponse(result.User),
+		"token": gin.H{
+			"access_token":  result.AccessToken,
+			"refresh_token": result.RefreshToken,
+			"token_type":    "Bearer",
+			"expires_in":    getAccessExpire(),
+		},
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Login successful",
-		"data": gin.H{
-			"user": buildUserResponse(user),
-			"token": gin.H{
-				"access_token":  accessToken,
-				"refresh_token": refreshToken,
-				"token_type":    "Bearer",
-				"expires_in":    getAccessExpire(),
-			},
-		},
+		"message": message,
+		"data":    data,
 	})
 }
 
@@ -88,20 +129,20 @@ func RefreshToken(c *gin.Context, authService services.AuthService, userRepo rep
 		return
 	}
 
-	accessToken, newRefreshToken, err := authService.RefreshToken(req.RefreshToken)
+	accessToken, newRefreshToken, err := authService.RefreshToken(req.RefreshToken, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": err.Error()})
 		return
 	}
 
 	// ambil user dari token lama
-	userIDStr, err := jwt.ValidateToken(req.RefreshToken)
+	claims, err := jwt.ValidateRefreshToken(req.RefreshToken)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid refresh token"})
 		return
 	}
 
-	userUUID, err := uuid.Parse(userIDStr)
+	userUUID, err := uuid.Parse(claims.UserID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid user ID format"})
 		return
@@ -168,10 +209,137 @@ func Logout(c *gin.Context, authService services.AuthService) {
 		return
 	}
 
-	if err := authService.Logout(req.RefreshToken); err != nil {
+	claims, err := jwt.GetClaimsFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Unauthorized"})
+		return
+	}
+
+	if err := authService.Logout(req.RefreshToken, claims); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to logout"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Logout successful"})
 }
+
+// ===================== CHANGE PASSWORD =====================
+func ChangePassword(c *gin.Context, userService services.UserService) {
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request payload"})
+		return
+	}
+
+	userIDStr, err := jwt.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Unauthorized"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid user ID format"})
+		return
+	}
+
+	if err := userService.ChangePassword(userUUID, req.CurrentPassword, req.NewPassword); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Password changed successfully, please login again"})
+}
+
+// ===================== RESET PASSWORD =====================
+func ResetPassword(c *gin.Context, userService services.UserService) {
+	var req struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request payload"})
+		return
+	}
+
+	if err := userService.ResetPassword(req.Token, req.NewPassword); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Password reset successfully"})
+}
+
+// ===================== SESSIONS =====================
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userIDStr, err := jwt.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Unauthorized"})
+		return uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid user ID format"})
+		return uuid.Nil, false
+	}
+	return userUUID, true
+}
+
+func ListSessions(c *gin.Context, authService services.AuthService) {
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := authService.ListSessions(userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to fetch sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Sessions fetched successfully",
+		"data":    gin.H{"sessions": sessions},
+	})
+}
+
+func RevokeSession(c *gin.Context, authService services.AuthService) {
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid session ID format"})
+		return
+	}
+
+	if err := authService.RevokeSession(userUUID, sessionID); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Session revoked successfully"})
+}
+
+func RevokeAllSessions(c *gin.Context, authService services.AuthService) {
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := authService.RevokeAllSessions(userUUID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Signed out from all sessions"})
+}
@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"wms-be/infrastructure/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// requestActorID user yang melakukan request, dipakai untuk kolom created_by.
+// Untuk request dengan API key ini adalah service account milik key.
+func requestActorID(c *gin.Context) (uuid.UUID, error) {
+	userIDStr, err := jwt.GetUserIDFromContext(c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.Parse(userIDStr)
+}
@@ -0,0 +1,401 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"wms-be/domain/models"
+	"wms-be/domain/services"
+	"wms-be/infrastructure/middleware"
+	"wms-be/interfaces/http/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CustomerHandler struct {
+	customerService services.CustomerService
+}
+
+func NewCustomerHandler(customerService services.CustomerService) *CustomerHandler {
+	return &CustomerHandler{customerService: customerService}
+}
+
+type CustomerAddressResponse struct {
+	ID            string `json:"id"`
+	AddressType   string `json:"address_type"`
+	Label         string `json:"label,omitempty"`
+	RecipientName string `json:"recipient_name,omitempty"`
+	Phone         string `json:"phone,omitempty"`
+	Address       string `json:"address"`
+	City          string `json:"city,omitempty"`
+	Province      string `json:"province,omitempty"`
+	PostalCode    string `json:"postal_code,omitempty"`
+	Country       string `json:"country,omitempty"`
+	IsDefault     bool   `json:"is_default"`
+}
+
+type CustomerResponse struct {
+	ID            string                    `json:"id"`
+	Code          string                    `json:"code"`
+	Name          string                    `json:"name"`
+	ContactPerson string                    `json:"contact_person,omitempty"`
+	Email         string                    `json:"email,omitempty"`
+	Phone         string                    `json:"phone,omitempty"`
+	TaxID         string                    `json:"tax_id,omitempty"`
+	CreditLimit   float64                   `json:"credit_limit"`
+	IsActive      bool                      `json:"is_active"`
+	Notes         string                    `json:"notes,omitempty"`
+	Addresses     []CustomerAddressResponse `json:"addresses"`
+	CreatedAt     string                    `json:"created_at"`
+	UpdatedAt     string                    `json:"updated_at"`
+}
+
+type customerRequest struct {
+	Code          string                   `json:"code" binding:"required"`
+	Name          string                   `json:"name" binding:"required"`
+	ContactPerson string                   `json:"contact_person"`
+	Email         string                   `json:"email"`
+	Phone         string                   `json:"phone"`
+	TaxID         string                   `json:"tax_id"`
+	CreditLimit   float64                  `json:"credit_limit"`
+	IsActive      *bool                    `json:"is_active"` // default true saat create, tidak berubah saat update jika kosong
+	Notes         string                   `json:"notes"`
+	Addresses     []customerAddressRequest `json:"addresses"` // hanya dipakai saat create
+}
+
+type customerAddressRequest struct {
+	AddressType   string `json:"address_type" binding:"required"`
+	Label         string `json:"label"`
+	RecipientName string `json:"recipient_name"`
+	Phone         string `json:"phone"`
+	Address       string `json:"address" binding:"required"`
+	City          string `json:"city"`
+	Province      string `json:"province"`
+	PostalCode    string `json:"postal_code"`
+	Country       string `json:"country"`
+	IsDefault     bool   `json:"is_default"`
+}
+
+func (r customerAddressRequest) toModel(customerID uuid.UUID) models.CustomerAddress {
+	return models.CustomerAddress{
+		CustomerID:    customerID,
+		AddressType:   r.AddressType,
+		Label:         r.Label,
+		RecipientName: r.RecipientName,
+		Phone:         r.Phone,
+		Address:       r.Address,
+		City:          r.City,
+		Province:      r.Province,
+		PostalCode:    r.PostalCode,
+		Country:       r.Country,
+		IsDefault:     r.IsDefault,
+	}
+}
+
+func mapCustomerToResponse(customer models.Customer) CustomerResponse {
+	addresses := make([]CustomerAddressResponse, 0, len(customer.Addresses))
+	for _, a := range customer.Addresses {
+		addresses = append(addresses, CustomerAddressResponse{
+			ID:            a.ID.String(),
+			AddressType:   a.AddressType,
+			Label:         a.Label,
+			RecipientName: a.RecipientName,
+			Phone:         a.Phone,
+			Address:       a.Address,
+			City:          a.City,
+			Province:      a.Province,
+			PostalCode:    a.PostalCode,
+			Country:       a.Country,
+			IsDefault:     a.IsDefault,
+		})
+	}
+
+	return CustomerResponse{
+		ID:            customer.ID.String(),
+		Code:          customer.Code,
+		Name:          customer.Name,
+		ContactPerson: customer.ContactPerson,
+		Email:         customer.Email,
+		Phone:         customer.Phone,
+		TaxID:         customer.TaxID,
+		CreditLimit:   customer.CreditLimit,
+		IsActive:      customer.IsActive,
+		Notes:         customer.Notes,
+		Addresses:     addresses,
+		CreatedAt:     customer.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     customer.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// GET /customers
+func (h *CustomerHandler) GetCustomers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := make(map[string]interface{})
+	if search := c.Query("search"); search != "" {
+		filters["search"] = search
+	}
+	if active := c.Query("is_active"); active != "" {
+		isActive, err := strconv.ParseBool(active)
+		if err != nil {
+			response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+			return
+		}
+		filters["is_active"] = isActive
+	}
+
+	customers, total, err := h.customerService.GetCustomers(page, limit, filters)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]CustomerResponse, 0, len(customers))
+	for _, customer := range customers {
+		resp = append(resp, mapCustomerToResponse(customer))
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	response.PaginatedResponse(c, "customers", resp, int(total), page, limit)
+}
+
+// GET /customers/:id
+func (h *CustomerHandler) GetCustomerByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	customer, err := h.customerService.GetCustomerByID(id)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapCustomerToResponse(*customer), "Customer retrieved successfully")
+}
+
+// POST /customers
+func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
+	var req customerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	customer := models.Customer{
+		ID:            uuid.New(),
+		Code:          req.Code,
+		Name:          req.Name,
+		ContactPerson: req.ContactPerson,
+		Email:         req.Email,
+		Phone:         req.Phone,
+		TaxID:         req.TaxID,
+		CreditLimit:   req.CreditLimit,
+		IsActive:      req.IsActive == nil || *req.IsActive,
+		Notes:         req.Notes,
+	}
+	for _, a := range req.Addresses {
+		customer.Addresses = append(customer.Addresses, a.toModel(customer.ID))
+	}
+
+	created, err := h.customerService.CreateCustomer(&customer)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapCustomerToResponse(*created), "Customer created successfully")
+}
+
+// PUT /customers/:id
+func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	var req customerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.customerService.GetCustomerByID(id)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	customer := models.Customer{
+		ID:            id,
+		Code:          req.Code,
+		Name:          req.Name,
+		ContactPerson: req.ContactPerson,
+		Email:         req.Email,
+		Phone:         req.Phone,
+		TaxID:         req.TaxID,
+		CreditLimit:   req.CreditLimit,
+		IsActive:      existing.IsActive,
+		Notes:         req.Notes,
+	}
+	if req.IsActive != nil {
+		customer.IsActive = *req.IsActive
+	}
+
+	updated, err := h.customerService.UpdateCustomer(&customer)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapCustomerToResponse(*updated), "Customer updated successfully")
+}
+
+// DELETE /customers/:id
+func (h *CustomerHandler) DeleteCustomer(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.customerService.DeleteCustomer(id); err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessMessageResponse(c, "Customer deleted successfully")
+}
+
+// POST /customers/:id/addresses
+func (h *CustomerHandler) AddAddress(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	var req customerAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	address := req.toModel(id)
+	customer, err := h.customerService.AddAddress(&address)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapCustomerToResponse(*customer), "Customer address added successfully")
+}
+
+// PUT /customers/:id/addresses/:address_id
+func (h *CustomerHandler) UpdateAddress(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+	addressID, err := uuid.Parse(c.Param("address_id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	var req customerAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	address := req.toModel(id)
+	address.ID = addressID
+	customer, err := h.customerService.UpdateAddress(&address)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapCustomerToResponse(*customer), "Customer address updated successfully")
+}
+
+// DELETE /customers/:id/addresses/:address_id
+func (h *CustomerHandler) DeleteAddress(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+	addressID, err := uuid.Parse(c.Param("address_id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	customer, err := h.customerService.DeleteAddress(id, addressID)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapCustomerToResponse(*customer), "Customer address deleted successfully")
+}
+
+// GET /customers/:id/orders
+func (h *CustomerHandler) GetCustomerOrders(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	orders, total, err := h.customerService.GetCustomerOrders(id, page, limit, middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	resp := make([]OrderResponse, 0, len(orders))
+	for _, order := range orders {
+		resp = append(resp, mapOrderToResponse(order))
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	response.PaginatedResponse(c, "orders", resp, int(total), page, limit)
+}
+
+// GET /customers/:id/outstanding
+func (h *CustomerHandler) GetOutstanding(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	outstanding, err := h.customerService.GetOutstanding(id)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, outstanding, "Customer outstanding retrieved successfully")
+}
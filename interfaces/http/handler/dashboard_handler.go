@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 	"wms-be/domain/services"
+	"wms-be/infrastructure/middleware"
 	"wms-be/interfaces/http/response"
 
 	"github.com/gin-gonic/gin"
@@ -17,7 +18,7 @@ func NewDashboardHandler(service *services.DashboardService) *DashboardHandler {
 }
 
 func (h *DashboardHandler) GetDashboard(c *gin.Context) {
-	summary, err := h.service.GetDashboardSummary()
+	summary, err := h.service.GetDashboardSummary(middleware.GetWarehouseScope(c))
 	if err != nil {
 		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
 		return
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"wms-be/domain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorStatus memetakan error dari service ke HTTP status code,
+// fallback dipakai untuk error yang tidak dikenal
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, services.ErrWarehouseAccessDenied):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInsufficientStock):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, services.ErrConflict):
+		return http.StatusConflict
+	default:
+		return fallback
+	}
+}
+
+// insufficientStockResponse kirim 422 beserta detail stok jika err adalah InsufficientStockError
+// atau OrderAllocationError (detail per item order)
+func insufficientStockResponse(c *gin.Context, err error) bool {
+	var details any
+	var stockErr *services.InsufficientStockError
+	var allocationErr *services.OrderAllocationError
+	switch {
+	case errors.As(err, &stockErr):
+		details = stockErr
+	case errors.As(err, &allocationErr):
+		details = allocationErr.Lines
+	default:
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"success": false,
+		"error":   err.Error(),
+		"code":    "insufficient_stock",
+		"details": details,
+	})
+	return true
+}
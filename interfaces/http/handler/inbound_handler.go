@@ -4,7 +4,9 @@ import (
 	"strconv"
 	"time"
 	"wms-be/domain/models"
+	"wms-be/domain/repository"
 	"wms-be/domain/services"
+	"wms-be/infrastructure/middleware"
 	"wms-be/interfaces/http/response"
 
 	"github.com/gin-gonic/gin"
@@ -20,23 +22,36 @@ func NewInboundHandler(inboundService *services.InboundService) *InboundHandler
 }
 
 type InboundResponse struct {
-	ID              string  `json:"id"`
-	ProductID       string  `json:"product_id"`
-	ProductName     string  `json:"product_name"`
-	ProductSKU      string  `json:"product_sku"`
-	WarehouseID     string  `json:"warehouse_id"`
-	WarehouseName   string  `json:"warehouse_name"`
-	Quantity        int     `json:"quantity"`
-	SupplierName    string  `json:"supplier_name"`
-	SupplierContact string  `json:"supplier_contact,omitempty"`
-	ReferenceNumber string  `json:"reference_number,omitempty"`
-	UnitCost        float64 `json:"unit_cost,omitempty"`
-	TotalCost       float64 `json:"total_cost,omitempty"`
-	Notes           string  `json:"notes,omitempty"`
-	ReceivedDate    string  `json:"received_date"`
-	CreatedAt       string  `json:"created_at"`
-	CreatedBy       string  `json:"created_by"`
-	CreatedByName   string  `json:"created_by_name"`
+	ID                  string  `json:"id"`
+	ReceiptNumber       string  `json:"receipt_number"`
+	InboundReceiptID    *string `json:"inbound_receipt_id,omitempty"`
+	PurchaseOrderID     *string `json:"purchase_order_id,omitempty"`
+	PurchaseOrderLineID *string `json:"purchase_order_line_id,omitempty"`
+	ProductID           string  `json:"product_id"`
+	ProductName         string  `json:"product_name"`
+	ProductSKU          string  `json:"product_sku"`
+	WarehouseID         string  `json:"warehouse_id"`
+	WarehouseName       string  `json:"warehouse_name"`
+	Quantity            int     `json:"quantity"`
+	SupplierID          string  `json:"supplier_id"`
+	SupplierName        string  `json:"supplier_name"`
+	SupplierContact     string  `json:"supplier_contact,omitempty"`
+	ReferenceNumber     string  `json:"reference_number,omitempty"`
+	UnitCost            float64 `json:"unit_cost,omitempty"`
+	TotalCost           float64 `json:"total_cost,omitempty"`
+	Notes               string  `json:"notes,omitempty"`
+	OrderedDate         *string `json:"ordered_date,omitempty"`
+	ExpectedDate        *string `json:"expected_date,omitempty"`
+	ReceivedDate        string  `json:"received_date"`
+	CreatedAt           string  `json:"created_at"`
+	CreatedBy           string  `json:"created_by"`
+	CreatedByName       string  `json:"created_by_name"`
+}
+
+// InboundCreatedResponse inbound baru beserta item order backorder yang teralokasi dari stok ini
+type InboundCreatedResponse struct {
+	InboundResponse
+	BackorderAllocations []repository.BackorderAllocation `json:"backorder_allocations"`
 }
 
 func mapInboundToResponse(inbound models.Inbound) InboundResponse {
@@ -53,29 +68,36 @@ func mapInboundToResponse(inbound models.Inbound) InboundResponse {
 	}
 
 	createdByName := ""
-	if inbound.User != (models.User{}) {
+	if inbound.User.ID != uuid.Nil {
 		createdByName = inbound.User.Name
 	}
 
 	// Return the populated InboundResponse
 	return InboundResponse{
-		ID:              inbound.ID.String(),
-		ProductID:       inbound.ProductID.String(),
-		ProductName:     productName,
-		ProductSKU:      productSKU,
-		WarehouseID:     inbound.WarehouseID.String(),
-		WarehouseName:   warehouseName,
-		Quantity:        inbound.Quantity,
-		SupplierName:    inbound.SupplierName,
-		SupplierContact: inbound.SupplierContact,
-		ReferenceNumber: inbound.ReferenceNumber,
-		UnitCost:        inbound.UnitCost,
-		TotalCost:       inbound.TotalCost,
-		Notes:           inbound.Notes,
-		ReceivedDate:    inbound.ReceivedDate.Format(time.RFC3339),
-		CreatedAt:       inbound.CreatedAt.Format(time.RFC3339),
-		CreatedBy:       inbound.CreatedBy.String(),
-		CreatedByName:   createdByName,
+		ID:                  inbound.ID.String(),
+		ReceiptNumber:       inbound.ReceiptNumber,
+		InboundReceiptID:    optionalUUIDString(inbound.InboundReceiptID),
+		PurchaseOrderID:     optionalUUIDString(inbound.PurchaseOrderID),
+		PurchaseOrderLineID: optionalUUIDString(inbound.PurchaseOrderLineID),
+		ProductID:           inbound.ProductID.String(),
+		ProductName:         productName,
+		ProductSKU:          productSKU,
+		WarehouseID:         inbound.WarehouseID.String(),
+		WarehouseName:       warehouseName,
+		Quantity:            inbound.Quantity,
+		SupplierID:          inbound.SupplierID.String(),
+		SupplierName:        inbound.SupplierName,
+		SupplierContact:     inbound.SupplierContact,
+		ReferenceNumber:     inbound.ReferenceNumber,
+		UnitCost:            inbound.UnitCost,
+		TotalCost:           inbound.TotalCost,
+		Notes:               inbound.Notes,
+		OrderedDate:         optionalTimeString(inbound.OrderedDate),
+		ExpectedDate:        optionalTimeString(inbound.ExpectedDate),
+		ReceivedDate:        inbound.ReceivedDate.Format(time.RFC3339),
+		CreatedAt:           inbound.CreatedAt.Format(time.RFC3339),
+		CreatedBy:           inbound.CreatedBy.String(),
+		CreatedByName:       createdByName,
 	}
 }
 
@@ -83,11 +105,12 @@ func mapInboundToResponse(inbound models.Inbound) InboundResponse {
 func (h *InboundHandler) GetInbounds(c *gin.Context) {
 	search := c.Query("search")
 	warehouseId := c.Query("warehouseId")
+	supplierId := c.Query("supplierId")
 	pageStr := c.Query("page")
 	limitStr := c.Query("limit")
 
 	// === cek apakah ada parameter pagination/filter ===
-	isPaginated := pageStr != "" || limitStr != "" || search != "" || warehouseId != ""
+	isPaginated := pageStr != "" || limitStr != "" || search != "" || warehouseId != "" || supplierId != ""
 
 	var (
 		page, limit int
@@ -109,7 +132,7 @@ func (h *InboundHandler) GetInbounds(c *gin.Context) {
 		limit = 1000000 // jumlah sangat besar agar ambil semua
 	}
 
-	inbounds, total, err := h.inboundService.GetInbounds(search, warehouseId, page, limit)
+	inbounds, total, err := h.inboundService.GetInbounds(search, warehouseId, supplierId, page, limit, middleware.GetWarehouseScope(c))
 	if err != nil {
 		response.ErrorMessageResponse(c, err, 500)
 		return
@@ -130,16 +153,18 @@ func (h *InboundHandler) GetInbounds(c *gin.Context) {
 // POST /inbounds
 func (h *InboundHandler) CreateInbound(c *gin.Context) {
 	var req struct {
-		ProductID       string  `json:"product_id"`
-		WarehouseID     string  `json:"warehouse_id"`
-		Quantity        int     `json:"quantity"`
-		SupplierName    string  `json:"supplier_name"`
-		SupplierContact string  `json:"supplier_contact,omitempty"`
-		ReferenceNumber string  `json:"reference_number,omitempty"`
-		UnitCost        float64 `json:"unit_cost,omitempty"`
-		Notes           string  `json:"notes,omitempty"`
-		ReceivedDate    string  `json:"received_date"`
-		CreatedBy       string  `json:"created_by"`
+		PurchaseOrderLineID *uuid.UUID `json:"purchase_order_line_id,omitempty"` // product, gudang & supplier boleh kosong (diambil dari PO)
+		ProductID           string     `json:"product_id"`
+		WarehouseID         string     `json:"warehouse_id"`
+		Quantity            int        `json:"quantity"`
+		SupplierID          string     `json:"supplier_id"`
+		SupplierContact     string     `json:"supplier_contact,omitempty"` // kosong = contact person supplier
+		ReferenceNumber     string     `json:"reference_number,omitempty"`
+		UnitCost            float64    `json:"unit_cost,omitempty"`
+		Notes               string     `json:"notes,omitempty"`
+		OrderedDate         *time.Time `json:"ordered_date,omitempty"`
+		ExpectedDate        *time.Time `json:"expected_date,omitempty"` // kosong = ordered_date + lead time supplier
+		ReceivedDate        string     `json:"received_date"`
 	}
 
 	// Bind incoming JSON request to the struct
@@ -148,55 +173,237 @@ func (h *InboundHandler) CreateInbound(c *gin.Context) {
 		return
 	}
 
-	// Convert strings to UUIDs for ProductID, WarehouseID, and CreatedBy
-	productID, err := uuid.Parse(req.ProductID)
+	// Convert strings to UUIDs; tanpa PO ketiganya wajib diisi
+	var ids [3]uuid.UUID
+	for i, raw := range []string{req.ProductID, req.WarehouseID, req.SupplierID} {
+		if raw == "" && req.PurchaseOrderLineID != nil {
+			continue
+		}
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			response.ErrorMessageResponse(c, err, 400)
+			return
+		}
+		ids[i] = id
+	}
+	productID, warehouseID, supplierID := ids[0], ids[1], ids[2]
+
+	// CreatedBy selalu dari user yang login (service account jika memakai API key)
+	createdBy, err := requestActorID(c)
 	if err != nil {
-		response.ErrorMessageResponse(c, err, 400)
+		response.ErrorMessageResponse(c, err, 401)
 		return
 	}
 
-	warehouseID, err := uuid.Parse(req.WarehouseID)
+	// Parse the received date string into time.Time
+	receivedDate, err := time.Parse(time.RFC3339, req.ReceivedDate)
 	if err != nil {
 		response.ErrorMessageResponse(c, err, 400)
 		return
 	}
 
-	createdBy, err := uuid.Parse(req.CreatedBy)
+	// Create the Inbound model
+	inbound := models.Inbound{
+		ProductID:           productID,
+		WarehouseID:         warehouseID,
+		Quantity:            req.Quantity,
+		SupplierID:          supplierID,
+		SupplierContact:     req.SupplierContact,
+		PurchaseOrderLineID: req.PurchaseOrderLineID,
+		ReferenceNumber:     req.ReferenceNumber,
+		UnitCost:            req.UnitCost,
+		TotalCost:           req.UnitCost * float64(req.Quantity),
+		Notes:               req.Notes,
+		OrderedDate:         req.OrderedDate,
+		ExpectedDate:        req.ExpectedDate,
+		ReceivedDate:        receivedDate,
+		CreatedAt:           time.Now(),
+		CreatedBy:           createdBy,
+	}
+
+	// Create the inbound record
+	createdInbound, allocations, err := h.inboundService.CreateInbound(inbound, middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, 500))
+		return
+	}
+
+	// Respond with the created inbound record, beserta backorder yang teralokasi dari stok ini
+	if allocations == nil {
+		allocations = []repository.BackorderAllocation{}
+	}
+	response.SuccessResponse(c, InboundCreatedResponse{
+		InboundResponse:      mapInboundToResponse(createdInbound),
+		BackorderAllocations: allocations,
+	}, "Inbound created successfully")
+}
+
+type InboundReceiptResponse struct {
+	ID              string            `json:"id"`
+	ReceiptNumber   string            `json:"receipt_number"`
+	WarehouseID     string            `json:"warehouse_id"`
+	WarehouseName   string            `json:"warehouse_name"`
+	SupplierID      string            `json:"supplier_id"`
+	SupplierName    string            `json:"supplier_name"`
+	SupplierContact string            `json:"supplier_contact,omitempty"`
+	ReferenceNumber string            `json:"reference_number,omitempty"`
+	ReceivedDate    string            `json:"received_date"`
+	TotalQuantity   int               `json:"total_quantity"`
+	TotalCost       float64           `json:"total_cost"`
+	Notes           string            `json:"notes,omitempty"`
+	Lines           []InboundResponse `json:"lines"`
+	CreatedAt       string            `json:"created_at"`
+	CreatedBy       string            `json:"created_by"`
+	CreatedByName   string            `json:"created_by_name"`
+}
+
+// InboundReceiptCreatedResponse penerimaan baru beserta item order backorder yang teralokasi
+type InboundReceiptCreatedResponse struct {
+	InboundReceiptResponse
+	BackorderAllocations []repository.BackorderAllocation `json:"backorder_allocations"`
+}
+
+func mapInboundReceiptToResponse(receipt models.InboundReceipt) InboundReceiptResponse {
+	lines := make([]InboundResponse, 0, len(receipt.Lines))
+	for _, line := range receipt.Lines {
+		// line tidak di-preload gudang / user, sama dengan header
+		line.Warehouse = receipt.Warehouse
+		line.User = receipt.User
+		lines = append(lines, mapInboundToResponse(line))
+	}
+
+	return InboundReceiptResponse{
+		ID:              receipt.ID.String(),
+		ReceiptNumber:   receipt.ReceiptNumber,
+		WarehouseID:     receipt.WarehouseID.String(),
+		WarehouseName:   receipt.Warehouse.Name,
+		SupplierID:      receipt.SupplierID.String(),
+		SupplierName:    receipt.SupplierName,
+		SupplierContact: receipt.SupplierContact,
+		ReferenceNumber: receipt.ReferenceNumber,
+		ReceivedDate:    receipt.ReceivedDate.Format(time.RFC3339),
+		TotalQuantity:   receipt.TotalQuantity,
+		TotalCost:       receipt.TotalCost,
+		Notes:           receipt.Notes,
+		Lines:           lines,
+		CreatedAt:       receipt.CreatedAt.Format(time.RFC3339),
+		CreatedBy:       receipt.CreatedBy.String(),
+		CreatedByName:   receipt.User.Name,
+	}
+}
+
+// GET /inbound_receipts
+func (h *InboundHandler) GetReceipts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := make(map[string]interface{})
+	for _, key := range []string{"search", "warehouse_id", "supplier_id"} {
+		if v := c.Query(key); v != "" {
+			filters[key] = v
+		}
+	}
+
+	receipts, total, err := h.inboundService.GetReceipts(page, limit, filters, middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, 500)
+		return
+	}
+
+	resp := make([]InboundReceiptResponse, 0, len(receipts))
+	for _, receipt := range receipts {
+		resp = append(resp, mapInboundReceiptToResponse(receipt))
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	response.PaginatedResponse(c, "inbound_receipts", resp, int(total), page, limit)
+}
+
+// GET /inbound_receipts/:id
+func (h *InboundHandler) GetReceiptByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		response.ErrorMessageResponse(c, err, 400)
 		return
 	}
 
-	// Parse the received date string into time.Time
-	receivedDate, err := time.Parse(time.RFC3339, req.ReceivedDate)
+	receipt, err := h.inboundService.GetReceiptByID(id, middleware.GetWarehouseScope(c))
 	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, 500))
+		return
+	}
+
+	response.SuccessResponse(c, mapInboundReceiptToResponse(*receipt), "Inbound receipt retrieved successfully")
+}
+
+// POST /inbound_receipts
+func (h *InboundHandler) CreateReceipt(c *gin.Context) {
+	var req struct {
+		WarehouseID     uuid.UUID `json:"warehouse_id" binding:"required"`
+		SupplierID      uuid.UUID `json:"supplier_id" binding:"required"`
+		SupplierContact string    `json:"supplier_contact,omitempty"` // kosong = contact person supplier
+		ReferenceNumber string    `json:"reference_number,omitempty"` // nomor surat jalan, berlaku untuk semua line
+		ReceivedDate    time.Time `json:"received_date" binding:"required"`
+		Notes           string    `json:"notes,omitempty"`
+		Lines           []struct {
+			PurchaseOrderLineID *uuid.UUID `json:"purchase_order_line_id,omitempty"` // product boleh kosong (diambil dari PO)
+			ProductID           uuid.UUID  `json:"product_id"`
+			Quantity            int        `json:"quantity" binding:"required"`
+			UnitCost            float64    `json:"unit_cost,omitempty"`
+			Notes               string     `json:"notes,omitempty"`
+			OrderedDate         *time.Time `json:"ordered_date,omitempty"`
+			ExpectedDate        *time.Time `json:"expected_date,omitempty"`
+		} `json:"lines" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
 		response.ErrorMessageResponse(c, err, 400)
 		return
 	}
 
-	// Create the Inbound model
-	inbound := models.Inbound{
-		ProductID:       productID,
-		WarehouseID:     warehouseID,
-		Quantity:        req.Quantity,
-		SupplierName:    req.SupplierName,
+	createdBy, err := requestActorID(c)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, 401)
+		return
+	}
+
+	receipt := models.InboundReceipt{
+		WarehouseID:     req.WarehouseID,
+		SupplierID:      req.SupplierID,
 		SupplierContact: req.SupplierContact,
 		ReferenceNumber: req.ReferenceNumber,
-		UnitCost:        req.UnitCost,
-		TotalCost:       req.UnitCost * float64(req.Quantity),
+		ReceivedDate:    req.ReceivedDate,
 		Notes:           req.Notes,
-		ReceivedDate:    receivedDate,
-		CreatedAt:       time.Now(),
 		CreatedBy:       createdBy,
+		CreatedAt:       time.Now(),
+	}
+	for _, l := range req.Lines {
+		receipt.Lines = append(receipt.Lines, models.Inbound{
+			PurchaseOrderLineID: l.PurchaseOrderLineID,
+			ProductID:           l.ProductID,
+			Quantity:            l.Quantity,
+			UnitCost:            l.UnitCost,
+			Notes:               l.Notes,
+			OrderedDate:         l.OrderedDate,
+			ExpectedDate:        l.ExpectedDate,
+		})
 	}
 
-	// Create the inbound record
-	createdInbound, err := h.inboundService.CreateInbound(inbound)
+	created, allocations, err := h.inboundService.CreateReceipt(receipt, middleware.GetWarehouseScope(c))
 	if err != nil {
-		response.ErrorMessageResponse(c, err, 500)
+		response.ErrorMessageResponse(c, err, errorStatus(err, 500))
 		return
 	}
 
-	// Respond with the created inbound record
-	response.SuccessResponse(c, mapInboundToResponse(createdInbound), "Inbound created successfully")
+	if allocations == nil {
+		allocations = []repository.BackorderAllocation{}
+	}
+	response.SuccessResponse(c, InboundReceiptCreatedResponse{
+		InboundReceiptResponse: mapInboundReceiptToResponse(*created),
+		BackorderAllocations:   allocations,
+	}, "Inbound receipt created successfully")
 }
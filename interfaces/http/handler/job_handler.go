@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+	"wms-be/domain/services"
+	"wms-be/interfaces/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler status & trigger manual job background
+type JobHandler struct {
+	orderExpiry *services.OrderExpiryScheduler
+}
+
+func NewJobHandler(orderExpiry *services.OrderExpiryScheduler) *JobHandler {
+	return &JobHandler{orderExpiry: orderExpiry}
+}
+
+// GET /jobs/order_expiry
+func (h *JobHandler) GetOrderExpiryStatus(c *gin.Context) {
+	response.SuccessResponse(c, h.orderExpiry.Status(), "Order expiry status fetched successfully")
+}
+
+// POST /jobs/order_expiry/run
+func (h *JobHandler) RunOrderExpiry(c *gin.Context) {
+	status, err := h.orderExpiry.RunOnce(c.Request.Context())
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	message := "Order expiry run completed"
+	if status.LastLockHeld {
+		message = "Order expiry is already running on another instance"
+	}
+	response.SuccessResponse(c, status, message)
+}
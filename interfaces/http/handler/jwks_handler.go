@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"net/http"
+	"wms-be/infrastructure/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS handles GET /.well-known/jwks.json
+// Format standar JWKS (tanpa wrapper success/data) agar bisa dibaca library JWT service lain.
+func GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwt.PublicJWKS())
+}
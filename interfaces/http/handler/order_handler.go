@@ -1,11 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 	"wms-be/domain/models"
 	"wms-be/domain/services"
+	"wms-be/infrastructure/middleware"
 	"wms-be/interfaces/http/response"
 
 	"github.com/gin-gonic/gin"
@@ -29,23 +31,54 @@ type OrderItemResponse struct {
 	Quantity    int     `json:"quantity"`
 	UnitPrice   float64 `json:"unit_price"`
 	TotalPrice  float64 `json:"total_price"`
+	Allocated   int     `json:"allocated_quantity"`
+	Picked      int     `json:"picked_quantity"`
+	Shipped     int     `json:"shipped_quantity"`
+	Backordered int     `json:"backordered_quantity"`
 	CreatedAt   string  `json:"created_at"`
 }
 
+type OrderShipmentItemResponse struct {
+	OrderItemID string `json:"order_item_id"`
+	ProductID   string `json:"product_id"`
+	Quantity    int    `json:"quantity"`
+}
+
+type OrderShipmentResponse struct {
+	ID             string                      `json:"id"`
+	ShipmentNumber string                      `json:"shipment_number"`
+	Notes          string                      `json:"notes,omitempty"`
+	ShippedBy      string                      `json:"shipped_by"`
+	ShippedAt      string                      `json:"shipped_at"`
+	Items          []OrderShipmentItemResponse `json:"items"`
+}
+
 type OrderResponse struct {
-	ID            string              `json:"id"`
-	OrderNumber   string              `json:"order_number"`
-	CustomerID    string              `json:"customer_id"`
-	CustomerName  string              `json:"customer_name"`
-	Status        string              `json:"status"`
-	TotalAmount   float64             `json:"total_amount"`
-	WarehouseID   string              `json:"warehouse_id"`
-	WarehouseName string              `json:"warehouse_name"`
-	Notes         string              `json:"notes"`
-	ExpiresAt     string              `json:"expires_at"`
-	CreatedAt     string              `json:"created_at"`
-	UpdatedAt     string              `json:"updated_at"`
-	Items         []OrderItemResponse `json:"items"`
+	ID                string                  `json:"id"`
+	OrderNumber       string                  `json:"order_number"`
+	CustomerID        string                  `json:"customer_id"`
+	CustomerCode      string                  `json:"customer_code"`
+	CustomerName      string                  `json:"customer_name"`
+	ShippingAddressID *string                 `json:"shipping_address_id"`
+	ShippingAddress   string                  `json:"shipping_address"`
+	BillingAddressID  *string                 `json:"billing_address_id"`
+	BillingAddress    string                  `json:"billing_address"`
+	Status            string                  `json:"status"`
+	TotalAmount       float64                 `json:"total_amount"`
+	WarehouseID       string                  `json:"warehouse_id"`
+	WarehouseName     string                  `json:"warehouse_name"`
+	Notes             string                  `json:"notes"`
+	ExpiresAt         string                  `json:"expires_at"`
+	CreatedAt         string                  `json:"created_at"`
+	UpdatedAt         string                  `json:"updated_at"`
+	Items             []OrderItemResponse     `json:"items"`
+	Shipments         []OrderShipmentResponse `json:"shipments"`
+}
+
+// OrderCreatedResponse order baru beserta hasil alokasi stok per item
+type OrderCreatedResponse struct {
+	OrderResponse
+	Allocation []services.OrderLineAllocation `json:"allocation"`
 }
 
 // mapOrderToResponse konversi Order ke OrderResponse
@@ -65,29 +98,59 @@ func mapOrderToResponse(order models.Order) OrderResponse {
 			Quantity:    i.Quantity,
 			UnitPrice:   i.UnitPrice,
 			TotalPrice:  i.TotalPrice,
+			Allocated:   i.AllocatedQuantity,
+			Picked:      i.PickedQuantity,
+			Shipped:     i.ShippedQuantity,
+			Backordered: i.BackorderedQuantity,
 			CreatedAt:   i.CreatedAt.Format(time.RFC3339),
 		})
 	}
 
+	shipments := make([]OrderShipmentResponse, 0)
+	for _, sh := range order.Shipments {
+		shipmentItems := make([]OrderShipmentItemResponse, 0, len(sh.Items))
+		for _, si := range sh.Items {
+			shipmentItems = append(shipmentItems, OrderShipmentItemResponse{
+				OrderItemID: si.OrderItemID.String(),
+				ProductID:   si.ProductID.String(),
+				Quantity:    si.Quantity,
+			})
+		}
+		shipments = append(shipments, OrderShipmentResponse{
+			ID:             sh.ID.String(),
+			ShipmentNumber: sh.ShipmentNumber,
+			Notes:          sh.Notes,
+			ShippedBy:      sh.ShippedBy.String(),
+			ShippedAt:      sh.ShippedAt.Format(time.RFC3339),
+			Items:          shipmentItems,
+		})
+	}
+
 	warehouseName := ""
 	if order.Warehouse != (models.Warehouse{}) {
 		warehouseName = order.Warehouse.Name
 	}
 
 	return OrderResponse{
-		ID:            order.ID.String(),
-		OrderNumber:   order.OrderNumber,
-		CustomerID:    order.CustomerID,
-		CustomerName:  order.CustomerName,
-		Status:        order.Status,
-		TotalAmount:   order.TotalAmount,
-		WarehouseID:   order.WarehouseID.String(),
-		WarehouseName: warehouseName,
-		Notes:         order.Notes,
-		ExpiresAt:     order.ExpiresAt.Format(time.RFC3339),
-		CreatedAt:     order.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:     order.UpdatedAt.Format(time.RFC3339),
-		Items:         items,
+		ID:                order.ID.String(),
+		OrderNumber:       order.OrderNumber,
+		CustomerID:        order.CustomerID.String(),
+		CustomerCode:      order.CustomerCode,
+		CustomerName:      order.CustomerName,
+		ShippingAddressID: optionalUUIDString(order.ShippingAddressID),
+		ShippingAddress:   order.ShippingAddress,
+		BillingAddressID:  optionalUUIDString(order.BillingAddressID),
+		BillingAddress:    order.BillingAddress,
+		Status:            order.Status,
+		TotalAmount:       order.TotalAmount,
+		WarehouseID:       order.WarehouseID.String(),
+		WarehouseName:     warehouseName,
+		Notes:             order.Notes,
+		ExpiresAt:         order.ExpiresAt.Format(time.RFC3339),
+		CreatedAt:         order.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:         order.UpdatedAt.Format(time.RFC3339),
+		Items:             items,
+		Shipments:         shipments,
 	}
 }
 
@@ -113,7 +176,7 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 		filters["warehouse_id"] = warehouseId
 	}
 
-	orders, total, err := h.OrderService.GetOrders(page, limit, filters)
+	orders, total, err := h.OrderService.GetOrders(page, limit, filters, middleware.GetWarehouseScope(c))
 	if err != nil {
 		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
 		return
@@ -131,9 +194,9 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 // GET /orders/:id
 func (h *OrderHandler) GetOrderByID(c *gin.Context) {
 	id := c.Param("id")
-	order, err := h.OrderService.GetOrderByID(id)
+	order, err := h.OrderService.GetOrderByID(id, middleware.GetWarehouseScope(c))
 	if err != nil {
-		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -143,16 +206,17 @@ func (h *OrderHandler) GetOrderByID(c *gin.Context) {
 // POST /orders
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	var orderRequest struct {
-		OrderNumber  string `json:"order_number"`
-		CustomerID   string `json:"customer_id"`
-		CustomerName string `json:"customer_name"`
-		WarehouseID  string `json:"warehouse_id"`
-		Items        []struct {
+		CustomerID        uuid.UUID  `json:"customer_id" binding:"required"`
+		ShippingAddressID *uuid.UUID `json:"shipping_address_id"` // kosong = alamat default customer
+		BillingAddressID  *uuid.UUID `json:"billing_address_id"`
+		WarehouseID       string     `json:"warehouse_id"`
+		Items             []struct {
 			ProductID string `json:"product_id"`
 			Quantity  int    `json:"quantity"`
 		} `json:"items"`
-		Notes     string    `json:"notes"`
-		ExpiresAt time.Time `json:"expires_at"`
+		Notes        string    `json:"notes"`
+		ExpiresAt    time.Time `json:"expires_at"`
+		AllowPartial bool      `json:"allow_partial"` // kekurangan stok dicatat sebagai backorder
 	}
 
 	if err := c.ShouldBindJSON(&orderRequest); err != nil {
@@ -167,12 +231,12 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	}
 
 	order := &models.Order{
-		OrderNumber:  orderRequest.OrderNumber,
-		CustomerID:   orderRequest.CustomerID,
-		CustomerName: orderRequest.CustomerName,
-		WarehouseID:  warehouseUUID,
-		Notes:        orderRequest.Notes,
-		ExpiresAt:    orderRequest.ExpiresAt,
+		CustomerID:        orderRequest.CustomerID,
+		ShippingAddressID: orderRequest.ShippingAddressID,
+		BillingAddressID:  orderRequest.BillingAddressID,
+		WarehouseID:       warehouseUUID,
+		Notes:             orderRequest.Notes,
+		ExpiresAt:         orderRequest.ExpiresAt,
 	}
 
 	for _, item := range orderRequest.Items {
@@ -188,13 +252,19 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		})
 	}
 
-	createdOrder, err := h.OrderService.CreateOrder(order)
+	createdOrder, allocation, err := h.OrderService.CreateOrder(order, orderRequest.AllowPartial, middleware.GetWarehouseScope(c))
 	if err != nil {
-		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		if insufficientStockResponse(c, err) {
+			return
+		}
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
-	response.SuccessResponse(c, mapOrderToResponse(*createdOrder), "Order created successfully")
+	response.SuccessResponse(c, OrderCreatedResponse{
+		OrderResponse: mapOrderToResponse(*createdOrder),
+		Allocation:    allocation,
+	}, "Order created successfully")
 }
 
 // PUT /orders/:id/status
@@ -209,11 +279,198 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	order, err := h.OrderService.UpdateOrderStatus(id, statusUpdate.Status)
+	// pembatalan order butuh permission khusus
+	if statusUpdate.Status == models.OrderStatusCancelled && !middleware.HasPermission(c, models.PermissionOrderCancel) {
+		response.ErrorMessageResponse(c, errors.New("you do not have permission to cancel orders"), http.StatusForbidden)
+		return
+	}
+
+	userID, err := requestActorID(c)
 	if err != nil {
-		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		response.ErrorMessageResponse(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	order, err := h.OrderService.UpdateOrderStatus(id, statusUpdate.Status, userID, middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
 	response.SuccessResponse(c, mapOrderToResponse(*order), "Order status updated successfully")
 }
+
+// orderLinesRequest body pick / shipment: quantity per item order
+type orderLinesRequest struct {
+	Items []struct {
+		OrderItemID string `json:"order_item_id"`
+		Quantity    int    `json:"quantity"`
+	} `json:"items"`
+	Notes string `json:"notes"`
+}
+
+func (r orderLinesRequest) lines() ([]services.OrderLineQuantity, error) {
+	lines := make([]services.OrderLineQuantity, 0, len(r.Items))
+	for _, item := range r.Items {
+		itemID, err := uuid.Parse(item.OrderItemID)
+		if err != nil {
+			return nil, errors.New("invalid order_item_id")
+		}
+		lines = append(lines, services.OrderLineQuantity{OrderItemID: itemID, Quantity: item.Quantity})
+	}
+	return lines, nil
+}
+
+// POST /orders/:id/pick
+func (h *OrderHandler) PickOrderItems(c *gin.Context) {
+	var req orderLinesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+	lines, err := req.lines()
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.OrderService.PickOrderItems(c.Param("id"), lines, middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapOrderToResponse(*order), "Order items picked successfully")
+}
+
+// POST /orders/:id/shipments
+// items kosong berarti kirim semua quantity yang teralokasi
+func (h *OrderHandler) ShipOrder(c *gin.Context) {
+	var req orderLinesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+	lines, err := req.lines()
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	userID, err := requestActorID(c)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	order, err := h.OrderService.ShipOrder(c.Param("id"), userID, lines, req.Notes, middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapOrderToResponse(*order), "Order shipment created successfully")
+}
+
+// orderItemRequest body tambah / ubah item order
+type orderItemRequest struct {
+	ProductID    string `json:"product_id"`
+	Quantity     int    `json:"quantity"`
+	AllowPartial bool   `json:"allow_partial"` // kekurangan stok dicatat sebagai backorder
+}
+
+// POST /orders/:id/items
+func (h *OrderHandler) AddOrderItem(c *gin.Context) {
+	var req orderItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+	productID, err := uuid.Parse(req.ProductID)
+	if err != nil {
+		response.ErrorMessageResponse(c, errors.New("invalid product_id"), http.StatusBadRequest)
+		return
+	}
+	userID, err := requestActorID(c)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	order, err := h.OrderService.AddOrderItem(c.Param("id"), productID, req.Quantity, req.AllowPartial, userID, middleware.GetWarehouseScope(c))
+	if err != nil {
+		if insufficientStockResponse(c, err) {
+			return
+		}
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapOrderToResponse(*order), "Order item added successfully")
+}
+
+// PUT /orders/:id/items/:item_id
+func (h *OrderHandler) UpdateOrderItem(c *gin.Context) {
+	var req orderItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+	itemID, err := uuid.Parse(c.Param("item_id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, errors.New("invalid order item ID"), http.StatusBadRequest)
+		return
+	}
+	userID, err := requestActorID(c)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	order, err := h.OrderService.UpdateOrderItem(c.Param("id"), itemID, req.Quantity, req.AllowPartial, userID, middleware.GetWarehouseScope(c))
+	if err != nil {
+		if insufficientStockResponse(c, err) {
+			return
+		}
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapOrderToResponse(*order), "Order item updated successfully")
+}
+
+// DELETE /orders/:id/items/:item_id
+func (h *OrderHandler) RemoveOrderItem(c *gin.Context) {
+	itemID, err := uuid.Parse(c.Param("item_id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, errors.New("invalid order item ID"), http.StatusBadRequest)
+		return
+	}
+	userID, err := requestActorID(c)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	order, err := h.OrderService.RemoveOrderItem(c.Param("id"), itemID, userID, middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapOrderToResponse(*order), "Order item removed successfully")
+}
+
+// GET /orders/:id/item_changes
+func (h *OrderHandler) GetOrderItemChanges(c *gin.Context) {
+	changes, err := h.OrderService.GetOrderItemChanges(c.Param("id"), middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	if changes == nil {
+		changes = []models.OrderItemChange{}
+	}
+
+	response.SuccessResponse(c, changes, "Order item changes retrieved successfully")
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"strconv"
 	"time"
 	"wms-be/domain/models"
 	"wms-be/domain/services"
+	"wms-be/infrastructure/middleware"
 	"wms-be/interfaces/http/response"
 
 	"github.com/gin-gonic/gin"
@@ -38,6 +40,8 @@ type OutboundResponse struct {
 	CreatedAt          string  `json:"created_at"`
 	CreatedBy          string  `json:"created_by"`
 	CreatedByName      string  `json:"created_by_name"`
+	StockOverride      bool    `json:"stock_override"`
+	OverrideReason     string  `json:"override_reason,omitempty"`
 }
 
 func mapOutboundToResponse(outbound models.Outbound) OutboundResponse {
@@ -60,6 +64,8 @@ func mapOutboundToResponse(outbound models.Outbound) OutboundResponse {
 		CreatedAt:          outbound.CreatedAt.Format(time.RFC3339),
 		CreatedBy:          outbound.CreatedBy.String(),
 		CreatedByName:      outbound.User.Name,
+		StockOverride:      outbound.StockOverride,
+		OverrideReason:     outbound.OverrideReason,
 	}
 }
 
@@ -93,7 +99,7 @@ func (h *OutboundHandler) GetOutbounds(c *gin.Context) {
 		limit = 1000000 // jumlah sangat besar agar ambil semua
 	}
 
-	outbounds, total, err := h.outboundService.GetOutbounds(search, warehouseId, page, limit)
+	outbounds, total, err := h.outboundService.GetOutbounds(search, warehouseId, page, limit, middleware.GetWarehouseScope(c))
 	if err != nil {
 		response.ErrorMessageResponse(c, err, 500)
 		return
@@ -124,7 +130,9 @@ func (h *OutboundHandler) CreateOutbound(c *gin.Context) {
 		UnitPrice          float64 `json:"unit_price,omitempty"`
 		Notes              string  `json:"notes,omitempty"`
 		ShippedDate        string  `json:"shipped_date"`
-		CreatedBy          string  `json:"created_by"`
+		// override validasi stok, khusus admin (stock:adjust) dan wajib disertai alasan
+		OverrideStockCheck bool   `json:"override_stock_check,omitempty"`
+		OverrideReason     string `json:"override_reason,omitempty"`
 	}
 
 	// Bind incoming JSON request to the struct
@@ -133,7 +141,7 @@ func (h *OutboundHandler) CreateOutbound(c *gin.Context) {
 		return
 	}
 
-	// Convert strings to UUIDs for ProductID, WarehouseID, and CreatedBy
+	// Convert strings to UUIDs for ProductID and WarehouseID
 	productID, err := uuid.Parse(req.ProductID)
 	if err != nil {
 		response.ErrorMessageResponse(c, err, 400)
@@ -146,9 +154,15 @@ func (h *OutboundHandler) CreateOutbound(c *gin.Context) {
 		return
 	}
 
-	createdBy, err := uuid.Parse(req.CreatedBy)
+	if req.OverrideStockCheck && !middleware.HasPermission(c, models.PermissionStockAdjust) {
+		response.ErrorMessageResponse(c, errors.New("only admins can override the stock check"), 403)
+		return
+	}
+
+	// CreatedBy selalu dari user yang login (service account jika memakai API key)
+	createdBy, err := requestActorID(c)
 	if err != nil {
-		response.ErrorMessageResponse(c, err, 400)
+		response.ErrorMessageResponse(c, err, 401)
 		return
 	}
 
@@ -173,12 +187,17 @@ func (h *OutboundHandler) CreateOutbound(c *gin.Context) {
 		ShippedDate:        receivedDate,
 		CreatedAt:          time.Now(),
 		CreatedBy:          createdBy,
+		StockOverride:      req.OverrideStockCheck,
+		OverrideReason:     req.OverrideReason,
 	}
 
 	// Create the outbound record
-	createdOutbound, err := h.outboundService.CreateOutbound(outbound)
+	createdOutbound, err := h.outboundService.CreateOutbound(outbound, middleware.GetWarehouseScope(c))
 	if err != nil {
-		response.ErrorMessageResponse(c, err, 500)
+		if insufficientStockResponse(c, err) {
+			return
+		}
+		response.ErrorMessageResponse(c, err, errorStatus(err, 500))
 		return
 	}
 
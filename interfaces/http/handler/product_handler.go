@@ -5,6 +5,7 @@ import (
 	"time"
 	"wms-be/domain/models"
 	"wms-be/domain/services"
+	"wms-be/infrastructure/middleware"
 	"wms-be/interfaces/http/response"
 
 	"github.com/gin-gonic/gin"
@@ -86,7 +87,7 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 		limit = 1000000 // jumlah sangat besar agar ambil semua
 	}
 
-	products, total, err := h.productService.GetProducts(search, warehouseId, category, page, limit)
+	products, total, err := h.productService.GetProducts(search, warehouseId, category, page, limit, middleware.GetWarehouseScope(c))
 	if err != nil {
 		response.ErrorMessageResponse(c, err, 500)
 		return
@@ -136,9 +137,9 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		WarehouseID:   uuid.MustParse(req.WarehouseID),
 	}
 
-	createdProduct, err := h.productService.CreateProduct(product)
+	createdProduct, err := h.productService.CreateProduct(product, middleware.GetWarehouseScope(c))
 	if err != nil {
-		response.ErrorMessageResponse(c, err, 500)
+		response.ErrorMessageResponse(c, err, errorStatus(err, 500))
 		return
 	}
 
@@ -148,9 +149,9 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 // GET /products/:id
 func (h *ProductHandler) GetProductByID(c *gin.Context) {
 	id := c.Param("id")
-	product, err := h.productService.GetProductByID(id)
+	product, err := h.productService.GetProductByID(id, middleware.GetWarehouseScope(c))
 	if err != nil {
-		response.ErrorMessageResponse(c, err, 500)
+		response.ErrorMessageResponse(c, err, errorStatus(err, 500))
 		return
 	}
 
@@ -194,9 +195,9 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		product.WarehouseID = uuid.MustParse(req.WarehouseID)
 	}
 
-	updatedProduct, err := h.productService.UpdateProduct(id, product)
+	updatedProduct, err := h.productService.UpdateProduct(id, product, middleware.GetWarehouseScope(c))
 	if err != nil {
-		response.ErrorMessageResponse(c, err, 500)
+		response.ErrorMessageResponse(c, err, errorStatus(err, 500))
 		return
 	}
 
@@ -206,8 +207,8 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 // DELETE /products/:id
 func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	id := c.Param("id")
-	if err := h.productService.DeleteProduct(id); err != nil {
-		response.ErrorMessageResponse(c, err, 500)
+	if err := h.productService.DeleteProduct(id, middleware.GetWarehouseScope(c)); err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, 500))
 		return
 	}
 
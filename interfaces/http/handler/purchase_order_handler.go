@@ -0,0 +1,317 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"wms-be/domain/models"
+	"wms-be/domain/services"
+	"wms-be/infrastructure/middleware"
+	"wms-be/interfaces/http/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PurchaseOrderHandler struct {
+	purchaseOrderService services.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(purchaseOrderService services.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{purchaseOrderService: purchaseOrderService}
+}
+
+type PurchaseOrderLineResponse struct {
+	ID                  string  `json:"id"`
+	ProductID           string  `json:"product_id"`
+	ProductName         string  `json:"product_name"`
+	ProductSKU          string  `json:"product_sku"`
+	OrderedQuantity     int     `json:"ordered_quantity"`
+	ReceivedQuantity    int     `json:"received_quantity"`
+	OutstandingQuantity int     `json:"outstanding_quantity"`
+	MaxReceivable       int     `json:"max_receivable_quantity"` // batas total penerimaan dengan toleransi
+	Fulfilled           bool    `json:"fulfilled"`
+	UnitCost            float64 `json:"unit_cost"`
+	TotalCost           float64 `json:"total_cost"`
+	Notes               string  `json:"notes,omitempty"`
+}
+
+type PurchaseOrderReceiptResponse struct {
+	InboundID           string  `json:"inbound_id"`
+	ReceiptNumber       string  `json:"receipt_number"`
+	PurchaseOrderLineID *string `json:"purchase_order_line_id,omitempty"`
+	ProductID           string  `json:"product_id"`
+	Quantity            int     `json:"quantity"`
+	ReferenceNumber     string  `json:"reference_number,omitempty"`
+	ReceivedDate        string  `json:"received_date"`
+	CreatedBy           string  `json:"created_by"`
+}
+
+type PurchaseOrderResponse struct {
+	ID                    string                         `json:"id"`
+	PONumber              string                         `json:"po_number"`
+	Status                string                         `json:"status"`
+	SupplierID            string                         `json:"supplier_id"`
+	SupplierCode          string                         `json:"supplier_code"`
+	SupplierName          string                         `json:"supplier_name"`
+	WarehouseID           string                         `json:"warehouse_id"`
+	WarehouseName         string                         `json:"warehouse_name"`
+	OrderDate             string                         `json:"order_date"`
+	ExpectedDate          *string                        `json:"expected_date,omitempty"`
+	OverReceiptTolerance  float64                        `json:"over_receipt_tolerance"`
+	UnderReceiptTolerance float64                        `json:"under_receipt_tolerance"`
+	TotalAmount           float64                        `json:"total_amount"`
+	ReferenceNumber       string                         `json:"reference_number,omitempty"`
+	Notes                 string                         `json:"notes,omitempty"`
+	CreatedBy             string                         `json:"created_by"`
+	ClosedBy              *string                        `json:"closed_by,omitempty"`
+	ClosedAt              *string                        `json:"closed_at,omitempty"`
+	CloseReason           string                         `json:"close_reason,omitempty"`
+	CancelledBy           *string                        `json:"cancelled_by,omitempty"`
+	CancelledAt           *string                        `json:"cancelled_at,omitempty"`
+	CancelReason          string                         `json:"cancel_reason,omitempty"`
+	Lines                 []PurchaseOrderLineResponse    `json:"lines"`
+	Receipts              []PurchaseOrderReceiptResponse `json:"receipts,omitempty"`
+	CreatedAt             string                         `json:"created_at"`
+	UpdatedAt             string                         `json:"updated_at"`
+}
+
+func mapPurchaseOrderToResponse(order models.PurchaseOrder) PurchaseOrderResponse {
+	lines := make([]PurchaseOrderLineResponse, 0, len(order.Lines))
+	for _, l := range order.Lines {
+		lines = append(lines, PurchaseOrderLineResponse{
+			ID:                  l.ID.String(),
+			ProductID:           l.ProductID.String(),
+			ProductName:         l.Product.Name,
+			ProductSKU:          l.Product.SKU,
+			OrderedQuantity:     l.OrderedQuantity,
+			ReceivedQuantity:    l.ReceivedQuantity,
+			OutstandingQuantity: l.OutstandingQuantity(),
+			MaxReceivable:       l.MaxReceivableQuantity(order.OverReceiptTolerance),
+			Fulfilled:           l.IsFulfilled(order.UnderReceiptTolerance),
+			UnitCost:            l.UnitCost,
+			TotalCost:           l.TotalCost,
+			Notes:               l.Notes,
+		})
+	}
+
+	var receipts []PurchaseOrderReceiptResponse
+	for _, r := range order.Receipts {
+		receipts = append(receipts, PurchaseOrderReceiptResponse{
+			InboundID:           r.ID.String(),
+			ReceiptNumber:       r.ReceiptNumber,
+			PurchaseOrderLineID: optionalUUIDString(r.PurchaseOrderLineID),
+			ProductID:           r.ProductID.String(),
+			Quantity:            r.Quantity,
+			ReferenceNumber:     r.ReferenceNumber,
+			ReceivedDate:        r.ReceivedDate.Format(time.RFC3339),
+			CreatedBy:           r.CreatedBy.String(),
+		})
+	}
+
+	return PurchaseOrderResponse{
+		ID:                    order.ID.String(),
+		PONumber:              order.PONumber,
+		Status:                order.Status,
+		SupplierID:            order.SupplierID.String(),
+		SupplierCode:          order.Supplier.Code,
+		SupplierName:          order.Supplier.Name,
+		WarehouseID:           order.WarehouseID.String(),
+		WarehouseName:         order.Warehouse.Name,
+		OrderDate:             order.OrderDate.Format(time.RFC3339),
+		ExpectedDate:          optionalTimeString(order.ExpectedDate),
+		OverReceiptTolerance:  order.OverReceiptTolerance,
+		UnderReceiptTolerance: order.UnderReceiptTolerance,
+		TotalAmount:           order.TotalAmount,
+		ReferenceNumber:       order.ReferenceNumber,
+		Notes:                 order.Notes,
+		CreatedBy:             order.CreatedBy.String(),
+		ClosedBy:              optionalUUIDString(order.ClosedBy),
+		ClosedAt:              optionalTimeString(order.ClosedAt),
+		CloseReason:           order.CloseReason,
+		CancelledBy:           optionalUUIDString(order.CancelledBy),
+		CancelledAt:           optionalTimeString(order.CancelledAt),
+		CancelReason:          order.CancelReason,
+		Lines:                 lines,
+		Receipts:              receipts,
+		CreatedAt:             order.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:             order.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// GET /purchase_orders
+func (h *PurchaseOrderHandler) GetPurchaseOrders(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := make(map[string]interface{})
+	for _, key := range []string{"search", "status", "warehouse_id", "supplier_id"} {
+		if v := c.Query(key); v != "" {
+			filters[key] = v
+		}
+	}
+
+	orders, total, err := h.purchaseOrderService.GetPurchaseOrders(page, limit, filters, middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]PurchaseOrderResponse, 0, len(orders))
+	for _, o := range orders {
+		resp = append(resp, mapPurchaseOrderToResponse(o))
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	response.PaginatedResponse(c, "purchase_orders", resp, int(total), page, limit)
+}
+
+// GET /purchase_orders/:id
+func (h *PurchaseOrderHandler) GetPurchaseOrderByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.purchaseOrderService.GetPurchaseOrderByID(id, middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapPurchaseOrderToResponse(*order), "Purchase order retrieved successfully")
+}
+
+// POST /purchase_orders
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(c *gin.Context) {
+	var req struct {
+		SupplierID            uuid.UUID  `json:"supplier_id" binding:"required"`
+		WarehouseID           uuid.UUID  `json:"warehouse_id" binding:"required"`
+		OrderDate             *time.Time `json:"order_date"`    // kosong = sekarang
+		ExpectedDate          *time.Time `json:"expected_date"` // kosong = order_date + lead time supplier
+		OverReceiptTolerance  *float64   `json:"over_receipt_tolerance"`
+		UnderReceiptTolerance *float64   `json:"under_receipt_tolerance"`
+		ReferenceNumber       string     `json:"reference_number"`
+		Notes                 string     `json:"notes"`
+		Lines                 []struct {
+			ProductID       uuid.UUID `json:"product_id" binding:"required"`
+			OrderedQuantity int       `json:"ordered_quantity" binding:"required"`
+			UnitCost        float64   `json:"unit_cost"`
+			Notes           string    `json:"notes"`
+		} `json:"lines" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	createdBy, err := requestActorID(c)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	order := &models.PurchaseOrder{
+		SupplierID:      req.SupplierID,
+		WarehouseID:     req.WarehouseID,
+		ExpectedDate:    req.ExpectedDate,
+		ReferenceNumber: req.ReferenceNumber,
+		Notes:           req.Notes,
+		CreatedBy:       createdBy,
+	}
+	if req.OrderDate != nil {
+		order.OrderDate = *req.OrderDate
+	}
+	for _, l := range req.Lines {
+		order.Lines = append(order.Lines, models.PurchaseOrderLine{
+			ProductID:       l.ProductID,
+			OrderedQuantity: l.OrderedQuantity,
+			UnitCost:        l.UnitCost,
+			Notes:           l.Notes,
+		})
+	}
+
+	tolerance := services.ReceiptToleranceInput{Over: req.OverReceiptTolerance, Under: req.UnderReceiptTolerance}
+	created, err := h.purchaseOrderService.CreatePurchaseOrder(order, tolerance, middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapPurchaseOrderToResponse(*created), "Purchase order created successfully")
+}
+
+// POST /purchase_orders/:id/close
+func (h *PurchaseOrderHandler) ClosePurchaseOrder(c *gin.Context) {
+	h.finishPurchaseOrder(c, h.purchaseOrderService.ClosePurchaseOrder, "Purchase order closed successfully")
+}
+
+// POST /purchase_orders/:id/cancel
+func (h *PurchaseOrderHandler) CancelPurchaseOrder(c *gin.Context) {
+	h.finishPurchaseOrder(c, h.purchaseOrderService.CancelPurchaseOrder, "Purchase order cancelled successfully")
+}
+
+func (h *PurchaseOrderHandler) finishPurchaseOrder(c *gin.Context, action func(id, userID uuid.UUID, reason string, scope models.WarehouseScope) (*models.PurchaseOrder, error), message string) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	// body opsional untuk close tanpa sisa
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	userID, err := requestActorID(c)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	order, err := action(id, userID, req.Reason, middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapPurchaseOrderToResponse(*order), message)
+}
+
+// GET /purchase_orders/open
+func (h *PurchaseOrderHandler) GetOpenPurchaseOrderReport(c *gin.Context) {
+	filters := make(map[string]interface{})
+	for _, key := range []string{"warehouse_id", "supplier_id"} {
+		if v := c.Query(key); v != "" {
+			filters[key] = v
+		}
+	}
+	if overdue := c.Query("overdue"); overdue != "" {
+		v, err := strconv.ParseBool(overdue)
+		if err != nil {
+			response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+			return
+		}
+		filters["overdue"] = v
+	}
+
+	report, err := h.purchaseOrderService.GetOpenPurchaseOrderReport(filters, middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(c, report, "Open purchase order report retrieved successfully")
+}
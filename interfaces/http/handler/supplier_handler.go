@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"wms-be/domain/models"
+	"wms-be/domain/repository"
+	"wms-be/domain/services"
+	"wms-be/infrastructure/middleware"
+	"wms-be/interfaces/http/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SupplierHandler struct {
+	supplierService services.SupplierService
+}
+
+func NewSupplierHandler(supplierService services.SupplierService) *SupplierHandler {
+	return &SupplierHandler{supplierService: supplierService}
+}
+
+type SupplierResponse struct {
+	ID            string `json:"id"`
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	ContactPerson string `json:"contact_person,omitempty"`
+	Email         string `json:"email,omitempty"`
+	Phone         string `json:"phone,omitempty"`
+	Address       string `json:"address,omitempty"`
+	TaxID         string `json:"tax_id,omitempty"`
+	LeadTimeDays  int    `json:"lead_time_days"`
+	IsActive      bool   `json:"is_active"`
+	Notes         string `json:"notes,omitempty"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+type supplierRequest struct {
+	Code          string `json:"code" binding:"required"`
+	Name          string `json:"name" binding:"required"`
+	ContactPerson string `json:"contact_person"`
+	Email         string `json:"email"`
+	Phone         string `json:"phone"`
+	Address       string `json:"address"`
+	TaxID         string `json:"tax_id"`
+	LeadTimeDays  int    `json:"lead_time_days"`
+	IsActive      *bool  `json:"is_active"` // default true saat create, tidak berubah saat update jika kosong
+	Notes         string `json:"notes"`
+}
+
+func (r supplierRequest) toModel(id uuid.UUID, isActive bool) models.Supplier {
+	if r.IsActive != nil {
+		isActive = *r.IsActive
+	}
+	return models.Supplier{
+		ID:            id,
+		Code:          r.Code,
+		Name:          r.Name,
+		ContactPerson: r.ContactPerson,
+		Email:         r.Email,
+		Phone:         r.Phone,
+		Address:       r.Address,
+		TaxID:         r.TaxID,
+		LeadTimeDays:  r.LeadTimeDays,
+		IsActive:      isActive,
+		Notes:         r.Notes,
+	}
+}
+
+func mapSupplierToResponse(supplier models.Supplier) SupplierResponse {
+	return SupplierResponse{
+		ID:            supplier.ID.String(),
+		Code:          supplier.Code,
+		Name:          supplier.Name,
+		ContactPerson: supplier.ContactPerson,
+		Email:         supplier.Email,
+		Phone:         supplier.Phone,
+		Address:       supplier.Address,
+		TaxID:         supplier.TaxID,
+		LeadTimeDays:  supplier.LeadTimeDays,
+		IsActive:      supplier.IsActive,
+		Notes:         supplier.Notes,
+		CreatedAt:     supplier.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     supplier.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// GET /suppliers
+func (h *SupplierHandler) GetSuppliers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := make(map[string]interface{})
+	if search := c.Query("search"); search != "" {
+		filters["search"] = search
+	}
+	if active := c.Query("is_active"); active != "" {
+		isActive, err := strconv.ParseBool(active)
+		if err != nil {
+			response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+			return
+		}
+		filters["is_active"] = isActive
+	}
+
+	suppliers, total, err := h.supplierService.GetSuppliers(page, limit, filters)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]SupplierResponse, 0, len(suppliers))
+	for _, supplier := range suppliers {
+		resp = append(resp, mapSupplierToResponse(supplier))
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	response.PaginatedResponse(c, "suppliers", resp, int(total), page, limit)
+}
+
+// GET /suppliers/:id
+func (h *SupplierHandler) GetSupplierByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	supplier, err := h.supplierService.GetSupplierByID(id)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapSupplierToResponse(*supplier), "Supplier retrieved successfully")
+}
+
+// POST /suppliers
+func (h *SupplierHandler) CreateSupplier(c *gin.Context) {
+	var req supplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	supplier := req.toModel(uuid.New(), true)
+	created, err := h.supplierService.CreateSupplier(&supplier)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapSupplierToResponse(*created), "Supplier created successfully")
+}
+
+// PUT /suppliers/:id
+func (h *SupplierHandler) UpdateSupplier(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	var req supplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.supplierService.GetSupplierByID(id)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	supplier := req.toModel(id, existing.IsActive)
+	updated, err := h.supplierService.UpdateSupplier(&supplier)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapSupplierToResponse(*updated), "Supplier updated successfully")
+}
+
+// DELETE /suppliers/:id
+func (h *SupplierHandler) DeleteSupplier(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.supplierService.DeleteSupplier(id); err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessMessageResponse(c, "Supplier deleted successfully")
+}
+
+// POST /suppliers/:id/merge
+func (h *SupplierHandler) MergeSuppliers(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		SupplierIDs []uuid.UUID `json:"supplier_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.supplierService.MergeSuppliers(id, req.SupplierIDs)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, gin.H{
+		"supplier":       mapSupplierToResponse(*result.Supplier),
+		"merged_count":   result.MergedCount,
+		"moved_inbounds": result.MovedInbounds,
+	}, "Suppliers merged successfully")
+}
+
+// GET /suppliers/performance
+func (h *SupplierHandler) GetPerformance(c *gin.Context) {
+	filters, ok := supplierPerformanceFilters(c)
+	if !ok {
+		return
+	}
+
+	rows, err := h.supplierService.GetPerformance(filters, middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		return
+	}
+	if rows == nil {
+		rows = []repository.SupplierPerformance{}
+	}
+
+	response.SuccessResponse(c, rows, "Supplier performance retrieved successfully")
+}
+
+// GET /suppliers/:id/performance
+func (h *SupplierHandler) GetSupplierPerformance(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+	filters, ok := supplierPerformanceFilters(c)
+	if !ok {
+		return
+	}
+
+	supplier, err := h.supplierService.GetSupplierByID(id)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	filters["supplier_id"] = id
+	rows, err := h.supplierService.GetPerformance(filters, middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	// supplier tanpa inbound pada periode tersebut tetap dilaporkan dengan angka nol
+	performance := repository.SupplierPerformance{
+		SupplierID:   supplier.ID,
+		SupplierCode: supplier.Code,
+		SupplierName: supplier.Name,
+	}
+	if len(rows) > 0 {
+		performance = rows[0]
+	}
+
+	response.SuccessResponse(c, performance, "Supplier performance retrieved successfully")
+}
+
+// supplierPerformanceFilters baca query from / to (YYYY-MM-DD, inklusif) dan warehouse_id
+func supplierPerformanceFilters(c *gin.Context) (map[string]interface{}, bool) {
+	filters := make(map[string]interface{})
+	if from := c.Query("from"); from != "" {
+		date, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+			return nil, false
+		}
+		filters["from"] = date
+	}
+	if to := c.Query("to"); to != "" {
+		date, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+			return nil, false
+		}
+		filters["to"] = date.AddDate(0, 0, 1)
+	}
+	if warehouseID := c.Query("warehouse_id"); warehouseID != "" {
+		filters["warehouse_id"] = warehouseID
+	}
+	return filters, true
+}
@@ -4,9 +4,11 @@ import (
 	"net/http"
 	"wms-be/domain/models"
 	"wms-be/domain/services"
+	"wms-be/infrastructure/middleware"
 	"wms-be/interfaces/http/response"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type TransactionHandler struct {
@@ -24,11 +26,67 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 		return
 	}
 
-	createdTransaction, err := h.service.CreateTransaction(&transaction)
+	// CreatedBy selalu dari user yang login (service account jika memakai API key)
+	createdBy, err := requestActorID(c)
 	if err != nil {
-		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		response.ErrorMessageResponse(c, err, http.StatusUnauthorized)
+		return
+	}
+	transaction.CreatedBy = createdBy
+	transaction.TransferOrderID = nil // hanya diisi oleh workflow transfer order
+
+	createdTransaction, err := h.service.CreateTransaction(&transaction, middleware.GetWarehouseScope(c))
+	if err != nil {
+		if insufficientStockResponse(c, err) {
+			return
+		}
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
 	response.SuccessResponse(c, createdTransaction, "Transaction created successfully")
 }
+
+// POST /transfers
+func (h *TransactionHandler) CreateTransfer(c *gin.Context) {
+	var req struct {
+		ProductID       uuid.UUID `json:"product_id" binding:"required"`
+		FromWarehouseID uuid.UUID `json:"from_warehouse_id" binding:"required"`
+		ToWarehouseID   uuid.UUID `json:"to_warehouse_id" binding:"required"`
+		Quantity        int       `json:"quantity"`
+		ReferenceNumber string    `json:"reference_number,omitempty"`
+		Notes           string    `json:"notes,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	createdBy, err := requestActorID(c)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	transfer := models.Transaction{
+		Type:            models.Transfer,
+		ProductID:       req.ProductID,
+		Quantity:        req.Quantity,
+		WarehouseID:     req.FromWarehouseID,
+		ToWarehouseID:   &req.ToWarehouseID,
+		ReferenceNumber: req.ReferenceNumber,
+		Notes:           req.Notes,
+		CreatedBy:       createdBy,
+	}
+
+	createdTransfer, err := h.service.CreateTransfer(&transfer, middleware.GetWarehouseScope(c))
+	if err != nil {
+		if insufficientStockResponse(c, err) {
+			return
+		}
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, createdTransfer, "Transfer completed successfully")
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 	"wms-be/domain/services"
+	"wms-be/infrastructure/middleware"
 	"wms-be/interfaces/http/response"
 
 	"github.com/gin-gonic/gin"
@@ -26,7 +27,7 @@ func (h *TransactionHistoryHandler) GetTransactions(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
-	transactions, total, err := h.service.GetTransactions(txType, warehouseId, dateFrom, dateTo, page, limit)
+	transactions, total, err := h.service.GetTransactions(txType, warehouseId, dateFrom, dateTo, page, limit, middleware.GetWarehouseScope(c))
 	if err != nil {
 		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
 		return
@@ -0,0 +1,311 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"wms-be/domain/models"
+	"wms-be/domain/services"
+	"wms-be/infrastructure/middleware"
+	"wms-be/interfaces/http/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TransferOrderHandler struct {
+	transferOrderService services.TransferOrderService
+}
+
+func NewTransferOrderHandler(transferOrderService services.TransferOrderService) *TransferOrderHandler {
+	return &TransferOrderHandler{transferOrderService: transferOrderService}
+}
+
+type TransferOrderReceiptResponse struct {
+	ID         string `json:"id"`
+	Quantity   int    `json:"quantity"`
+	Notes      string `json:"notes,omitempty"`
+	ReceivedBy string `json:"received_by"`
+	ReceivedAt string `json:"received_at"`
+}
+
+type TransferOrderResponse struct {
+	ID                   string                         `json:"id"`
+	TransferNumber       string                         `json:"transfer_number"`
+	Status               string                         `json:"status"`
+	ProductID            string                         `json:"product_id"`
+	ProductName          string                         `json:"product_name"`
+	ProductSKU           string                         `json:"product_sku"`
+	DestinationProductID *string                        `json:"destination_product_id,omitempty"`
+	FromWarehouseID      string                         `json:"from_warehouse_id"`
+	FromWarehouseName    string                         `json:"from_warehouse_name"`
+	ToWarehouseID        string                         `json:"to_warehouse_id"`
+	ToWarehouseName      string                         `json:"to_warehouse_name"`
+	Quantity             int                            `json:"quantity"`
+	QuantityReceived     int                            `json:"quantity_received"`
+	InTransitQuantity    int                            `json:"in_transit_quantity"`
+	DiscrepancyQuantity  int                            `json:"discrepancy_quantity"`
+	DiscrepancyNotes     string                         `json:"discrepancy_notes,omitempty"`
+	ReferenceNumber      string                         `json:"reference_number,omitempty"`
+	Notes                string                         `json:"notes,omitempty"`
+	RequestedBy          string                         `json:"requested_by"`
+	RequestedAt          string                         `json:"requested_at"`
+	ApprovedBy           *string                        `json:"approved_by,omitempty"`
+	ApprovedAt           *string                        `json:"approved_at,omitempty"`
+	DispatchedBy         *string                        `json:"dispatched_by,omitempty"`
+	DispatchedAt         *string                        `json:"dispatched_at,omitempty"`
+	InTransitBy          *string                        `json:"in_transit_by,omitempty"`
+	InTransitAt          *string                        `json:"in_transit_at,omitempty"`
+	ReceivedBy           *string                        `json:"received_by,omitempty"`
+	ReceivedAt           *string                        `json:"received_at,omitempty"`
+	CancelledBy          *string                        `json:"cancelled_by,omitempty"`
+	CancelledAt          *string                        `json:"cancelled_at,omitempty"`
+	CancelReason         string                         `json:"cancel_reason,omitempty"`
+	Receipts             []TransferOrderReceiptResponse `json:"receipts"`
+	CreatedAt            string                         `json:"created_at"`
+	UpdatedAt            string                         `json:"updated_at"`
+}
+
+func optionalUUIDString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+func optionalTimeString(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(time.RFC3339)
+	return &s
+}
+
+func mapTransferOrderToResponse(order models.TransferOrder) TransferOrderResponse {
+	receipts := make([]TransferOrderReceiptResponse, 0, len(order.Receipts))
+	for _, r := range order.Receipts {
+		receipts = append(receipts, TransferOrderReceiptResponse{
+			ID:         r.ID.String(),
+			Quantity:   r.Quantity,
+			Notes:      r.Notes,
+			ReceivedBy: r.ReceivedBy.String(),
+			ReceivedAt: r.ReceivedAt.Format(time.RFC3339),
+		})
+	}
+
+	return TransferOrderResponse{
+		ID:                   order.ID.String(),
+		TransferNumber:       order.TransferNumber,
+		Status:               order.Status,
+		ProductID:            order.ProductID.String(),
+		ProductName:          order.Product.Name,
+		ProductSKU:           order.Product.SKU,
+		DestinationProductID: optionalUUIDString(order.DestinationProductID),
+		FromWarehouseID:      order.FromWarehouseID.String(),
+		FromWarehouseName:    order.FromWarehouse.Name,
+		ToWarehouseID:        order.ToWarehouseID.String(),
+		ToWarehouseName:      order.ToWarehouse.Name,
+		Quantity:             order.Quantity,
+		QuantityReceived:     order.QuantityReceived,
+		InTransitQuantity:    order.InTransitQuantity(),
+		DiscrepancyQuantity:  order.DiscrepancyQuantity,
+		DiscrepancyNotes:     order.DiscrepancyNotes,
+		ReferenceNumber:      order.ReferenceNumber,
+		Notes:                order.Notes,
+		RequestedBy:          order.RequestedBy.String(),
+		RequestedAt:          order.RequestedAt.Format(time.RFC3339),
+		ApprovedBy:           optionalUUIDString(order.ApprovedBy),
+		ApprovedAt:           optionalTimeString(order.ApprovedAt),
+		DispatchedBy:         optionalUUIDString(order.DispatchedBy),
+		DispatchedAt:         optionalTimeString(order.DispatchedAt),
+		InTransitBy:          optionalUUIDString(order.InTransitBy),
+		InTransitAt:          optionalTimeString(order.InTransitAt),
+		ReceivedBy:           optionalUUIDString(order.ReceivedBy),
+		ReceivedAt:           optionalTimeString(order.ReceivedAt),
+		CancelledBy:          optionalUUIDString(order.CancelledBy),
+		CancelledAt:          optionalTimeString(order.CancelledAt),
+		CancelReason:         order.CancelReason,
+		Receipts:             receipts,
+		CreatedAt:            order.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:            order.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// transferOrderError kirim error dari service, 422 + detail jika stok kurang
+func transferOrderError(c *gin.Context, err error) {
+	if insufficientStockResponse(c, err) {
+		return
+	}
+	response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+}
+
+// GET /transfer_orders
+func (h *TransferOrderHandler) GetTransferOrders(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	filters := make(map[string]interface{})
+	if search := c.Query("search"); search != "" {
+		filters["search"] = search
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if warehouseID := c.Query("warehouse_id"); warehouseID != "" {
+		filters["warehouse_id"] = warehouseID
+	}
+
+	orders, total, err := h.transferOrderService.GetTransferOrders(page, limit, filters, middleware.GetWarehouseScope(c))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]TransferOrderResponse, 0, len(orders))
+	for _, o := range orders {
+		resp = append(resp, mapTransferOrderToResponse(o))
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	response.PaginatedResponse(c, "transfer_orders", resp, int(total), page, limit)
+}
+
+// GET /transfer_orders/:id
+func (h *TransferOrderHandler) GetTransferOrderByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.transferOrderService.GetTransferOrderByID(id, middleware.GetWarehouseScope(c))
+	if err != nil {
+		transferOrderError(c, err)
+		return
+	}
+
+	response.SuccessResponse(c, mapTransferOrderToResponse(*order), "Transfer order retrieved successfully")
+}
+
+// POST /transfer_orders
+func (h *TransferOrderHandler) CreateTransferOrder(c *gin.Context) {
+	var req struct {
+		ProductID       uuid.UUID `json:"product_id" binding:"required"`
+		FromWarehouseID uuid.UUID `json:"from_warehouse_id" binding:"required"`
+		ToWarehouseID   uuid.UUID `json:"to_warehouse_id" binding:"required"`
+		Quantity        int       `json:"quantity"`
+		ReferenceNumber string    `json:"reference_number,omitempty"`
+		Notes           string    `json:"notes,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	requestedBy, err := requestActorID(c)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	order := &models.TransferOrder{
+		ProductID:       req.ProductID,
+		FromWarehouseID: req.FromWarehouseID,
+		ToWarehouseID:   req.ToWarehouseID,
+		Quantity:        req.Quantity,
+		ReferenceNumber: req.ReferenceNumber,
+		Notes:           req.Notes,
+		RequestedBy:     requestedBy,
+	}
+
+	created, err := h.transferOrderService.CreateTransferOrder(order, middleware.GetWarehouseScope(c))
+	if err != nil {
+		transferOrderError(c, err)
+		return
+	}
+
+	response.SuccessResponse(c, mapTransferOrderToResponse(*created), "Transfer order requested successfully")
+}
+
+// transition helper untuk endpoint perubahan status tanpa body
+func (h *TransferOrderHandler) transition(c *gin.Context, message string, fn func(id, userID uuid.UUID, scope models.WarehouseScope) (*models.TransferOrder, error)) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	userID, err := requestActorID(c)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	order, err := fn(id, userID, middleware.GetWarehouseScope(c))
+	if err != nil {
+		transferOrderError(c, err)
+		return
+	}
+
+	response.SuccessResponse(c, mapTransferOrderToResponse(*order), message)
+}
+
+// POST /transfer_orders/:id/approve
+func (h *TransferOrderHandler) ApproveTransferOrder(c *gin.Context) {
+	h.transition(c, "Transfer order approved successfully", h.transferOrderService.ApproveTransferOrder)
+}
+
+// POST /transfer_orders/:id/dispatch
+func (h *TransferOrderHandler) DispatchTransferOrder(c *gin.Context) {
+	h.transition(c, "Transfer order dispatched successfully", h.transferOrderService.DispatchTransferOrder)
+}
+
+// POST /transfer_orders/:id/in_transit
+func (h *TransferOrderHandler) MarkTransferOrderInTransit(c *gin.Context) {
+	h.transition(c, "Transfer order marked as in transit", h.transferOrderService.MarkTransferOrderInTransit)
+}
+
+// POST /transfer_orders/:id/receive
+func (h *TransferOrderHandler) ReceiveTransferOrder(c *gin.Context) {
+	var req struct {
+		Quantity         int    `json:"quantity"`
+		Notes            string `json:"notes,omitempty"`
+		Close            bool   `json:"close,omitempty"`
+		DiscrepancyNotes string `json:"discrepancy_notes,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	input := services.ReceiveTransferInput{
+		Quantity:         req.Quantity,
+		Notes:            req.Notes,
+		Close:            req.Close,
+		DiscrepancyNotes: req.DiscrepancyNotes,
+	}
+	h.transition(c, "Transfer order received successfully", func(id, userID uuid.UUID, scope models.WarehouseScope) (*models.TransferOrder, error) {
+		return h.transferOrderService.ReceiveTransferOrder(id, userID, input, scope)
+	})
+}
+
+// POST /transfer_orders/:id/cancel
+func (h *TransferOrderHandler) CancelTransferOrder(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	h.transition(c, "Transfer order cancelled successfully", func(id, userID uuid.UUID, scope models.WarehouseScope) (*models.TransferOrder, error) {
+		return h.transferOrderService.CancelTransferOrder(id, userID, req.Reason, scope)
+	})
+}
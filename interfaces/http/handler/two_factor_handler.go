@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"wms-be/domain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ===================== 2FA LOGIN (public, pakai challenge token) =====================
+func VerifyTwoFactor(c *gin.Context, authService services.AuthService) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"` // kode TOTP 6 digit atau recovery code
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request payload"})
+		return
+	}
+
+	result, err := authService.VerifyTwoFactor(req.ChallengeToken, req.Code, clientInfo(c))
+	if err != nil {
+		loginErrorResponse(c, err)
+		return
+	}
+
+	loginSuccessResponse(c, result, "Login successful", nil)
+}
+
+// EnrollTwoFactor mulai enrolment saat login untuk akun yang wajib 2FA
+func EnrollTwoFactor(c *gin.Context, authService services.AuthService) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.ChallengeToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request payload"})
+		return
+	}
+
+	setup, err := authService.BeginChallengeEnrollment(req.ChallengeToken)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusUnauthorized), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Scan the QR code and confirm with a code", "data": setup})
+}
+
+// ConfirmTwoFactorEnrollment selesaikan enrolment saat login, mengembalikan token dan recovery code
+func ConfirmTwoFactorEnrollment(c *gin.Context, authService services.AuthService) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request payload"})
+		return
+	}
+
+	result, recoveryCodes, err := authService.ConfirmChallengeEnrollment(req.ChallengeToken, req.Code, clientInfo(c))
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err, http.StatusUnauthorized), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	loginSuccessResponse(c, result, "Two-factor authentication enabled", gin.H{"recovery_codes": recoveryCodes})
+}
+
+// ===================== 2FA MANAGEMENT (protected) =====================
+func GetTwoFactorStatus(c *gin.Context, authService services.AuthService) {
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	status, err := authService.GetTwoFactorStatus(userUUID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Two-factor status fetched successfully", "data": status})
+}
+
+func SetupTwoFactor(c *gin.Context, authService services.AuthService) {
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	setup, err := authService.BeginTwoFactorEnrollment(userUUID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Scan the QR code and confirm with a code", "data": setup})
+}
+
+func EnableTwoFactor(c *gin.Context, authService services.AuthService) {
+	var req struct {
+		Code string `json:"code"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request payload"})
+		return
+	}
+
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	recoveryCodes, err := authService.ConfirmTwoFactorEnrollment(userUUID, req.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err, http.StatusInternalServerError), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Two-factor authentication enabled",
+		"data":    gin.H{"recovery_codes": recoveryCodes},
+	})
+}
+
+func DisableTwoFactor(c *gin.Context, authService services.AuthService) {
+	var req struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request payload"})
+		return
+	}
+
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := authService.DisableTwoFactor(userUUID, req.Password, req.Code); err != nil {
+		c.JSON(twoFactorErrorStatus(err, http.StatusInternalServerError), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Two-factor authentication disabled"})
+}
+
+func RegenerateRecoveryCodes(c *gin.Context, authService services.AuthService) {
+	var req struct {
+		Code string `json:"code"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request payload"})
+		return
+	}
+
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	recoveryCodes, err := authService.RegenerateRecoveryCodes(userUUID, req.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err, http.StatusInternalServerError), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Recovery codes regenerated",
+		"data":    gin.H{"recovery_codes": recoveryCodes},
+	})
+}
+
+// twoFactorErrorStatus kode 2FA salah = 400, challenge tidak valid = 401
+func twoFactorErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrInvalidChallenge):
+		return http.StatusUnauthorized
+	}
+	return errorStatus(err, fallback)
+}
@@ -0,0 +1,419 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"wms-be/domain/models"
+	"wms-be/domain/services"
+	"wms-be/infrastructure/jwt"
+	"wms-be/interfaces/http/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UserHandler handles user management requests (admin only)
+type UserHandler struct {
+	userService services.UserService
+	authService services.AuthService
+}
+
+// Constructor
+func NewUserHandler(userService services.UserService, authService services.AuthService) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+		authService: authService,
+	}
+}
+
+// UserResponse represents API response for a user
+type UserResponse struct {
+	ID                   string   `json:"id"`
+	Name                 string   `json:"name"`
+	Email                string   `json:"email"`
+	Role                 string   `json:"role"`
+	WarehouseID          string   `json:"warehouseId"`
+	WarehouseName        string   `json:"warehouseName"`
+	AssignedWarehouseIDs []string `json:"assignedWarehouseIds"`
+	IsActive             bool     `json:"isActive"`
+	FailedLoginAttempts  int      `json:"failedLoginAttempts"`
+	LockedUntil          *string  `json:"lockedUntil"`
+	TwoFactorEnabled     bool     `json:"twoFactorEnabled"`
+	CreatedAt            string   `json:"createdAt"`
+	UpdatedAt            string   `json:"updatedAt"`
+}
+
+func mapUserToResponse(user models.User) UserResponse {
+	assigned := make([]string, 0, len(user.AssignedWarehouses))
+	for _, a := range user.AssignedWarehouses {
+		assigned = append(assigned, a.WarehouseID.String())
+	}
+
+	var lockedUntil *string
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		formatted := user.LockedUntil.Format(time.RFC3339)
+		lockedUntil = &formatted
+	}
+
+	return UserResponse{
+		ID:                   user.ID.String(),
+		Name:                 user.Name,
+		Email:                user.Email,
+		Role:                 user.Role,
+		WarehouseID:          user.WarehouseID.String(),
+		WarehouseName:        user.Warehouse.Name,
+		AssignedWarehouseIDs: assigned,
+		IsActive:             user.IsActive,
+		FailedLoginAttempts:  user.FailedLoginAttempts,
+		LockedUntil:          lockedUntil,
+		TwoFactorEnabled:     user.TOTPEnabled,
+		CreatedAt:            user.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:            user.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+type userRequest struct {
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	Password    string `json:"password"`
+	Role        string `json:"role"`
+	WarehouseID string `json:"warehouseId"`
+}
+
+func (req userRequest) toInput() (services.UserInput, error) {
+	input := services.UserInput{
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: req.Password,
+		Role:     req.Role,
+	}
+	if req.WarehouseID != "" {
+		warehouseID, err := uuid.Parse(req.WarehouseID)
+		if err != nil {
+			return input, errors.New("invalid warehouseId")
+		}
+		input.WarehouseID = warehouseID
+	}
+	return input, nil
+}
+
+func parseUserID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.ErrorMessageResponse(c, errors.New("invalid user id"), http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// GetUsers handles GET /users
+func (h *UserHandler) GetUsers(c *gin.Context) {
+	search := c.Query("search")
+	role := c.Query("role")
+	warehouseId := c.Query("warehouseId")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	users, total, err := h.userService.GetUsers(search, role, warehouseId, page, limit)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]UserResponse, len(users))
+	for i, u := range users {
+		resp[i] = mapUserToResponse(u)
+	}
+
+	response.PaginatedResponse(c, "users", resp, total, page, limit)
+}
+
+// GetUserByID handles GET /users/:id
+func (h *UserHandler) GetUserByID(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetUserByID(id)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapUserToResponse(*user), "User fetched successfully")
+}
+
+// CreateUser handles POST /users
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	var req userRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	input, err := req.toInput()
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.CreateUser(input)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapUserToResponse(*user), "User created successfully")
+}
+
+// UpdateUser handles PUT /users/:id
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req userRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	input, err := req.toInput()
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.UpdateUser(id, input)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapUserToResponse(*user), "User updated successfully")
+}
+
+// ActivateUser handles POST /users/:id/activate
+func (h *UserHandler) ActivateUser(c *gin.Context) {
+	h.setActive(c, true, "User activated successfully")
+}
+
+// DeactivateUser handles POST /users/:id/deactivate
+func (h *UserHandler) DeactivateUser(c *gin.Context) {
+	h.setActive(c, false, "User deactivated successfully")
+}
+
+func (h *UserHandler) setActive(c *gin.Context, active bool, message string) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	// admin tidak boleh menonaktifkan akunnya sendiri
+	if currentUserID, err := jwt.GetUserIDFromContext(c); err == nil && !active && currentUserID == id.String() {
+		response.ErrorMessageResponse(c, errors.New("you cannot deactivate your own account"), http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.SetUserActive(id, active)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapUserToResponse(*user), message)
+}
+
+// AssignWarehouses handles PUT /users/:id/warehouses
+func (h *UserHandler) AssignWarehouses(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		WarehouseID  string   `json:"warehouseId"`
+		WarehouseIDs []string `json:"warehouseIds"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusBadRequest)
+		return
+	}
+
+	primaryID := uuid.Nil
+	if req.WarehouseID != "" {
+		parsed, err := uuid.Parse(req.WarehouseID)
+		if err != nil {
+			response.ErrorMessageResponse(c, errors.New("invalid warehouseId"), http.StatusBadRequest)
+			return
+		}
+		primaryID = parsed
+	}
+
+	additional := make([]uuid.UUID, 0, len(req.WarehouseIDs))
+	for _, raw := range req.WarehouseIDs {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			response.ErrorMessageResponse(c, errors.New("invalid warehouseIds"), http.StatusBadRequest)
+			return
+		}
+		additional = append(additional, parsed)
+	}
+
+	user, err := h.userService.AssignWarehouses(id, primaryID, additional)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapUserToResponse(*user), "User warehouses updated successfully")
+}
+
+// IssuePasswordReset handles POST /users/:id/reset_password
+func (h *UserHandler) IssuePasswordReset(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	adminIDStr, err := jwt.GetUserIDFromContext(c)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusUnauthorized)
+		return
+	}
+	adminID, err := uuid.Parse(adminIDStr)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	token, expiresAt, err := h.userService.IssuePasswordReset(id, adminID)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, gin.H{
+		"reset_token": token,
+		"expires_at":  expiresAt.Format(time.RFC3339),
+	}, "Password reset token issued successfully")
+}
+
+// GetUserSessions handles GET /users/:id/sessions
+func (h *UserHandler) GetUserSessions(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(id)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessResponse(c, sessions, "Sessions fetched successfully")
+}
+
+// RevokeUserSession handles DELETE /users/:id/sessions/:sessionId
+func (h *UserHandler) RevokeUserSession(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		response.ErrorMessageResponse(c, errors.New("invalid session id"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authService.RevokeSession(id, sessionID); err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessMessageResponse(c, "Session revoked successfully")
+}
+
+// RevokeUserSessions handles DELETE /users/:id/sessions
+func (h *UserHandler) RevokeUserSessions(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.RevokeAllSessions(id); err != nil {
+		response.ErrorMessageResponse(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.SuccessMessageResponse(c, "All sessions revoked successfully")
+}
+
+// UnlockUser handles POST /users/:id/unlock
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.UnlockUser(id)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapUserToResponse(*user), "User unlocked successfully")
+}
+
+// GetLoginAttempts handles GET /users/:id/login_attempts
+func (h *UserHandler) GetLoginAttempts(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	attempts, total, err := h.userService.GetLoginAttempts(id, page, limit)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.PaginatedResponse(c, "loginAttempts", attempts, total, page, limit)
+}
+
+// ResetTwoFactor handles POST /users/:id/2fa/reset
+func (h *UserHandler) ResetTwoFactor(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.ResetTwoFactor(id)
+	if err != nil {
+		response.ErrorMessageResponse(c, err, errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	response.SuccessResponse(c, mapUserToResponse(*user), "Two-factor authentication reset successfully")
+}
@@ -2,6 +2,7 @@ package router
 
 import (
 	"time"
+	"wms-be/domain/models"
 	"wms-be/domain/repository"
 	"wms-be/domain/services"
 	"wms-be/infrastructure/database"
@@ -21,6 +22,16 @@ func SetupRouter(
 	inboundRepo repository.InboundRepository,
 	outboundRepo repository.OutboundRepository,
 	orderRepo repository.OrderRepository,
+	passwordResetTokenRepo repository.PasswordResetTokenRepository,
+	revokedAccessTokenRepo repository.RevokedAccessTokenRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	recoveryCodeRepo repository.RecoveryCodeRepository,
+	apiKeyRepo repository.APIKeyRepository,
+	transferOrderRepo repository.TransferOrderRepository,
+	customerRepo repository.CustomerRepository,
+	supplierRepo repository.SupplierRepository,
+	purchaseOrderRepo repository.PurchaseOrderRepository,
+	orderExpiry *services.OrderExpiryScheduler,
 ) *gin.Engine {
 	r := gin.Default()
 
@@ -28,20 +39,27 @@ func SetupRouter(
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-API-Key"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	// Initialize Services
-	authService := services.NewAuthService(userRepo, refreshTokenRepo)
+	tokenDenylist := services.NewTokenDenylist(revokedAccessTokenRepo)
+	authService := services.NewAuthService(userRepo, refreshTokenRepo, loginAttemptRepo, recoveryCodeRepo, tokenDenylist)
 	warehouseService := services.NewWarehouseService(warehouseRepo)
 	productService := services.NewProductService(productRepo)
 	transactionService := services.NewTransactionService(transactionRepo)
-	inboundService := services.NewInboundService(inboundRepo)
+	inboundService := services.NewInboundService(inboundRepo, supplierRepo, purchaseOrderRepo)
 	outboundService := services.NewOutboundService(outboundRepo)
-	orderService := services.NewOrderService(orderRepo)
+	orderService := services.NewOrderService(orderRepo, customerRepo)
+	transferOrderService := services.NewTransferOrderService(transferOrderRepo, productRepo, warehouseRepo)
+	customerService := services.NewCustomerService(customerRepo, orderRepo)
+	supplierService := services.NewSupplierService(supplierRepo)
+	purchaseOrderService := services.NewPurchaseOrderService(purchaseOrderRepo, supplierRepo, productRepo)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, warehouseRepo)
+	userService := services.NewUserService(userRepo, warehouseRepo, refreshTokenRepo, passwordResetTokenRepo, loginAttemptRepo, recoveryCodeRepo, tokenDenylist)
 
 	// Ambil koneksi DB dari package database
 	db := database.GetDB()
@@ -57,6 +75,22 @@ func SetupRouter(
 	outboundHandler := handler.NewOutboundHandler(outboundService)
 	orderHandler := handler.NewOrderHandler(orderService)
 	dashboardHandler := handler.NewDashboardHandler(dashboardService)
+	userHandler := handler.NewUserHandler(userService, authService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	transferOrderHandler := handler.NewTransferOrderHandler(transferOrderService)
+	jobHandler := handler.NewJobHandler(orderExpiry)
+	customerHandler := handler.NewCustomerHandler(customerService)
+	supplierHandler := handler.NewSupplierHandler(supplierService)
+	purchaseOrderHandler := handler.NewPurchaseOrderHandler(purchaseOrderService)
+
+	// Auth (bearer JWT: cek signature, exp dan denylist; atau header X-API-Key)
+	authMiddleware := middleware.AuthMiddleware(tokenDenylist, apiKeyService)
+
+	// Scope gudang per user (staff hanya gudangnya sendiri)
+	warehouseScope := middleware.WarehouseScopeMiddleware(userRepo)
+
+	// Public key untuk verifikasi token oleh service lain
+	r.GET("/.well-known/jwks.json", handler.GetJWKS)
 
 	// API Prefix
 	api := r.Group("/api")
@@ -65,15 +99,27 @@ func SetupRouter(
 	authRoutes := api.Group("/auth")
 	{
 		authRoutes.POST("/login", func(c *gin.Context) {
-			handler.Login(c, authService, userRepo)
+			handler.Login(c, authService)
+		})
+		authRoutes.POST("/2fa/verify", func(c *gin.Context) {
+			handler.VerifyTwoFactor(c, authService)
+		})
+		authRoutes.POST("/2fa/enroll", func(c *gin.Context) {
+			handler.EnrollTwoFactor(c, authService)
+		})
+		authRoutes.POST("/2fa/enroll/confirm", func(c *gin.Context) {
+			handler.ConfirmTwoFactorEnrollment(c, authService)
 		})
 		authRoutes.POST("/refresh_token", func(c *gin.Context) {
 			handler.RefreshToken(c, authService, userRepo)
 		})
+		authRoutes.POST("/reset_password", func(c *gin.Context) {
+			handler.ResetPassword(c, userService)
+		})
 	}
 
 	// Protected Auth Routes
-	authProtected := api.Group("/auth").Use(middleware.AuthMiddleware())
+	authProtected := api.Group("/auth").Use(authMiddleware, middleware.UserSessionOnly())
 	{
 		authProtected.GET("/me", func(c *gin.Context) {
 			handler.Me(c, userRepo)
@@ -84,62 +130,198 @@ func SetupRouter(
 		authProtected.POST("/logout", func(c *gin.Context) {
 			handler.Logout(c, authService)
 		})
+		authProtected.POST("/change_password", func(c *gin.Context) {
+			handler.ChangePassword(c, userService)
+		})
+		authProtected.GET("/2fa", func(c *gin.Context) {
+			handler.GetTwoFactorStatus(c, authService)
+		})
+		authProtected.POST("/2fa/setup", func(c *gin.Context) {
+			handler.SetupTwoFactor(c, authService)
+		})
+		authProtected.POST("/2fa/enable", func(c *gin.Context) {
+			handler.EnableTwoFactor(c, authService)
+		})
+		authProtected.POST("/2fa/disable", func(c *gin.Context) {
+			handler.DisableTwoFactor(c, authService)
+		})
+		authProtected.POST("/2fa/recovery_codes", func(c *gin.Context) {
+			handler.RegenerateRecoveryCodes(c, authService)
+		})
+		authProtected.GET("/sessions", func(c *gin.Context) {
+			handler.ListSessions(c, authService)
+		})
+		authProtected.DELETE("/sessions", func(c *gin.Context) {
+			handler.RevokeAllSessions(c, authService)
+		})
+		authProtected.DELETE("/sessions/:id", func(c *gin.Context) {
+			handler.RevokeSession(c, authService)
+		})
+	}
+
+	// User Management Routes (admin)
+	userRoutes := api.Group("/users").Use(authMiddleware, middleware.RequirePermission(models.PermissionUserManage))
+	{
+		userRoutes.GET("", userHandler.GetUsers)
+		userRoutes.POST("", userHandler.CreateUser)
+		userRoutes.GET("/:id", userHandler.GetUserByID)
+		userRoutes.PUT("/:id", userHandler.UpdateUser)
+		userRoutes.POST("/:id/activate", userHandler.ActivateUser)
+		userRoutes.POST("/:id/deactivate", userHandler.DeactivateUser)
+		userRoutes.POST("/:id/unlock", userHandler.UnlockUser)
+		userRoutes.GET("/:id/login_attempts", userHandler.GetLoginAttempts)
+		userRoutes.POST("/:id/2fa/reset", userHandler.ResetTwoFactor)
+		userRoutes.PUT("/:id/warehouses", userHandler.AssignWarehouses)
+		userRoutes.POST("/:id/reset_password", userHandler.IssuePasswordReset)
+		userRoutes.GET("/:id/sessions", userHandler.GetUserSessions)
+		userRoutes.DELETE("/:id/sessions", userHandler.RevokeUserSessions)
+		userRoutes.DELETE("/:id/sessions/:sessionId", userHandler.RevokeUserSession)
+	}
+
+	// API Key Routes (admin only)
+	apiKeyRoutes := api.Group("/api_keys").Use(authMiddleware, middleware.RequirePermission(models.PermissionAPIKeyManage))
+	{
+		apiKeyRoutes.GET("", apiKeyHandler.GetAPIKeys)
+		apiKeyRoutes.POST("", apiKeyHandler.CreateAPIKey)
+		apiKeyRoutes.GET("/:id", apiKeyHandler.GetAPIKeyByID)
+		apiKeyRoutes.PUT("/:id", apiKeyHandler.UpdateAPIKey)
+		apiKeyRoutes.POST("/:id/revoke", apiKeyHandler.RevokeAPIKey)
+	}
+
+	// Background Job Routes (admin only)
+	jobRoutes := api.Group("/jobs").Use(authMiddleware, middleware.RequirePermission(models.PermissionJobManage))
+	{
+		jobRoutes.GET("/order_expiry", jobHandler.GetOrderExpiryStatus)
+		jobRoutes.POST("/order_expiry/run", jobHandler.RunOrderExpiry)
 	}
 
 	// Warehouse Routes
-	warehouseRoutes := api.Group("/warehouses").Use(middleware.AuthMiddleware())
+	warehouseRoutes := api.Group("/warehouses").Use(authMiddleware)
 	{
-		warehouseRoutes.GET("", warehouseHandler.GetWarehouses)
-		warehouseRoutes.POST("", warehouseHandler.CreateWarehouse)
-		warehouseRoutes.GET("/:id", warehouseHandler.GetWarehouseByID)
-		warehouseRoutes.PUT("/:id", warehouseHandler.UpdateWarehouse)
-		warehouseRoutes.DELETE("/:id", warehouseHandler.DeleteWarehouse)
+		warehouseRoutes.GET("", middleware.RequirePermission(models.PermissionWarehouseRead), warehouseHandler.GetWarehouses)
+		warehouseRoutes.POST("", middleware.RequirePermission(models.PermissionWarehouseWrite), warehouseHandler.CreateWarehouse)
+		warehouseRoutes.GET("/:id", middleware.RequirePermission(models.PermissionWarehouseRead), warehouseHandler.GetWarehouseByID)
+		warehouseRoutes.PUT("/:id", middleware.RequirePermission(models.PermissionWarehouseWrite), warehouseHandler.UpdateWarehouse)
+		warehouseRoutes.DELETE("/:id", middleware.RequirePermission(models.PermissionWarehouseWrite), warehouseHandler.DeleteWarehouse)
 	}
 
 	// Product Routes
-	productRoutes := api.Group("/products").Use(middleware.AuthMiddleware())
+	productRoutes := api.Group("/products").Use(authMiddleware, warehouseScope)
 	{
-		productRoutes.GET("", productHandler.GetProducts)
-		productRoutes.POST("", productHandler.CreateProduct)
-		productRoutes.GET("/:id", productHandler.GetProductByID)
-		productRoutes.PUT("/:id", productHandler.UpdateProduct)
-		productRoutes.DELETE("/:id", productHandler.DeleteProduct)
+		productRoutes.GET("", middleware.RequirePermission(models.PermissionProductRead), productHandler.GetProducts)
+		productRoutes.POST("", middleware.RequirePermission(models.PermissionProductWrite), productHandler.CreateProduct)
+		productRoutes.GET("/:id", middleware.RequirePermission(models.PermissionProductRead), productHandler.GetProductByID)
+		productRoutes.PUT("/:id", middleware.RequirePermission(models.PermissionProductWrite, models.PermissionStockAdjust), productHandler.UpdateProduct)
+		productRoutes.DELETE("/:id", middleware.RequirePermission(models.PermissionProductWrite), productHandler.DeleteProduct)
 	}
 
 	// Transaction Routes
-	transactionRoutes := api.Group("/transactions").Use(middleware.AuthMiddleware())
+	transactionRoutes := api.Group("/transactions").Use(authMiddleware, warehouseScope)
+	{
+		transactionRoutes.POST("", middleware.RequirePermission(models.PermissionTransferCreate), transactionHandler.CreateTransaction)
+		transactionRoutes.GET("", middleware.RequirePermission(models.PermissionTransactionRead), transactionHistoryHandler.GetTransactions)
+	}
+
+	// Transfer Routes
+	transferRoutes := api.Group("/transfers").Use(authMiddleware, warehouseScope)
 	{
-		transactionRoutes.POST("", transactionHandler.CreateTransaction)
-		transactionRoutes.GET("", transactionHistoryHandler.GetTransactions)
+		transferRoutes.POST("", middleware.RequirePermission(models.PermissionTransferCreate), transactionHandler.CreateTransfer)
+	}
+
+	// Transfer Order Routes (dua fase: dispatch dari gudang asal, receive di gudang tujuan)
+	transferOrderRoutes := api.Group("/transfer_orders").Use(authMiddleware, warehouseScope)
+	{
+		transferOrderRoutes.GET("", middleware.RequirePermission(models.PermissionTransferRead), transferOrderHandler.GetTransferOrders)
+		transferOrderRoutes.POST("", middleware.RequirePermission(models.PermissionTransferCreate), transferOrderHandler.CreateTransferOrder)
+		transferOrderRoutes.GET("/:id", middleware.RequirePermission(models.PermissionTransferRead), transferOrderHandler.GetTransferOrderByID)
+		transferOrderRoutes.POST("/:id/approve", middleware.RequirePermission(models.PermissionTransferApprove), transferOrderHandler.ApproveTransferOrder)
+		transferOrderRoutes.POST("/:id/dispatch", middleware.RequirePermission(models.PermissionTransferCreate), transferOrderHandler.DispatchTransferOrder)
+		transferOrderRoutes.POST("/:id/in_transit", middleware.RequirePermission(models.PermissionTransferCreate), transferOrderHandler.MarkTransferOrderInTransit)
+		transferOrderRoutes.POST("/:id/receive", middleware.RequirePermission(models.PermissionTransferCreate), transferOrderHandler.ReceiveTransferOrder)
+		transferOrderRoutes.POST("/:id/cancel", middleware.RequirePermission(models.PermissionTransferCreate), transferOrderHandler.CancelTransferOrder)
+	}
+
+	// Supplier Routes
+	supplierRoutes := api.Group("/suppliers").Use(authMiddleware, warehouseScope)
+	{
+		supplierRoutes.GET("", middleware.RequirePermission(models.PermissionSupplierRead), supplierHandler.GetSuppliers)
+		supplierRoutes.POST("", middleware.RequirePermission(models.PermissionSupplierWrite), supplierHandler.CreateSupplier)
+		supplierRoutes.GET("/performance", middleware.RequirePermission(models.PermissionSupplierRead, models.PermissionInboundRead), supplierHandler.GetPerformance)
+		supplierRoutes.GET("/:id", middleware.RequirePermission(models.PermissionSupplierRead), supplierHandler.GetSupplierByID)
+		supplierRoutes.PUT("/:id", middleware.RequirePermission(models.PermissionSupplierWrite), supplierHandler.UpdateSupplier)
+		supplierRoutes.DELETE("/:id", middleware.RequirePermission(models.PermissionSupplierWrite), supplierHandler.DeleteSupplier)
+		supplierRoutes.POST("/:id/merge", middleware.RequirePermission(models.PermissionSupplierWrite), supplierHandler.MergeSuppliers)
+		supplierRoutes.GET("/:id/performance", middleware.RequirePermission(models.PermissionSupplierRead, models.PermissionInboundRead), supplierHandler.GetSupplierPerformance)
+	}
+
+	// Purchase Order Routes
+	purchaseOrderRoutes := api.Group("/purchase_orders").Use(authMiddleware, warehouseScope)
+	{
+		purchaseOrderRoutes.GET("", middleware.RequirePermission(models.PermissionPurchaseOrderRead), purchaseOrderHandler.GetPurchaseOrders)
+		purchaseOrderRoutes.POST("", middleware.RequirePermission(models.PermissionPurchaseOrderWrite), purchaseOrderHandler.CreatePurchaseOrder)
+		purchaseOrderRoutes.GET("/open", middleware.RequirePermission(models.PermissionPurchaseOrderRead), purchaseOrderHandler.GetOpenPurchaseOrderReport)
+		purchaseOrderRoutes.GET("/:id", middleware.RequirePermission(models.PermissionPurchaseOrderRead), purchaseOrderHandler.GetPurchaseOrderByID)
+		purchaseOrderRoutes.POST("/:id/close", middleware.RequirePermission(models.PermissionPurchaseOrderWrite), purchaseOrderHandler.ClosePurchaseOrder)
+		purchaseOrderRoutes.POST("/:id/cancel", middleware.RequirePermission(models.PermissionPurchaseOrderWrite), purchaseOrderHandler.CancelPurchaseOrder)
 	}
 
 	// Inbound Routes
-	inboundRoutes := api.Group("/inbounds").Use(middleware.AuthMiddleware())
+	inboundRoutes := api.Group("/inbounds").Use(authMiddleware, warehouseScope)
+	{
+		inboundRoutes.GET("", middleware.RequirePermission(models.PermissionInboundRead), inboundHandler.GetInbounds)
+		inboundRoutes.POST("", middleware.RequirePermission(models.PermissionInboundWrite), inboundHandler.CreateInbound)
+	}
+
+	// Inbound Receipt Routes (penerimaan multi-line satu pengiriman)
+	inboundReceiptRoutes := api.Group("/inbound_receipts").Use(authMiddleware, warehouseScope)
 	{
-		inboundRoutes.GET("", inboundHandler.GetInbounds)
-		inboundRoutes.POST("", inboundHandler.CreateInbound)
+		inboundReceiptRoutes.GET("", middleware.RequirePermission(models.PermissionInboundRead), inboundHandler.GetReceipts)
+		inboundReceiptRoutes.POST("", middleware.RequirePermission(models.PermissionInboundWrite), inboundHandler.CreateReceipt)
+		inboundReceiptRoutes.GET("/:id", middleware.RequirePermission(models.PermissionInboundRead), inboundHandler.GetReceiptByID)
 	}
 
 	// Outbound Routes
-	outboundRoutes := api.Group("/outbounds").Use(middleware.AuthMiddleware())
+	outboundRoutes := api.Group("/outbounds").Use(authMiddleware, warehouseScope)
+	{
+		outboundRoutes.GET("", middleware.RequirePermission(models.PermissionOutboundRead), outboundHandler.GetOutbounds)
+		outboundRoutes.POST("", middleware.RequirePermission(models.PermissionOutboundWrite), outboundHandler.CreateOutbound)
+	}
+
+	// Customer Routes
+	customerRoutes := api.Group("/customers").Use(authMiddleware, warehouseScope)
 	{
-		outboundRoutes.GET("", outboundHandler.GetOutbounds)
-		outboundRoutes.POST("", outboundHandler.CreateOutbound)
+		customerRoutes.GET("", middleware.RequirePermission(models.PermissionCustomerRead), customerHandler.GetCustomers)
+		customerRoutes.POST("", middleware.RequirePermission(models.PermissionCustomerWrite), customerHandler.CreateCustomer)
+		customerRoutes.GET("/:id", middleware.RequirePermission(models.PermissionCustomerRead), customerHandler.GetCustomerByID)
+		customerRoutes.PUT("/:id", middleware.RequirePermission(models.PermissionCustomerWrite), customerHandler.UpdateCustomer)
+		customerRoutes.DELETE("/:id", middleware.RequirePermission(models.PermissionCustomerWrite), customerHandler.DeleteCustomer)
+		customerRoutes.POST("/:id/addresses", middleware.RequirePermission(models.PermissionCustomerWrite), customerHandler.AddAddress)
+		customerRoutes.PUT("/:id/addresses/:address_id", middleware.RequirePermission(models.PermissionCustomerWrite), customerHandler.UpdateAddress)
+		customerRoutes.DELETE("/:id/addresses/:address_id", middleware.RequirePermission(models.PermissionCustomerWrite), customerHandler.DeleteAddress)
+		customerRoutes.GET("/:id/orders", middleware.RequirePermission(models.PermissionCustomerRead, models.PermissionOrderRead), customerHandler.GetCustomerOrders)
+		customerRoutes.GET("/:id/outstanding", middleware.RequirePermission(models.PermissionCustomerRead), customerHandler.GetOutstanding)
 	}
 
 	// Order Routes
-	orderRoutes := api.Group("/orders").Use(middleware.AuthMiddleware())
+	orderRoutes := api.Group("/orders").Use(authMiddleware, warehouseScope)
 	{
-		orderRoutes.POST("", orderHandler.CreateOrder)
-		orderRoutes.GET("", orderHandler.GetOrders)
-		orderRoutes.PUT("/:id/status", orderHandler.UpdateOrderStatus)
-		orderRoutes.GET("/:id", orderHandler.GetOrderByID)
+		orderRoutes.POST("", middleware.RequirePermission(models.PermissionOrderWrite), orderHandler.CreateOrder)
+		orderRoutes.GET("", middleware.RequirePermission(models.PermissionOrderRead), orderHandler.GetOrders)
+		orderRoutes.PUT("/:id/status", middleware.RequirePermission(models.PermissionOrderWrite), orderHandler.UpdateOrderStatus)
+		orderRoutes.GET("/:id", middleware.RequirePermission(models.PermissionOrderRead), orderHandler.GetOrderByID)
+		orderRoutes.POST("/:id/pick", middleware.RequirePermission(models.PermissionOrderWrite), orderHandler.PickOrderItems)
+		orderRoutes.POST("/:id/shipments", middleware.RequirePermission(models.PermissionOrderWrite), orderHandler.ShipOrder)
+		// Ubah item order selama pending_payment / confirmed
+		orderRoutes.POST("/:id/items", middleware.RequirePermission(models.PermissionOrderWrite), orderHandler.AddOrderItem)
+		orderRoutes.PUT("/:id/items/:item_id", middleware.RequirePermission(models.PermissionOrderWrite), orderHandler.UpdateOrderItem)
+		orderRoutes.DELETE("/:id/items/:item_id", middleware.RequirePermission(models.PermissionOrderWrite), orderHandler.RemoveOrderItem)
+		orderRoutes.GET("/:id/item_changes", middleware.RequirePermission(models.PermissionOrderRead), orderHandler.GetOrderItemChanges)
 	}
 
 	// Dashboard Routes
-	dashboardRoutes := api.Group("/dashboard", middleware.AuthMiddleware())
+	dashboardRoutes := api.Group("/dashboard").Use(authMiddleware, warehouseScope)
 	{
-		dashboardRoutes.GET("/stats", dashboardHandler.GetDashboard)
+		dashboardRoutes.GET("/stats", middleware.RequirePermission(models.PermissionDashboardRead), dashboardHandler.GetDashboard)
 	}
 
 	return r